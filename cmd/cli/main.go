@@ -1,29 +1,28 @@
 package main
 
-func main() {
-	// if len(os.Args) <= 0 {
-	// 	log.Fatal("supply a har file")
-	// }
-
-	// data, err := ioutil.ReadFile("a.har")
-	// if err != nil {
-	// 	log.Fatal(err)
-	// }
+import (
+	"fmt"
+	"os"
+)
 
-	// results, err := requests.ParseHAR(data, func(my *requests.MyRequest) (string, string) {
-	// 	reqText := my.URL + " " + my.Method + " " + my.ReqBody + " " + my.ReqHeaders.EchoMatcher("cookies")
+// usage describes the subcommands of the CLI
+const usage = `usage: cli <command> [flags]
 
-	// 	respText := fmt.Sprintf("%d %d %s %s",
-	// 		my.ResStatus, my.RespSize, my.ResBody, my.ResHeaders.EchoFilter("date"),
-	// 	)
-	// 	return reqText, respText
-	// })
+commands:
+  migrate    apply, roll back or list the database schema migrations
+`
 
-	// if err != nil {
-	// 	log.Fatal(err)
-	// }
-
-	// s, _ := json.Marshal(&results)
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
 
-	// fmt.Println(s)
+	switch os.Args[1] {
+	case "migrate":
+		os.Exit(runMigrate(os.Args[2:]))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
 }
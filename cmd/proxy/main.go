@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"linn221/Requester/config"
+	"linn221/Requester/proxy"
+	"linn221/Requester/services"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8081", "address the proxy listens on")
+	programID := flag.Uint("program", 0, "ID of the program the captured requests belong to (required)")
+	title := flag.String("title", "", "title of the import job, defaults to the start time")
+	ignoreHeaders := flag.String("ignore-headers", "", "comma separated headers left out of the hashes, such as Date")
+	ignoreFields := flag.String("ignore-fields", "", "comma separated JSON body fields left out of the hashes, such as csrf_token")
+	dropOutOfScope := flag.Bool("drop-out-of-scope", false, "skip requests outside the program scope instead of storing them flagged")
+	caDir := flag.String("ca-dir", "", "directory of the CA certificate and key, defaults to the binary's directory")
+	flag.Parse()
+
+	if *programID == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Connect to database
+	db := config.ConnectDB()
+	if *caDir == "" {
+		*caDir = config.GetBaseDir()
+	}
+	ca, err := proxy.LoadOrCreateCA(*caDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	database := services.NewGormDatabaseAdapter(db)
+	captureService := services.NewCaptureService(database, services.NewEndpointService(database))
+	session, err := captureService.Start(context.Background(), uint(*programID), services.CaptureOptions{
+		Title:          *title,
+		IgnoredHeaders: splitList(*ignoreHeaders),
+		IgnoredFields:  splitList(*ignoreFields),
+		DropOutOfScope: *dropOutOfScope,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	handler := proxy.New(ca, session)
+	server := &http.Server{Addr: *addr, Handler: handler}
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	job := session.Job()
+	fmt.Printf("Proxy listening on %s, recording into import job %d %q\n", *addr, job.ID, job.Title)
+	fmt.Printf("Trust %s in the browser to record HTTPS, stop with Ctrl+C\n", ca.CertPath)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Print(err)
+	}
+
+	// Wait for the queued exchanges before the job is marked as done
+	handler.Close()
+	if err := session.Close(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Recorded import job %d\n", job.ID)
+}
+
+// splitList splits a comma separated flag value, dropping blanks
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
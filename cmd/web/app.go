@@ -1,6 +1,7 @@
 package main
 
 import (
+	"linn221/Requester/handlers"
 	"linn221/Requester/services"
 	"linn221/Requester/views/templates"
 	"net/http"
@@ -35,3 +36,12 @@ func (a *App) HandleMin(h func(w http.ResponseWriter, r *http.Request) error) ht
 		}
 	}
 }
+
+// HandleAPI adapts a JSON API handler, answering its errors with JSON error bodies
+func (a *App) HandleAPI(h func(w http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := h(w, r); err != nil {
+			handlers.WriteAPIError(w, err)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"linn221/Requester/internal/contract"
+	"linn221/Requester/internal/testdb"
+)
+
+// contractHAR is imported to check the import, job and request responses
+const contractHAR = `{"log": {"version": "1.2", "entries": [
+	{"startedDateTime": "2024-01-01T00:00:00Z", "time": 12.5,
+		"request": {"method": "GET", "url": "https://example.com/api/users/1?q=a", "headers": [{"name": "Host", "value": "example.com"}]},
+		"response": {"status": 200, "headers": [{"name": "Content-Type", "value": "application/json"}], "content": {"text": "{\"id\":1}"}}},
+	{"startedDateTime": "2024-01-01T00:00:01Z", "time": 8,
+		"request": {"method": "POST", "url": "https://example.com/api/users", "headers": [{"name": "Content-Type", "value": "application/json"}], "postData": {"text": "{\"name\":\"a\"}"}},
+		"response": {"status": 201, "headers": [{"name": "Content-Type", "value": "application/json"}], "content": {"text": "{\"id\":2}"}}}
+]}}`
+
+// contractClient calls the JSON API and checks every response against the spec
+type contractClient struct {
+	t    *testing.T
+	base string
+	spec *contract.Spec
+	http *http.Client
+}
+
+// TestAPIContract calls every route of the JSON API and checks the responses against openapi.yaml
+func TestAPIContract(t *testing.T) {
+	spec, err := contract.LoadSpec(filepath.Join("..", "..", "openapi.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ATTACHMENTS_DIR", filepath.Join(t.TempDir(), "attachments"))
+	db := testdb.Open(t)
+
+	const secret = "contract-secret"
+	mux := http.NewServeMux()
+	mountAPIRoutes(NewApp(db, "0", secret), mux)
+	server := httptest.NewServer(RecoveryMiddleware(mux))
+	defer server.Close()
+
+	jar, _ := cookiejar.New(nil)
+	c := &contractClient{
+		t:    t,
+		base: server.URL + "/api/v1",
+		spec: spec,
+		http: &http.Client{Jar: jar, Timeout: 30 * time.Second},
+	}
+
+	c.expectUndocumented("GET", "/programs", http.StatusUnauthorized)
+	c.expect("GET", "/start-session?secret="+url.QueryEscape(secret+"-wrong"), nil, "", http.StatusUnauthorized)
+	if _, ok := c.expect("GET", "/start-session?secret="+url.QueryEscape(secret), nil, "", http.StatusOK); !ok {
+		t.Fatal("could not start a session")
+	}
+	c.expectUndocumented("GET", "/no-such-route", http.StatusNotFound)
+
+	// programs
+	c.expect("GET", "/programs?page=1&per_page=10", nil, "", http.StatusOK)
+	c.expect("GET", "/programs?per_page=0", nil, "", http.StatusBadRequest)
+	c.expect("POST", "/programs", []byte(`{"name":`), "application/json", http.StatusBadRequest)
+	c.expect("POST", "/programs", jsonBody(map[string]string{"url": "https://example.com"}), "application/json", http.StatusBadRequest)
+	programID := c.createdID("/programs", map[string]string{
+		"name":    "contract check",
+		"url":     "https://example.com",
+		"domains": "example.com",
+		"note":    "created by the contract test",
+	})
+	if programID == 0 {
+		t.Fatal("could not create a program")
+	}
+	programPath := fmt.Sprintf("/programs/%d", programID)
+	c.expect("GET", programPath, nil, "", http.StatusOK)
+	c.expect("PUT", programPath, jsonBody(map[string]string{"name": "contract check", "domains": "example.com, *.example.com"}), "application/json", http.StatusOK)
+	c.expect("GET", "/programs/999999999", nil, "", http.StatusNotFound)
+	c.expect("GET", "/programs/abc", nil, "", http.StatusBadRequest)
+
+	c.checkNotes(programID)
+
+	// endpoints
+	endpoint := map[string]interface{}{
+		"program_id":   programID,
+		"domain":       "example.com",
+		"method":       "get",
+		"URI":          "/contract/{id}",
+		"EndpointType": "api",
+		"description":  "created by the contract test",
+	}
+	endpointID := c.createdID("/endpoints", endpoint)
+	c.expect("POST", "/endpoints", jsonBody(endpoint), "application/json", http.StatusConflict)
+	c.expect("POST", "/endpoints", jsonBody(map[string]interface{}{"program_id": programID, "domain": "example.com", "method": "get", "URI": "no-slash"}), "application/json", http.StatusBadRequest)
+	c.expect("GET", fmt.Sprintf("/endpoints?program_id=%d", programID), nil, "", http.StatusOK)
+	if endpointID != 0 {
+		endpointPath := fmt.Sprintf("/endpoints/%d", endpointID)
+		c.expect("GET", endpointPath, nil, "", http.StatusOK)
+		endpoint["EndpointType"] = "web"
+		c.expect("PUT", endpointPath, jsonBody(endpoint), "application/json", http.StatusOK)
+	}
+	c.expect("GET", "/endpoints/999999999", nil, "", http.StatusNotFound)
+
+	// requests and jobs
+	c.expect("GET", "/requests?order_by=latency&asc=false&per_page=5", nil, "", http.StatusOK)
+	c.expect("GET", "/requests?order_by=raw_sql", nil, "", http.StatusBadRequest)
+	c.expect("GET", "/requests/999999999", nil, "", http.StatusNotFound)
+	c.expect("GET", "/jobs?per_page=5", nil, "", http.StatusOK)
+	c.expect("GET", "/jobs/999999999", nil, "", http.StatusNotFound)
+	c.checkImport(programID)
+
+	// clean up
+	if endpointID != 0 {
+		c.expect("DELETE", fmt.Sprintf("/endpoints/%d", endpointID), nil, "", http.StatusNoContent)
+	}
+	c.expect("DELETE", programPath, nil, "", http.StatusNoContent)
+	c.expect("GET", programPath, nil, "", http.StatusNotFound)
+}
+
+// checkNotes adds, edits and deletes a note and an attachment of the program
+func (c *contractClient) checkNotes(programID uint) {
+	c.expect("POST", "/notes", jsonBody(map[string]interface{}{"reference_type": "programs", "reference_id": 999999999, "value": "x"}), "application/json", http.StatusBadRequest)
+	c.expect("POST", "/notes", jsonBody(map[string]interface{}{"reference_type": "programs", "reference_id": programID, "value": " "}), "application/json", http.StatusBadRequest)
+	noteID := c.createdID("/notes", map[string]interface{}{
+		"reference_type": "programs",
+		"reference_id":   programID,
+		"value":          "contract check note",
+	})
+	c.expect("GET", "/notes?type=program&search=contract+check&per_page=5", nil, "", http.StatusOK)
+	if noteID != 0 {
+		notePath := fmt.Sprintf("/notes/%d", noteID)
+		c.expect("GET", notePath, nil, "", http.StatusOK)
+		c.expect("PATCH", notePath+"?value=edited+contract+check+note", nil, "", http.StatusCreated)
+		c.expect("PATCH", notePath, nil, "", http.StatusBadRequest)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("reference_type", "programs")
+	form.WriteField("reference_id", fmt.Sprint(programID))
+	part, _ := form.CreateFormFile("file", "contract.txt")
+	part.Write([]byte("attached by the contract test\n"))
+	form.Close()
+	result, ok := c.expect("POST", "/attachments", body.Bytes(), form.FormDataContentType(), http.StatusCreated)
+	var attachment struct {
+		ID uint `json:"id"`
+	}
+	if ok {
+		json.Unmarshal(result, &attachment)
+	}
+
+	c.expect("GET", fmt.Sprintf("/programs/%d", programID), nil, "", http.StatusOK)
+	if attachment.ID != 0 {
+		c.expect("DELETE", fmt.Sprintf("/attachments?id=%d", attachment.ID), nil, "", http.StatusNoContent)
+	}
+	c.expect("DELETE", "/attachments?id=999999999", nil, "", http.StatusNotFound)
+	if noteID != 0 {
+		c.expect("DELETE", fmt.Sprintf("/notes/%d", noteID), nil, "", http.StatusNoContent)
+		c.expect("GET", fmt.Sprintf("/notes/%d", noteID), nil, "", http.StatusNotFound)
+	}
+}
+
+// checkImport uploads contractHAR to the program, waits for its job and checks the imported requests
+func (c *contractClient) checkImport(programID uint) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("program_id", fmt.Sprint(programID))
+	form.WriteField("title", "contract check")
+	part, _ := form.CreateFormFile("file", "contract.har")
+	part.Write([]byte(contractHAR))
+	form.Close()
+
+	result, ok := c.expect("POST", "/import-har", body.Bytes(), form.FormDataContentType(), http.StatusCreated)
+	if !ok {
+		return
+	}
+	var created struct {
+		ID uint `json:"id"`
+	}
+	if err := json.Unmarshal(result, &created); err != nil || created.ID == 0 {
+		c.t.Errorf("POST /import-har: no job id in %s", result)
+		return
+	}
+
+	jobPath := fmt.Sprintf("/jobs/%d", created.ID)
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		result, ok := c.expect("GET", jobPath, nil, "", http.StatusOK)
+		if !ok {
+			return
+		}
+		var job struct {
+			Status string `json:"status"`
+			Error  string `json:"error"`
+		}
+		json.Unmarshal(result, &job)
+		if job.Status == "failed" {
+			c.t.Errorf("GET %s: import failed: %s", jobPath, job.Error)
+			return
+		}
+		if job.Status == "done" {
+			break
+		}
+		if time.Now().After(deadline) {
+			c.t.Errorf("GET %s: import still %s after 30 seconds", jobPath, job.Status)
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	result, ok = c.expect("GET", fmt.Sprintf("/requests?job_id=%d&search=example&per_page=20", created.ID), nil, "", http.StatusOK)
+	if !ok {
+		return
+	}
+	var listed []struct {
+		ID uint `json:"id"`
+	}
+	json.Unmarshal(result, &listed)
+	if len(listed) != 2 {
+		c.t.Errorf("GET /requests?job_id=%d: listed %d requests, want 2", created.ID, len(listed))
+	}
+	for _, request := range listed {
+		c.expect("GET", fmt.Sprintf("/requests/%d", request.ID), nil, "", http.StatusOK)
+	}
+	c.expect("GET", fmt.Sprintf("/requests?program_id=%d&in_scope=true&hash_kind=body&order_by=sequence_number", programID), nil, "", http.StatusOK)
+}
+
+// createdID posts v as JSON, expecting 201 with the id of the created resource
+func (c *contractClient) createdID(path string, v interface{}) uint {
+	result, ok := c.expect("POST", path, jsonBody(v), "application/json", http.StatusCreated)
+	if !ok {
+		return 0
+	}
+	var created struct {
+		ID uint `json:"id"`
+	}
+	if err := json.Unmarshal(result, &created); err != nil || created.ID == 0 {
+		c.t.Errorf("POST %s: no id in %s", path, result)
+		return 0
+	}
+	return created.ID
+}
+
+// expect calls the API, then checks the status against want and the response against the spec
+func (c *contractClient) expect(method, path string, body []byte, contentType string, want int) ([]byte, bool) {
+	c.t.Helper()
+	resp, data, err := c.do(method, path, body, contentType)
+	if err != nil {
+		c.t.Errorf("%s %s: %v", method, path, err)
+		return nil, false
+	}
+	if resp.StatusCode != want {
+		c.t.Errorf("%s %s: expected %d, got %d: %s", method, path, want, resp.StatusCode, strings.TrimSpace(string(data)))
+		return data, false
+	}
+	specPath, _, _ := strings.Cut(path, "?")
+	if err := c.spec.CheckResponse(method, specPath, resp.StatusCode, resp.Header.Get("Content-Type"), data); err != nil {
+		c.t.Error(err)
+		return data, false
+	}
+	return data, true
+}
+
+// expectUndocumented calls a path the spec does not describe, expecting want and a JSON error body
+func (c *contractClient) expectUndocumented(method, path string, want int) {
+	c.t.Helper()
+	resp, data, err := c.do(method, path, nil, "")
+	if err != nil {
+		c.t.Errorf("%s %s: %v", method, path, err)
+		return
+	}
+	if resp.StatusCode != want {
+		c.t.Errorf("%s %s: expected %d, got %d", method, path, want, resp.StatusCode)
+		return
+	}
+	if err := c.spec.CheckError(resp.StatusCode, resp.Header.Get("Content-Type"), data); err != nil {
+		c.t.Errorf("%s %s: %v", method, path, err)
+	}
+}
+
+// do sends a request to the API and reads the whole response
+func (c *contractClient) do(method, path string, body []byte, contentType string) (*http.Response, []byte, error) {
+	req, err := http.NewRequest(method, c.base+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read body: %v", err)
+	}
+	return resp, data, nil
+}
+
+// jsonBody encodes v as a JSON request body
+func jsonBody(v interface{}) []byte {
+	data, _ := json.Marshal(v)
+	return data
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"runtime/debug"
 	"strconv"
+	"strings"
 )
 
 func MakeAuthMiddleware(secret string) func(http.Handler) http.Handler {
@@ -32,6 +33,26 @@ func MakeAuthMiddleware(secret string) func(http.Handler) http.Handler {
 	}
 }
 
+// MakeAPIAuthMiddleware accepts API calls carrying the secret in the session cookie or as a bearer token,
+// answering the others with a JSON 401 instead of a redirect
+func MakeAPIAuthMiddleware(secret string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if cookie, err := r.Cookie("secret"); err == nil {
+				provided = cookie.Value
+			}
+			if provided != secret {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"a valid secret is required"}` + "\n"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -53,6 +74,10 @@ func makeDashboardRoutes(app *App, mux *http.ServeMux) {
 	requestsHandler := handlers.NewRequestsHandler(app.services)
 	endpointsHandler := handlers.NewEndpointsHandler(app.services)
 	programsHandler := handlers.NewProgramsHandler(app.services)
+	fuzzHandler := handlers.NewFuzzHandler(app.services)
+	authzHandler := handlers.NewAuthzHandler(app.services)
+	clustersHandler := handlers.NewClustersHandler(app.services)
+	notesHandler := handlers.NewNotesHandler(app.services)
 
 	// Home page - check if it's an HTMX request
 	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
@@ -96,6 +121,9 @@ func makeDashboardRoutes(app *App, mux *http.ServeMux) {
 	mux.HandleFunc("POST /programs", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
 		return programsHandler.HandleProgramStore(w, r)
 	}))
+	mux.HandleFunc("GET /programs/{id}", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return programsHandler.HandleProgramDetail(w, r)
+	}))
 	mux.HandleFunc("GET /programs/{id}/edit", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
 		return programsHandler.HandleProgramEdit(w, r)
 	}))
@@ -105,12 +133,61 @@ func makeDashboardRoutes(app *App, mux *http.ServeMux) {
 	mux.HandleFunc("DELETE /programs/{id}", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
 		return programsHandler.HandleProgramDelete(w, r)
 	}))
+	mux.HandleFunc("POST /programs/{id}/regroup", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return programsHandler.HandleProgramRegroup(w, r)
+	}))
+
+	// API documentation import and coverage per program
+	mux.HandleFunc("GET /programs/{id}/openapi", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return programsHandler.HandleOpenAPIForm(w, r)
+	}))
+	mux.HandleFunc("POST /programs/{id}/openapi", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return programsHandler.HandleOpenAPIImport(w, r)
+	}))
+	mux.HandleFunc("GET /programs/{id}/coverage", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return programsHandler.HandleProgramCoverage(w, r)
+	}))
+
+	// Program identities - credential sets swapped into requests by authorization runs
+	mux.HandleFunc("GET /programs/{id}/identities", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return authzHandler.HandleIdentities(w, r)
+	}))
+	mux.HandleFunc("POST /programs/{id}/identities", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return authzHandler.HandleIdentityStore(w, r)
+	}))
+	mux.HandleFunc("DELETE /programs/{id}/identities/{identityID}", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return authzHandler.HandleIdentityDelete(w, r)
+	}))
+
+	// Authorization runs - an import job or endpoint resent as each identity, results as a matrix
+	mux.HandleFunc("GET /authz/new", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return authzHandler.HandleAuthzForm(w, r)
+	}))
+	mux.HandleFunc("POST /authz", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return authzHandler.HandleAuthzStart(w, r)
+	}))
+	mux.HandleFunc("GET /authz/{id}", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return authzHandler.HandleAuthzMatrix(w, r)
+	}))
 
 	// Import jobs list - check if it's an HTMX request
 	mux.HandleFunc("GET /import-jobs", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
 		return importJobsHandler.HandleImportJobsList(w, r)
 	}))
 
+	// Rehash stored requests of an import job or program with new ignored headers and body fields
+	mux.HandleFunc("GET /rehash/new", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return importJobsHandler.HandleRehashForm(w, r)
+	}))
+	mux.HandleFunc("POST /rehash", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return importJobsHandler.HandleRehashStart(w, r)
+	}))
+
+	// Jobs listing as JSON, or a single job's progress for HTMX polling
+	mux.HandleFunc("GET /jobs", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return importJobsHandler.HandleJobs(w, r)
+	}))
+
 	// Endpoints list - check if it's an HTMX request
 	mux.HandleFunc("GET /endpoints", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
 		return endpointsHandler.HandleEndpointsList(w, r)
@@ -126,10 +203,142 @@ func makeDashboardRoutes(app *App, mux *http.ServeMux) {
 		return requestsHandler.HandleRequestsList(w, r)
 	}))
 
+	// Requests export - downloads the filtered selection as HAR 1.2
+	mux.HandleFunc("GET /requests/export.har", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return requestsHandler.HandleRequestsExport(w, r)
+	}))
+
+	// Hash clusters - groups the requests of an import job, endpoint or program by a hash
+	mux.HandleFunc("GET /clusters", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return clustersHandler.HandleClusters(w, r)
+	}))
+	mux.HandleFunc("GET /clusters/requests", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return clustersHandler.HandleClusterRequests(w, r)
+	}))
+
+	// Notes and attachments of programs, endpoints and requests
+	mux.HandleFunc("GET /notes", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return notesHandler.HandleNoteSearch(w, r)
+	}))
+	mux.HandleFunc("GET /notes/panel", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return notesHandler.HandleNotesPanel(w, r)
+	}))
+	mux.HandleFunc("POST /notes", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return notesHandler.HandleNoteStore(w, r)
+	}))
+	mux.HandleFunc("PUT /notes/{id}", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return notesHandler.HandleNoteUpdate(w, r)
+	}))
+	mux.HandleFunc("DELETE /notes/{id}", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return notesHandler.HandleNoteDelete(w, r)
+	}))
+	mux.HandleFunc("POST /attachments", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return notesHandler.HandleAttachmentStore(w, r)
+	}))
+	mux.HandleFunc("GET /attachments/{id}", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return notesHandler.HandleAttachmentDownload(w, r)
+	}))
+	mux.HandleFunc("DELETE /attachments/{id}", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return notesHandler.HandleAttachmentDelete(w, r)
+	}))
+
+	// Request diff - compares two requests side by side
+	mux.HandleFunc("GET /requests/diff", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return requestsHandler.HandleRequestDiff(w, r)
+	}))
+
 	// Request detail - check if it's an HTMX request
 	mux.HandleFunc("GET /requests/detail/{id}", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
 		return handleRequestDetail(app, w, r)
 	}))
+
+	// Request replay - edit and resend a stored request, keeping the result linked to it
+	mux.HandleFunc("GET /requests/detail/{id}/replay", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return requestsHandler.HandleReplayPanel(w, r)
+	}))
+	mux.HandleFunc("POST /requests/detail/{id}/replay", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return requestsHandler.HandleReplay(w, r)
+	}))
+
+	// Request fuzzing - payloads at insertion points of a stored request, results per run
+	mux.HandleFunc("GET /requests/detail/{id}/fuzz", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return fuzzHandler.HandleFuzzForm(w, r)
+	}))
+	mux.HandleFunc("POST /requests/detail/{id}/fuzz", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return fuzzHandler.HandleFuzzStart(w, r)
+	}))
+	mux.HandleFunc("GET /fuzz/{id}", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return fuzzHandler.HandleFuzzResults(w, r)
+	}))
+
+	// Request snippet - renders the request as code in the chosen format
+	mux.HandleFunc("GET /requests/detail/{id}/export", app.HandleMin(func(w http.ResponseWriter, r *http.Request) error {
+		return requestsHandler.HandleRequestSnippet(w, r)
+	}))
+}
+
+// makeAPIRoutes registers the JSON API described by openapi.yaml, mounted under /api/v1
+func makeAPIRoutes(app *App, mux *http.ServeMux) {
+	apiHandler := handlers.NewAPIHandler(app.services)
+
+	// Programs
+	mux.HandleFunc("GET /programs", app.HandleAPI(apiHandler.HandleProgramsList))
+	mux.HandleFunc("POST /programs", app.HandleAPI(apiHandler.HandleProgramCreate))
+	mux.HandleFunc("GET /programs/{id}", app.HandleAPI(apiHandler.HandleProgramDetail))
+	mux.HandleFunc("PUT /programs/{id}", app.HandleAPI(apiHandler.HandleProgramUpdate))
+	mux.HandleFunc("DELETE /programs/{id}", app.HandleAPI(apiHandler.HandleProgramDelete))
+
+	// Endpoints
+	mux.HandleFunc("GET /endpoints", app.HandleAPI(apiHandler.HandleEndpointsList))
+	mux.HandleFunc("POST /endpoints", app.HandleAPI(apiHandler.HandleEndpointCreate))
+	mux.HandleFunc("GET /endpoints/{id}", app.HandleAPI(apiHandler.HandleEndpointDetail))
+	mux.HandleFunc("PUT /endpoints/{id}", app.HandleAPI(apiHandler.HandleEndpointUpdate))
+	mux.HandleFunc("DELETE /endpoints/{id}", app.HandleAPI(apiHandler.HandleEndpointDelete))
+
+	// Requests
+	mux.HandleFunc("GET /requests", app.HandleAPI(apiHandler.HandleRequestsList))
+	mux.HandleFunc("GET /requests/{id}", app.HandleAPI(apiHandler.HandleRequestDetail))
+
+	// Notes and attachments
+	mux.HandleFunc("POST /notes", app.HandleAPI(apiHandler.HandleNoteCreate))
+	mux.HandleFunc("GET /notes", app.HandleAPI(apiHandler.HandleNotesList))
+	mux.HandleFunc("GET /notes/{id}", app.HandleAPI(apiHandler.HandleNoteDetail))
+	mux.HandleFunc("PATCH /notes/{id}", app.HandleAPI(apiHandler.HandleNoteUpdate))
+	mux.HandleFunc("DELETE /notes/{id}", app.HandleAPI(apiHandler.HandleNoteDelete))
+	mux.HandleFunc("POST /attachments", app.HandleAPI(apiHandler.HandleAttachmentCreate))
+	mux.HandleFunc("DELETE /attachments", app.HandleAPI(apiHandler.HandleAttachmentDelete))
+
+	// Imports and jobs
+	mux.HandleFunc("POST /import-har", app.HandleAPI(apiHandler.HandleImportHAR))
+	mux.HandleFunc("GET /jobs", app.HandleAPI(apiHandler.HandleJobsList))
+	mux.HandleFunc("GET /jobs/{id}", app.HandleAPI(apiHandler.HandleJobDetail))
+
+	// Everything else under the prefix is a JSON 404
+	mux.HandleFunc("/", app.HandleAPI(apiHandler.HandleNotFound))
+}
+
+// mountAPIRoutes mounts the JSON API under /api/v1 behind its session start, which answers 401 for a wrong secret
+func mountAPIRoutes(a *App, mux *http.ServeMux) {
+	apiMux := http.NewServeMux()
+	makeAPIRoutes(a, apiMux)
+	mux.HandleFunc("GET /api/v1/start-session", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("secret") != a.secret {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"wrong secret"}` + "\n"))
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     "secret",
+			Value:    a.secret,
+			MaxAge:   10 * 60 * 60,
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		})
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", MakeAPIAuthMiddleware(a.secret)(apiMux)))
 }
 
 func handleImport(app *App, w http.ResponseWriter, r *http.Request) error {
@@ -139,28 +348,25 @@ func handleImport(app *App, w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	// Import HAR using service
-	result, err := app.services.ImportService.ImportHAR(r.Context(), *importReq)
+	// Queue the import using service
+	result, err := app.services.ImportService.Import(r.Context(), *importReq)
 	if err != nil {
 		return err
 	}
 
-	// Create summary (TODO: Get actual stats from service)
-	summary := templates.ImportSummary{
-		TotalRequests:   result.RequestCount,
-		UniqueEndpoints: 0, // TODO: Get from service
-		UniqueDomains:   result.UniqueDomains,
-		Methods:         make(map[string]int),
-		StatusCodes:     make(map[string]int),
+	// Fetch the queued job so the result page can poll its progress
+	importJob, err := app.services.ImportJobService.GetImportJobByID(r.Context(), result.ImportJobID)
+	if err != nil {
+		return err
 	}
 
 	// Check if it's an HTMX request
 	if r.Header.Get("HX-Request") == "true" {
 		// HTMX request - return just the content
-		return templates.ImportResult(importReq.Title, result.RequestCount, result.UniqueDomains, summary, result.ImportJobID).Render(r.Context(), w)
+		return templates.ImportResult(*importJob).Render(r.Context(), w)
 	} else {
 		// Direct visit - return full page with layout
-		return templates.ImportResultPage(importReq.Title, result.RequestCount, result.UniqueDomains, summary, result.ImportJobID).Render(r.Context(), w)
+		return templates.ImportResultPage(*importJob).Render(r.Context(), w)
 	}
 }
 
@@ -216,6 +422,9 @@ func (a *App) Serve() {
 		}
 	})
 
+	// JSON API, with its own session start answering 401 for a wrong secret
+	mountAPIRoutes(a, mux)
+
 	mux.HandleFunc("GET /secret-required", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Secrets required. Open the link from running the app"))
 	})
@@ -6,8 +6,13 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"linn221/Requester/migrations"
+	"linn221/Requester/store"
+
+	"github.com/glebarez/sqlite"
 	"github.com/joho/godotenv"
 
 	"gorm.io/driver/mysql"
@@ -18,47 +23,99 @@ import (
 
 const SearchLimit = 10
 
+// Database drivers selectable with DB_DRIVER
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
 var _BASE_DIR string
 
+// ConnectDB loads the .env next to the executable, connects to the database and applies the pending migrations
 func ConnectDB() *gorm.DB {
-	// Load env from .env
-	// godotenv.Load(".env")
-
 	dir, err := filepath.Abs(filepath.Dir(os.Args[0]))
 	if err != nil {
 		log.Fatal(err)
 	}
-	_BASE_DIR = dir
-	environmentPath := filepath.Join(dir, ".env")
-	err = godotenv.Load(environmentPath)
-	if err != nil {
+	if err := LoadEnvFile(filepath.Join(dir, ".env")); err != nil {
 		log.Fatal(err)
 	}
 
-	return connectDatabase()
+	db, err := OpenDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	applied, err := migrations.Up(db)
+	for _, migration := range applied {
+		log.Printf("applied migration %d %s", migration.Version, migration.Name)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return db
 	// connectRedis()
 }
+
+// LoadEnvFile loads the environment from path, files such as the SQLite database default to its directory
+func LoadEnvFile(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if err := godotenv.Load(path); err != nil {
+		return fmt.Errorf("failed to load %s: %v", path, err)
+	}
+	_BASE_DIR = filepath.Dir(path)
+	return nil
+}
+
 func GetBaseDir() string {
 	return _BASE_DIR
 }
 
-func connectDatabase() *gorm.DB {
-	databaseConfig := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?multiStatements=true&parseTime=true",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_NAME"),
-	)
-
-	var err error
-	db, err := gorm.Open(mysql.Open(databaseConfig), initConfig())
+// OpenDB connects to the database of DB_DRIVER without touching its schema, see the migrations package
+func OpenDB() (*gorm.DB, error) {
+	dialector, err := openDialector(os.Getenv("DB_DRIVER"))
+	if err != nil {
+		return nil, err
+	}
 
+	db, err := gorm.Open(dialector, initConfig())
 	if err != nil {
-		panic("Fail To Connect Database")
+		return nil, fmt.Errorf("failed to connect database: %v", err)
+	}
+	// Response bodies live in the body_blobs table
+	if err := db.Use(store.BodyStore{}); err != nil {
+		return nil, fmt.Errorf("failed to register the body store: %v", err)
+	}
+	return db, nil
+}
+
+// openDialector picks the database of DB_DRIVER: mysql (the default) reads DB_USER, DB_PASSWORD, DB_HOST, DB_PORT
+// and DB_NAME, sqlite keeps everything in the file at DB_PATH, requester.db next to the executable by default
+func openDialector(driver string) (gorm.Dialector, error) {
+	switch strings.ToLower(strings.TrimSpace(driver)) {
+	case "", DriverMySQL:
+		databaseConfig := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?multiStatements=true&parseTime=true",
+			os.Getenv("DB_USER"),
+			os.Getenv("DB_PASSWORD"),
+			os.Getenv("DB_HOST"),
+			os.Getenv("DB_PORT"),
+			os.Getenv("DB_NAME"),
+		)
+		return mysql.Open(databaseConfig), nil
+	case DriverSQLite:
+		path := os.Getenv("DB_PATH")
+		if path == "" {
+			path = filepath.Join(_BASE_DIR, "requester.db")
+		}
+		// Wait for the lock instead of failing while import workers write, and let readers run alongside a writer.
+		// Transactions take the write lock when they begin: a deferred one upgrading from a read lock while another
+		// connection writes fails with SQLITE_BUSY right away, busy_timeout does not apply to the upgrade.
+		return sqlite.Open(path + "?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_txlock=immediate"), nil
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q, use %s or %s", driver, DriverMySQL, DriverSQLite)
 	}
-	migrate(db)
-	return db
 }
 
 // InitConfig Initialize Config
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"linn221/Requester/services"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Pagination defaults of the JSON API
+const (
+	defaultPerPage = 50
+	maxPerPage     = 500
+)
+
+// APIHandler serves the JSON API described by openapi.yaml under /api/v1
+type APIHandler struct {
+	services *services.ServiceContainer
+}
+
+// NewAPIHandler creates a new APIHandler
+func NewAPIHandler(services *services.ServiceContainer) *APIHandler {
+	return &APIHandler{
+		services: services,
+	}
+}
+
+// HandleNotFound answers paths under /api/v1 that no route matches
+func (h *APIHandler) HandleNotFound(w http.ResponseWriter, r *http.Request) error {
+	return newAPIError(http.StatusNotFound, "no API route for %s %s", r.Method, r.URL.Path)
+}
+
+// apiError is an error the API responds with using its status code
+type apiError struct {
+	status  int
+	message string
+}
+
+// Error returns the message of the error
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// newAPIError creates an error answered with status
+func newAPIError(status int, format string, args ...interface{}) error {
+	return &apiError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// errorResponse is the JSON body of every API error, as described by the Error schema in openapi.yaml
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// WriteAPIError answers err as a JSON error body. The status comes from an apiError, or from the
+// service errors for invalid input, conflicts and missing records, anything else is a server error.
+func WriteAPIError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var apiErr *apiError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.status
+	case errors.Is(err, services.ErrInvalid):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrConflict):
+		status = http.StatusConflict
+	case services.IsNotFound(err):
+		status = http.StatusNotFound
+	}
+	if status == http.StatusInternalServerError {
+		log.Printf("[API ERROR] %v", err)
+	}
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// writeJSON answers v as a JSON body with status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(v)
+}
+
+// decodeJSON reads the JSON request body into v, rejecting unknown fields
+func decodeJSON(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return newAPIError(http.StatusBadRequest, "invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+// pathID parses the {id} path value
+func pathID(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return 0, newAPIError(http.StatusBadRequest, "invalid ID %q", r.PathValue("id"))
+	}
+	return uint(id), nil
+}
+
+// queryID parses an optional ID query parameter, treating an empty value as 0
+func queryID(r *http.Request, name string) (uint, error) {
+	id, err := parseOptionalID(r.URL.Query().Get(name))
+	if err != nil {
+		return 0, newAPIError(http.StatusBadRequest, "invalid %s: %v", name, err)
+	}
+	return id, nil
+}
+
+// pagination is the page of a list requested with the page and per_page query parameters
+type pagination struct {
+	page    int
+	perPage int
+}
+
+// parsePagination reads the 1-based page and the page size, defaulting to the first page of defaultPerPage items
+func parsePagination(r *http.Request) (pagination, error) {
+	p := pagination{page: 1, perPage: defaultPerPage}
+	if value := r.URL.Query().Get("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return p, newAPIError(http.StatusBadRequest, "page must be a positive number")
+		}
+		p.page = page
+	}
+	if value := r.URL.Query().Get("per_page"); value != "" {
+		perPage, err := strconv.Atoi(value)
+		if err != nil || perPage < 1 || perPage > maxPerPage {
+			return p, newAPIError(http.StatusBadRequest, "per_page must be between 1 and %d", maxPerPage)
+		}
+		p.perPage = perPage
+	}
+	return p, nil
+}
+
+// offset returns the number of items before the page
+func (p pagination) offset() int {
+	return (p.page - 1) * p.perPage
+}
+
+// setHeaders reports the page and the total number of items in the X-Total-Count, X-Page and X-Per-Page headers
+func (p pagination) setHeaders(w http.ResponseWriter, total int64) {
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	w.Header().Set("X-Page", strconv.Itoa(p.page))
+	w.Header().Set("X-Per-Page", strconv.Itoa(p.perPage))
+}
+
+// paginate returns the page of items already loaded in memory and sets the pagination headers
+func paginate[T any](w http.ResponseWriter, p pagination, items []T) []T {
+	p.setHeaders(w, int64(len(items)))
+	start := min(p.offset(), len(items))
+	end := min(start+p.perPage, len(items))
+	return items[start:end]
+}
+
+// formatTimestamp formats a unix timestamp as the date-time strings of the API
+func formatTimestamp(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
+
+// idResponse is the body of a created resource
+type idResponse struct {
+	ID uint `json:"id"`
+}
+
+// noteResponse is a note of a program, endpoint or request, as described by the Note schema in openapi.yaml
+type noteResponse struct {
+	ID            uint   `json:"id"`
+	ReferenceType string `json:"reference_type"`
+	ReferenceID   uint   `json:"reference_id"`
+	Value         string `json:"value"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+// attachmentResponse is a file attached to a program, endpoint or request, as described by the Attachment schema
+type attachmentResponse struct {
+	ID       uint   `json:"id"`
+	Filename string `json:"filename"`
+	URL      string `json:"url"`
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"linn221/Requester/requests"
+	"net/http"
+	"strings"
+)
+
+// endpointInput is the body of POST /endpoints and PUT /endpoints/{id}, as described by the EndpointInput schema
+type endpointInput struct {
+	Domain       string `json:"domain"`
+	ProgramID    uint   `json:"program_id"`
+	Method       string `json:"method"`
+	URI          string `json:"URI"`
+	EndpointType string `json:"EndpointType"`
+	Description  string `json:"description"`
+}
+
+// endpoint builds the endpoint described by the input, an empty type is guessed from the URI
+func (in endpointInput) endpoint(id uint) (*requests.Endpoint, error) {
+	endpoint := &requests.Endpoint{
+		ID:     id,
+		Method: in.Method,
+		Domain: strings.TrimSpace(in.Domain),
+		URI:    strings.TrimSpace(in.URI),
+		Notes:  strings.TrimSpace(in.Description),
+	}
+	if in.ProgramID != 0 {
+		programID := in.ProgramID
+		endpoint.ProgramID = &programID
+	}
+	switch strings.ToLower(in.EndpointType) {
+	case "":
+	case "web":
+		endpoint.EndpointType = requests.EndpointTypeWeb
+	case "api":
+		endpoint.EndpointType = requests.EndpointTypeAPI
+	case "graphql":
+		endpoint.EndpointType = requests.EndpointTypeGraphQL
+	default:
+		return nil, newAPIError(http.StatusBadRequest, "EndpointType must be web, api or graphql")
+	}
+	return endpoint, nil
+}
+
+// endpointListResponse is an endpoint of GET /endpoints, as described by the EndpointList schema
+type endpointListResponse struct {
+	ID           uint   `json:"id"`
+	ProgramID    uint   `json:"program_id"`
+	Domain       string `json:"domain"`
+	URI          string `json:"URI"`
+	Method       string `json:"method"`
+	EndpointType string `json:"EndpointType"`
+	Source       string `json:"source"`
+}
+
+// newEndpointListResponse converts an endpoint to its listing representation
+func newEndpointListResponse(endpoint requests.Endpoint) endpointListResponse {
+	response := endpointListResponse{
+		ID:           endpoint.ID,
+		Domain:       endpoint.Domain,
+		URI:          endpoint.URI,
+		Method:       endpoint.Method,
+		EndpointType: strings.ToLower(string(endpoint.EndpointType)),
+		Source:       string(endpoint.Source),
+	}
+	if endpoint.ProgramID != nil {
+		response.ProgramID = *endpoint.ProgramID
+	}
+	return response
+}
+
+// endpointDetailResponse is the body of GET /endpoints/{id}, as described by the EndpointDetail schema
+type endpointDetailResponse struct {
+	endpointListResponse
+	Description string               `json:"description"`
+	Notes       []noteResponse       `json:"notes"`
+	Attachments []attachmentResponse `json:"attachments"`
+}
+
+// HandleEndpointsList handles GET /api/v1/endpoints?program_id=
+func (h *APIHandler) HandleEndpointsList(w http.ResponseWriter, r *http.Request) error {
+	page, err := parsePagination(r)
+	if err != nil {
+		return err
+	}
+	programID, err := queryID(r, "program_id")
+	if err != nil {
+		return err
+	}
+
+	var endpoints []requests.Endpoint
+	if programID != 0 {
+		endpoints, err = h.services.EndpointService.GetEndpointsByProgram(r.Context(), programID)
+	} else {
+		endpoints, err = h.services.EndpointService.GetAllEndpoints(r.Context())
+	}
+	if err != nil {
+		return err
+	}
+
+	items := make([]endpointListResponse, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		items = append(items, newEndpointListResponse(endpoint))
+	}
+	return writeJSON(w, http.StatusOK, paginate(w, page, items))
+}
+
+// HandleEndpointCreate handles POST /api/v1/endpoints
+func (h *APIHandler) HandleEndpointCreate(w http.ResponseWriter, r *http.Request) error {
+	var input endpointInput
+	if err := decodeJSON(r, &input); err != nil {
+		return err
+	}
+	endpoint, err := input.endpoint(0)
+	if err != nil {
+		return err
+	}
+	if err := h.services.EndpointService.CreateEndpoint(r.Context(), endpoint); err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, idResponse{ID: endpoint.ID})
+}
+
+// HandleEndpointDetail handles GET /api/v1/endpoints/{id}
+func (h *APIHandler) HandleEndpointDetail(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r)
+	if err != nil {
+		return err
+	}
+	endpoint, err := h.services.EndpointService.GetEndpointByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	notes, attachments, err := h.referenceResponses(r.Context(), requests.ReferenceEndpoints, endpoint.ID)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, endpointDetailResponse{
+		endpointListResponse: newEndpointListResponse(*endpoint),
+		Description:          endpoint.Notes,
+		Notes:                notes,
+		Attachments:          attachments,
+	})
+}
+
+// HandleEndpointUpdate handles PUT /api/v1/endpoints/{id}
+func (h *APIHandler) HandleEndpointUpdate(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r)
+	if err != nil {
+		return err
+	}
+	if _, err := h.services.EndpointService.GetEndpointByID(r.Context(), id); err != nil {
+		return err
+	}
+
+	var input endpointInput
+	if err := decodeJSON(r, &input); err != nil {
+		return err
+	}
+	endpoint, err := input.endpoint(id)
+	if err != nil {
+		return err
+	}
+	if err := h.services.EndpointService.UpdateEndpoint(r.Context(), endpoint); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// HandleEndpointDelete handles DELETE /api/v1/endpoints/{id}, refusing endpoints that still have requests
+func (h *APIHandler) HandleEndpointDelete(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r)
+	if err != nil {
+		return err
+	}
+	if _, err := h.services.EndpointService.GetEndpointByID(r.Context(), id); err != nil {
+		return err
+	}
+	if err := h.services.EndpointService.DeleteEndpoint(r.Context(), id); err != nil {
+		return err
+	}
+	if err := h.deleteReferenced(r.Context(), requests.ReferenceEndpoints, id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
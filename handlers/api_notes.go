@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"linn221/Requester/requests"
+	"linn221/Requester/services"
+	"net/http"
+)
+
+// noteInput is the body of POST /notes
+type noteInput struct {
+	ReferenceType string `json:"reference_type"`
+	ReferenceID   uint   `json:"reference_id"`
+	Value         string `json:"value"`
+}
+
+// newNoteResponse converts a note to its API representation
+func newNoteResponse(note requests.Note) noteResponse {
+	return noteResponse{
+		ID:            note.ID,
+		ReferenceType: string(note.ReferenceType),
+		ReferenceID:   note.ReferenceID,
+		Value:         note.Value,
+		CreatedAt:     formatTimestamp(note.CreatedAt),
+		UpdatedAt:     formatTimestamp(note.UpdatedAt),
+	}
+}
+
+// newAttachmentResponse converts an attachment to its API representation, linking the dashboard download
+func newAttachmentResponse(attachment requests.Attachment) attachmentResponse {
+	return attachmentResponse{
+		ID:       attachment.ID,
+		Filename: attachment.Filename,
+		URL:      fmt.Sprintf("/dashboard/attachments/%d", attachment.ID),
+	}
+}
+
+// referenceResponses returns the notes and attachments of a program, endpoint or request for its detail response
+func (h *APIHandler) referenceResponses(ctx context.Context, referenceType requests.ReferenceType, referenceID uint) ([]noteResponse, []attachmentResponse, error) {
+	notes, err := h.services.NoteService.GetNotes(ctx, referenceType, referenceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	attachments, err := h.services.AttachmentService.GetAttachments(ctx, referenceType, referenceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	noteItems := make([]noteResponse, 0, len(notes))
+	for _, note := range notes {
+		noteItems = append(noteItems, newNoteResponse(note))
+	}
+	attachmentItems := make([]attachmentResponse, 0, len(attachments))
+	for _, attachment := range attachments {
+		attachmentItems = append(attachmentItems, newAttachmentResponse(attachment))
+	}
+	return noteItems, attachmentItems, nil
+}
+
+// deleteReferenced deletes the notes and attachments of a deleted program or endpoint
+func (h *APIHandler) deleteReferenced(ctx context.Context, referenceType requests.ReferenceType, referenceID uint) error {
+	if err := h.services.NoteService.DeleteNotesOf(ctx, referenceType, referenceID); err != nil {
+		return err
+	}
+	return h.services.AttachmentService.DeleteAttachmentsOf(ctx, referenceType, referenceID)
+}
+
+// HandleNoteCreate handles POST /api/v1/notes
+func (h *APIHandler) HandleNoteCreate(w http.ResponseWriter, r *http.Request) error {
+	var input noteInput
+	if err := decodeJSON(r, &input); err != nil {
+		return err
+	}
+	referenceType, ok := requests.ParseReferenceType(input.ReferenceType)
+	if !ok {
+		return newAPIError(http.StatusBadRequest, "reference_type must be programs, endpoints or requests")
+	}
+
+	note := &requests.Note{ReferenceType: referenceType, ReferenceID: input.ReferenceID, Value: input.Value}
+	if err := h.services.NoteService.CreateNote(r.Context(), note); err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, idResponse{ID: note.ID})
+}
+
+// HandleNotesList handles GET /api/v1/notes?type=&search=, most recently updated first
+func (h *APIHandler) HandleNotesList(w http.ResponseWriter, r *http.Request) error {
+	page, err := parsePagination(r)
+	if err != nil {
+		return err
+	}
+	filter := services.NoteFilter{Search: r.URL.Query().Get("search")}
+	if value := r.URL.Query().Get("type"); value != "" {
+		referenceType, ok := requests.ParseReferenceType(value)
+		if !ok {
+			return newAPIError(http.StatusBadRequest, "type must be program, endpoint or request")
+		}
+		filter.ReferenceType = referenceType
+	}
+
+	notes, total, err := h.services.NoteService.SearchNotes(r.Context(), filter, page.offset(), page.perPage)
+	if err != nil {
+		return err
+	}
+	page.setHeaders(w, total)
+
+	items := make([]noteResponse, 0, len(notes))
+	for _, note := range notes {
+		items = append(items, newNoteResponse(note))
+	}
+	return writeJSON(w, http.StatusOK, items)
+}
+
+// HandleNoteDetail handles GET /api/v1/notes/{id}
+func (h *APIHandler) HandleNoteDetail(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r)
+	if err != nil {
+		return err
+	}
+	note, err := h.services.NoteService.GetNoteByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newNoteResponse(*note))
+}
+
+// HandleNoteUpdate handles PATCH /api/v1/notes/{id}?value=, answering 201 without a body as the spec describes
+func (h *APIHandler) HandleNoteUpdate(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r)
+	if err != nil {
+		return err
+	}
+	if _, err := h.services.NoteService.GetNoteByID(r.Context(), id); err != nil {
+		return err
+	}
+	if err := h.services.NoteService.UpdateNote(r.Context(), id, r.URL.Query().Get("value")); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// HandleNoteDelete handles DELETE /api/v1/notes/{id}
+func (h *APIHandler) HandleNoteDelete(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r)
+	if err != nil {
+		return err
+	}
+	if _, err := h.services.NoteService.GetNoteByID(r.Context(), id); err != nil {
+		return err
+	}
+	if err := h.services.NoteService.DeleteNote(r.Context(), id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// HandleAttachmentCreate handles POST /api/v1/attachments with a multipart file upload
+func (h *APIHandler) HandleAttachmentCreate(w http.ResponseWriter, r *http.Request) error {
+	upload, err := h.services.FormParser.ParseAttachmentForm(services.NewHTTPRequestAdapter(r))
+	if err != nil {
+		return err
+	}
+	defer upload.File.Close()
+
+	attachment, err := h.services.AttachmentService.CreateAttachment(r.Context(), *upload)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, newAttachmentResponse(*attachment))
+}
+
+// HandleAttachmentDelete handles DELETE /api/v1/attachments?id=
+func (h *APIHandler) HandleAttachmentDelete(w http.ResponseWriter, r *http.Request) error {
+	id, err := queryID(r, "id")
+	if err != nil {
+		return err
+	}
+	if id == 0 {
+		return newAPIError(http.StatusBadRequest, "id is required")
+	}
+	if err := h.services.AttachmentService.DeleteAttachment(r.Context(), id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"linn221/Requester/requests"
+	"net/http"
+	"strings"
+)
+
+// programInput is the body of POST /programs and PUT /programs/{id}, as described by the ProgramInput schema
+type programInput struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Scope     string `json:"scope"`
+	Domains   string `json:"domains"`
+	PathRules string `json:"path_rules"`
+	Note      string `json:"note"`
+}
+
+// program builds the program described by the input
+func (in programInput) program(id uint) (*requests.Program, error) {
+	program := &requests.Program{
+		ID:        id,
+		Name:      strings.TrimSpace(in.Name),
+		URL:       strings.TrimSpace(in.URL),
+		Notes:     strings.TrimSpace(in.Note),
+		Scope:     strings.TrimSpace(in.Scope),
+		Domains:   strings.TrimSpace(in.Domains),
+		PathRules: strings.TrimSpace(in.PathRules),
+	}
+	if program.Name == "" {
+		return nil, newAPIError(http.StatusBadRequest, "name is required")
+	}
+	return program, nil
+}
+
+// programListResponse is a program of GET /programs, as described by the ProgramList schema
+type programListResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// programDetailResponse is the body of GET /programs/{id}, as described by the ProgramDetail schema
+type programDetailResponse struct {
+	ID          uint                 `json:"id"`
+	Name        string               `json:"name"`
+	URL         string               `json:"url"`
+	Scope       string               `json:"scope"`
+	Domains     string               `json:"domains"`
+	PathRules   string               `json:"path_rules"`
+	Note        string               `json:"note"`
+	Notes       []noteResponse       `json:"notes"`
+	Attachments []attachmentResponse `json:"attachments"`
+}
+
+// HandleProgramsList handles GET /api/v1/programs
+func (h *APIHandler) HandleProgramsList(w http.ResponseWriter, r *http.Request) error {
+	page, err := parsePagination(r)
+	if err != nil {
+		return err
+	}
+	programs, err := h.services.ProgramService.GetAllPrograms(r.Context())
+	if err != nil {
+		return err
+	}
+
+	items := make([]programListResponse, 0, len(programs))
+	for _, program := range programs {
+		items = append(items, programListResponse{ID: program.ID, Name: program.Name, URL: program.URL})
+	}
+	return writeJSON(w, http.StatusOK, paginate(w, page, items))
+}
+
+// HandleProgramCreate handles POST /api/v1/programs
+func (h *APIHandler) HandleProgramCreate(w http.ResponseWriter, r *http.Request) error {
+	var input programInput
+	if err := decodeJSON(r, &input); err != nil {
+		return err
+	}
+	program, err := input.program(0)
+	if err != nil {
+		return err
+	}
+	if err := h.services.ProgramService.CreateProgram(r.Context(), program); err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, idResponse{ID: program.ID})
+}
+
+// HandleProgramDetail handles GET /api/v1/programs/{id}
+func (h *APIHandler) HandleProgramDetail(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r)
+	if err != nil {
+		return err
+	}
+	program, err := h.services.ProgramService.GetProgramByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	notes, attachments, err := h.referenceResponses(r.Context(), requests.ReferencePrograms, program.ID)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, programDetailResponse{
+		ID:          program.ID,
+		Name:        program.Name,
+		URL:         program.URL,
+		Scope:       program.Scope,
+		Domains:     program.Domains,
+		PathRules:   program.PathRules,
+		Note:        program.Notes,
+		Notes:       notes,
+		Attachments: attachments,
+	})
+}
+
+// HandleProgramUpdate handles PUT /api/v1/programs/{id}
+func (h *APIHandler) HandleProgramUpdate(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r)
+	if err != nil {
+		return err
+	}
+	if _, err := h.services.ProgramService.GetProgramByID(r.Context(), id); err != nil {
+		return err
+	}
+
+	var input programInput
+	if err := decodeJSON(r, &input); err != nil {
+		return err
+	}
+	program, err := input.program(id)
+	if err != nil {
+		return err
+	}
+	if err := h.services.ProgramService.UpdateProgram(r.Context(), program); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// HandleProgramDelete handles DELETE /api/v1/programs/{id}, with the notes and attachments of the program
+func (h *APIHandler) HandleProgramDelete(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r)
+	if err != nil {
+		return err
+	}
+	if err := h.services.ProgramService.DeleteProgram(r.Context(), id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"linn221/Requester/requests"
+	"linn221/Requester/services"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// requestOrderColumns maps the order_by values of GET /requests to the sortable columns of RequestService
+var requestOrderColumns = map[string]string{
+	"id":              "id",
+	"method":          "method",
+	"url":             "url",
+	"status_code":     "res_status",
+	"size":            "resp_size",
+	"latency":         "latency_ms",
+	"sequence_number": "sequence",
+	"created_at":      "created_at",
+}
+
+// requestListResponse is a request of GET /requests, as described by the RequestList schema
+type requestListResponse struct {
+	ID             uint     `json:"id"`
+	ProgramID      uint     `json:"program_id"`
+	EndpointID     uint     `json:"endpoint_id"`
+	JobID          uint     `json:"job_id"`
+	SequenceNumber int      `json:"sequence_number"`
+	URL            string   `json:"url"`
+	Method         string   `json:"method"`
+	Domain         string   `json:"domain"`
+	StatusCode     int      `json:"status_code"`
+	OutOfScope     bool     `json:"out_of_scope"`
+	SearchResults  []string `json:"search_results"`
+}
+
+// newRequestListResponse converts a request to its listing representation, naming the fields containing search
+func newRequestListResponse(request requests.MyRequest, search string) requestListResponse {
+	response := requestListResponse{
+		ID:             request.ID,
+		EndpointID:     request.EndpointID,
+		JobID:          request.ImportJobID,
+		SequenceNumber: request.Sequence,
+		URL:            request.URL,
+		Method:         request.Method,
+		Domain:         request.Domain,
+		StatusCode:     request.ResStatus,
+		OutOfScope:     request.OutOfScope,
+		SearchResults:  []string{},
+	}
+	if request.ProgramID != nil {
+		response.ProgramID = *request.ProgramID
+	}
+	if search != "" {
+		term := strings.ToLower(search)
+		fields := []struct{ name, value string }{
+			{"url", request.URL},
+			{"method", request.Method},
+			{"domain", request.Domain},
+			{"request_headers", request.ReqHeaders},
+			{"request_body", request.ReqBody},
+			{"response_headers", request.ResHeaders},
+			{"response_body", request.ResBody},
+		}
+		for _, field := range fields {
+			if strings.Contains(strings.ToLower(field.value), term) {
+				response.SearchResults = append(response.SearchResults, field.name)
+			}
+		}
+	}
+	return response
+}
+
+// requestDetailResponse is the body of GET /requests/{id}, as described by the RequestDetail schema
+type requestDetailResponse struct {
+	requestListResponse
+	RequestHeaders   string               `json:"request_headers"`
+	RequestBody      string               `json:"request_body"`
+	ResponseHeaders  string               `json:"response_headers"`
+	ResponseBody     string               `json:"response_body"`
+	ResponseMimeType string               `json:"response_mime_type"`
+	ResponseEncoding string               `json:"response_body_encoding"`
+	ReqHash          string               `json:"reqHash"`
+	ResponseHash     string               `json:"responseHash"`
+	ResponseBodyHash string               `json:"responseBodyHash"`
+	LatencyMs        int64                `json:"latency_ms"`
+	ReplayOfID       *uint                `json:"replay_of_id"`
+	Notes            []noteResponse       `json:"notes"`
+	Attachments      []attachmentResponse `json:"attachments"`
+}
+
+// HandleRequestsList handles GET /api/v1/requests with the program_id, endpoint_id, job_id, search, in_scope,
+// hash_kind and hash filters, ordered by order_by and asc
+func (h *APIHandler) HandleRequestsList(w http.ResponseWriter, r *http.Request) error {
+	page, err := parsePagination(r)
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+	filter := services.RequestFilter{
+		Search:       query.Get("search"),
+		SearchBodies: true,
+		InScopeOnly:  query.Get("in_scope") == "true",
+		HashKind:     requests.ParseHashKind(query.Get("hash_kind")),
+		Hash:         query.Get("hash"),
+	}
+	if filter.ProgramID, err = queryID(r, "program_id"); err != nil {
+		return err
+	}
+	if filter.ImportJobID, err = queryID(r, "job_id"); err != nil {
+		return err
+	}
+	endpointID, err := queryID(r, "endpoint_id")
+	if err != nil {
+		return err
+	}
+	if endpointID != 0 {
+		filter.EndpointIDs = []uint{endpointID}
+	}
+
+	orderBy := query.Get("order_by")
+	if orderBy == "" {
+		orderBy = "id"
+	}
+	column, ok := requestOrderColumns[orderBy]
+	if !ok {
+		return newAPIError(http.StatusBadRequest, "unsupported order_by %q", orderBy)
+	}
+	direction := "ASC"
+	if asc := query.Get("asc"); asc != "" {
+		ascending, err := strconv.ParseBool(asc)
+		if err != nil {
+			return newAPIError(http.StatusBadRequest, "asc must be true or false")
+		}
+		if !ascending {
+			direction = "DESC"
+		}
+	}
+	orders := []services.OrderClause{{Column: column, Direction: direction}}
+
+	reqs, total, err := h.services.RequestService.FindRequestsPage(r.Context(), filter, orders, page.offset(), page.perPage)
+	if err != nil {
+		return err
+	}
+	page.setHeaders(w, total)
+
+	items := make([]requestListResponse, 0, len(reqs))
+	for _, request := range reqs {
+		items = append(items, newRequestListResponse(request, filter.Search))
+	}
+	return writeJSON(w, http.StatusOK, items)
+}
+
+// HandleRequestDetail handles GET /api/v1/requests/{id}
+func (h *APIHandler) HandleRequestDetail(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r)
+	if err != nil {
+		return err
+	}
+	request, err := h.services.RequestService.GetRequestByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	notes, attachments, err := h.referenceResponses(r.Context(), requests.ReferenceRequests, request.ID)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, http.StatusOK, requestDetailResponse{
+		requestListResponse: newRequestListResponse(*request, ""),
+		RequestHeaders:      request.ReqHeaders,
+		RequestBody:         request.ReqBody,
+		ResponseHeaders:     request.ResHeaders,
+		ResponseBody:        request.ResBody,
+		ResponseMimeType:    request.ResMimeType,
+		ResponseEncoding:    request.ResBodyEncoding,
+		ReqHash:             request.ReqHash,
+		ResponseHash:        request.ResHash,
+		ResponseBodyHash:    request.ResBodyHash,
+		LatencyMs:           request.LatencyMs,
+		ReplayOfID:          request.ReplayOfID,
+		Notes:               notes,
+		Attachments:         attachments,
+	})
+}
+
+// HandleJobsList handles GET /api/v1/jobs, newest first
+func (h *APIHandler) HandleJobsList(w http.ResponseWriter, r *http.Request) error {
+	page, err := parsePagination(r)
+	if err != nil {
+		return err
+	}
+	importJobs, err := h.services.ImportJobService.GetAllImportJobs(r.Context())
+	if err != nil {
+		return err
+	}
+
+	items := make([]jobResponse, 0, len(importJobs))
+	for _, job := range importJobs {
+		items = append(items, newJobResponse(job))
+	}
+	return writeJSON(w, http.StatusOK, paginate(w, page, items))
+}
+
+// HandleJobDetail handles GET /api/v1/jobs/{id}, polled while an import runs
+func (h *APIHandler) HandleJobDetail(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathID(r)
+	if err != nil {
+		return err
+	}
+	job, err := h.services.ImportJobService.GetImportJobByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, newJobResponse(*job))
+}
+
+// HandleImportHAR handles POST /api/v1/import-har, queueing the uploaded file as an import job
+func (h *APIHandler) HandleImportHAR(w http.ResponseWriter, r *http.Request) error {
+	importReq, err := h.services.FormParser.ParseHARUploadForm(services.NewHTTPRequestAdapter(r))
+	if err != nil {
+		return err
+	}
+	if _, err := h.services.ProgramService.GetProgramByID(r.Context(), importReq.ProgramID); err != nil {
+		os.Remove(importReq.FilePath)
+		if services.IsNotFound(err) {
+			return newAPIError(http.StatusBadRequest, "program %d does not exist", importReq.ProgramID)
+		}
+		return err
+	}
+
+	result, err := h.services.ImportService.Import(r.Context(), *importReq)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, idResponse{ID: result.ImportJobID})
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"fmt"
+	"linn221/Requester/requests"
+	"linn221/Requester/services"
+	"linn221/Requester/views/templates"
+	"net/http"
+	"strconv"
+)
+
+// AuthzHandler handles program identities and authorization runs
+type AuthzHandler struct {
+	services *services.ServiceContainer
+}
+
+// NewAuthzHandler creates a new AuthzHandler
+func NewAuthzHandler(services *services.ServiceContainer) *AuthzHandler {
+	return &AuthzHandler{
+		services: services,
+	}
+}
+
+// HandleIdentities handles GET /programs/{id}/identities
+func (h *AuthzHandler) HandleIdentities(w http.ResponseWriter, r *http.Request) error {
+	program, err := h.programFromPath(r)
+	if err != nil {
+		return err
+	}
+	return h.renderIdentities(w, r, program)
+}
+
+// HandleIdentityStore handles POST /programs/{id}/identities
+func (h *AuthzHandler) HandleIdentityStore(w http.ResponseWriter, r *http.Request) error {
+	program, err := h.programFromPath(r)
+	if err != nil {
+		return err
+	}
+
+	identity, err := h.services.FormParser.ParseIdentityForm(services.NewHTTPRequestAdapter(r), program.ID)
+	if err != nil {
+		return err
+	}
+	if err := h.services.IdentityService.CreateIdentity(r.Context(), identity); err != nil {
+		return err
+	}
+	return h.renderIdentities(w, r, program)
+}
+
+// HandleIdentityDelete handles DELETE /programs/{id}/identities/{identityID}
+func (h *AuthzHandler) HandleIdentityDelete(w http.ResponseWriter, r *http.Request) error {
+	program, err := h.programFromPath(r)
+	if err != nil {
+		return err
+	}
+	identityID, err := strconv.ParseUint(r.PathValue("identityID"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid identity ID: %v", err)
+	}
+
+	if err := h.services.IdentityService.DeleteIdentity(r.Context(), program.ID, uint(identityID)); err != nil {
+		return err
+	}
+	return h.renderIdentities(w, r, program)
+}
+
+// renderIdentities renders the identities of a program
+func (h *AuthzHandler) renderIdentities(w http.ResponseWriter, r *http.Request, program *requests.Program) error {
+	identities, err := h.services.IdentityService.GetIdentities(r.Context(), program.ID)
+	if err != nil {
+		return err
+	}
+
+	view := templates.IdentitiesView{Program: *program}
+	for _, identity := range identities {
+		headers, err := identity.HeaderSet()
+		if err != nil {
+			return err
+		}
+		row := templates.IdentityRow{Identity: identity}
+		for _, header := range headers {
+			row.HeaderNames = append(row.HeaderNames, header.Name)
+		}
+		view.Identities = append(view.Identities, row)
+	}
+
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		// HTMX request - return just the content
+		return templates.Identities(view).Render(r.Context(), w)
+	} else {
+		// Direct visit - return full page with layout
+		return templates.IdentitiesPage(view).Render(r.Context(), w)
+	}
+}
+
+// HandleAuthzForm handles GET /authz/new?import_job_id= or ?endpoint_id=
+func (h *AuthzHandler) HandleAuthzForm(w http.ResponseWriter, r *http.Request) error {
+	importJobID, err := parseOptionalID(r.URL.Query().Get("import_job_id"))
+	if err != nil {
+		return fmt.Errorf("invalid import job ID: %v", err)
+	}
+	endpointID, err := parseOptionalID(r.URL.Query().Get("endpoint_id"))
+	if err != nil {
+		return fmt.Errorf("invalid endpoint ID: %v", err)
+	}
+
+	view := templates.AuthzFormView{ImportJobID: importJobID, EndpointID: endpointID}
+	var programID *uint
+	switch {
+	case importJobID != 0:
+		job, err := h.services.ImportJobService.GetImportJobByID(r.Context(), importJobID)
+		if err != nil {
+			return err
+		}
+		programID, view.Source = job.ProgramID, job.Title
+	case endpointID != 0:
+		endpoint, err := h.services.EndpointService.GetEndpointByID(r.Context(), endpointID)
+		if err != nil {
+			return err
+		}
+		programID, view.Source = endpoint.ProgramID, fmt.Sprintf("%s %s%s", endpoint.Method, endpoint.Domain, endpoint.URI)
+	default:
+		return fmt.Errorf("choose an import job or an endpoint to test")
+	}
+	if programID == nil {
+		return fmt.Errorf("the requests belong to no program, identities are defined per program")
+	}
+
+	program, err := h.services.ProgramService.GetProgramByID(r.Context(), *programID)
+	if err != nil {
+		return err
+	}
+	view.Program = *program
+	if view.Identities, err = h.services.IdentityService.GetIdentities(r.Context(), program.ID); err != nil {
+		return err
+	}
+
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		// HTMX request - return just the form
+		return templates.AuthzForm(view).Render(r.Context(), w)
+	} else {
+		// Direct visit - return full page with layout
+		return templates.AuthzFormPage(view).Render(r.Context(), w)
+	}
+}
+
+// HandleAuthzStart handles POST /authz, starting a run and showing its matrix
+func (h *AuthzHandler) HandleAuthzStart(w http.ResponseWriter, r *http.Request) error {
+	authzReq, err := h.services.FormParser.ParseAuthzForm(services.NewHTTPRequestAdapter(r))
+	if err != nil {
+		return err
+	}
+
+	job, err := h.services.AuthzService.Start(r.Context(), *authzReq)
+	if err != nil {
+		return err
+	}
+
+	matrixURL := fmt.Sprintf("/authz/%d", job.ID)
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Push-Url", matrixURL)
+		return templates.AuthzMatrix(templates.AuthzMatrixView{Job: *job}).Render(r.Context(), w)
+	}
+	http.Redirect(w, r, matrixURL, http.StatusSeeOther)
+	return nil
+}
+
+// HandleAuthzMatrix handles GET /authz/{id}
+// The matrix panel polls itself while the run is going, those requests only get the panel back.
+func (h *AuthzHandler) HandleAuthzMatrix(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid authorization job ID: %v", err)
+	}
+
+	matrix, err := h.services.AuthzService.GetMatrix(r.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	view := templates.AuthzMatrixView{
+		Job:        matrix.Job,
+		Identities: matrix.Identities,
+		Attempts:   matrix.Attempts,
+		Flagged:    matrix.Flagged,
+	}
+	for _, row := range matrix.Rows {
+		viewRow := templates.AuthzMatrixRow{
+			Endpoint:         row.Endpoint,
+			Originals:        row.Originals,
+			OriginalStatuses: row.OriginalStatuses,
+		}
+		for _, cell := range row.Cells {
+			viewRow.Cells = append(viewRow.Cells, templates.AuthzCell{
+				Variants:  cell.Variants,
+				Flagged:   cell.Flagged,
+				Verdict:   cell.Verdict,
+				Statuses:  cell.Statuses,
+				RequestID: cell.RequestID,
+			})
+		}
+		view.Rows = append(view.Rows, viewRow)
+	}
+
+	if r.Header.Get("HX-Target") == "authz-matrix" {
+		return templates.AuthzMatrixPanel(view).Render(r.Context(), w)
+	}
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		// HTMX request - return just the content
+		return templates.AuthzMatrix(view).Render(r.Context(), w)
+	} else {
+		// Direct visit - return full page with layout
+		return templates.AuthzMatrixPage(view).Render(r.Context(), w)
+	}
+}
+
+// programFromPath fetches the program named by the {id} path value
+func (h *AuthzHandler) programFromPath(r *http.Request) (*requests.Program, error) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid program ID: %v", err)
+	}
+	return h.services.ProgramService.GetProgramByID(r.Context(), uint(id))
+}
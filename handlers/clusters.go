@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"fmt"
+	"linn221/Requester/requests"
+	"linn221/Requester/services"
+	"linn221/Requester/views/templates"
+	"net/http"
+	"strconv"
+)
+
+// clusterRequestsPerPage is the number of requests of a hash cluster listed per page
+const clusterRequestsPerPage = 100
+
+// ClustersHandler handles grouping requests by their hashes
+type ClustersHandler struct {
+	services *services.ServiceContainer
+}
+
+// NewClustersHandler creates a new ClustersHandler
+func NewClustersHandler(services *services.ServiceContainer) *ClustersHandler {
+	return &ClustersHandler{
+		services: services,
+	}
+}
+
+// HandleClusters handles GET /clusters?import_job_id=|endpoint_id=|program_id=&hash_kind=
+func (h *ClustersHandler) HandleClusters(w http.ResponseWriter, r *http.Request) error {
+	scope, scopeView, err := h.parseScope(r)
+	if err != nil {
+		return err
+	}
+	kind := requests.ParseHashKind(r.URL.Query().Get("hash_kind"))
+
+	clusters, err := h.services.ClusterService.GetHashClusters(r.Context(), scope, kind)
+	if err != nil {
+		return err
+	}
+
+	view := templates.HashClustersView{
+		Scope:         scopeView,
+		Kind:          kind,
+		ClusterCount:  clusters.ClusterCount,
+		UniqueCount:   clusters.UniqueCount,
+		RequestCount:  clusters.RequestCount,
+		LargestCount:  clusters.LargestCount,
+		DuplicateRate: clusters.DuplicateRate,
+	}
+	for _, cluster := range clusters.Clusters {
+		view.Rows = append(view.Rows, templates.HashClusterRow{
+			Hash:   cluster.Hash,
+			Count:  cluster.Count,
+			Sample: cluster.Sample,
+		})
+	}
+
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		// HTMX request - return just the content
+		return templates.HashClusters(view).Render(r.Context(), w)
+	} else {
+		// Direct visit - return full page with layout
+		return templates.HashClustersPage(view).Render(r.Context(), w)
+	}
+}
+
+// HandleClusterRequests handles GET /clusters/requests?import_job_id=|endpoint_id=|program_id=&hash_kind=&hash=&page=
+// A common hash can be shared by most of a program's traffic, the requests are listed a page at a time.
+func (h *ClustersHandler) HandleClusterRequests(w http.ResponseWriter, r *http.Request) error {
+	scope, scopeView, err := h.parseScope(r)
+	if err != nil {
+		return err
+	}
+	kind := requests.ParseHashKind(r.URL.Query().Get("hash_kind"))
+	hash := r.URL.Query().Get("hash")
+	if hash == "" {
+		return fmt.Errorf("hash is required")
+	}
+
+	page := 1
+	if value := r.URL.Query().Get("page"); value != "" {
+		page, err = strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return fmt.Errorf("page must be a positive number")
+		}
+	}
+
+	orders := []services.OrderClause{{Column: "created_at", Direction: "ASC"}, {Column: "id", Direction: "ASC"}}
+	reqs, total, err := h.services.RequestService.FindRequestsPage(r.Context(), scope.Filter(kind, hash), orders, (page-1)*clusterRequestsPerPage, clusterRequestsPerPage)
+	if err != nil {
+		return err
+	}
+
+	view := templates.HashClusterRequestsView{
+		Scope:    scopeView,
+		Kind:     kind,
+		Hash:     hash,
+		Requests: reqs,
+		Total:    total,
+		Page:     page,
+		PerPage:  clusterRequestsPerPage,
+	}
+
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		// HTMX request - return just the content
+		return templates.HashClusterRequests(view).Render(r.Context(), w)
+	} else {
+		// Direct visit - return full page with layout
+		return templates.HashClusterRequestsPage(view).Render(r.Context(), w)
+	}
+}
+
+// parseScope reads the import job, endpoint or program to cluster from the query and names it
+func (h *ClustersHandler) parseScope(r *http.Request) (services.ClusterScope, templates.ClusterScopeView, error) {
+	var scope services.ClusterScope
+	var view templates.ClusterScopeView
+
+	if id, err := parseOptionalID(r.URL.Query().Get("import_job_id")); err != nil {
+		return scope, view, fmt.Errorf("invalid import job ID: %v", err)
+	} else if id != 0 {
+		job, err := h.services.ImportJobService.GetImportJobByID(r.Context(), id)
+		if err != nil {
+			return scope, view, err
+		}
+		scope.ImportJobID = job.ID
+		view = templates.ClusterScopeView{Title: job.Title, Query: fmt.Sprintf("import_job_id=%d", job.ID)}
+		return scope, view, nil
+	}
+	if id, err := parseOptionalID(r.URL.Query().Get("endpoint_id")); err != nil {
+		return scope, view, fmt.Errorf("invalid endpoint ID: %v", err)
+	} else if id != 0 {
+		endpoint, err := h.services.EndpointService.GetEndpointByID(r.Context(), id)
+		if err != nil {
+			return scope, view, err
+		}
+		scope.EndpointID = endpoint.ID
+		view = templates.ClusterScopeView{Title: endpoint.Method + " " + endpoint.Domain + endpoint.URI, Query: fmt.Sprintf("endpoint_id=%d", endpoint.ID)}
+		return scope, view, nil
+	}
+	if id, err := parseOptionalID(r.URL.Query().Get("program_id")); err != nil {
+		return scope, view, fmt.Errorf("invalid program ID: %v", err)
+	} else if id != 0 {
+		program, err := h.services.ProgramService.GetProgramByID(r.Context(), id)
+		if err != nil {
+			return scope, view, err
+		}
+		scope.ProgramID = program.ID
+		view = templates.ClusterScopeView{Title: program.Name, Query: fmt.Sprintf("program_id=%d", program.ID)}
+		return scope, view, nil
+	}
+	return scope, view, fmt.Errorf("an import_job_id, endpoint_id or program_id parameter is required")
+}
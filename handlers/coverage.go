@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"linn221/Requester/requests"
+	"linn221/Requester/views/templates"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// HandleOpenAPIForm handles GET /programs/{id}/openapi
+func (h *ProgramsHandler) HandleOpenAPIForm(w http.ResponseWriter, r *http.Request) error {
+	program, err := h.programFromPath(r)
+	if err != nil {
+		return err
+	}
+
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		// HTMX request - return just the form
+		return templates.OpenAPIImportForm(*program).Render(r.Context(), w)
+	} else {
+		// Direct visit - return full page with layout
+		return templates.OpenAPIImportFormPage(*program).Render(r.Context(), w)
+	}
+}
+
+// HandleOpenAPIImport handles POST /programs/{id}/openapi, seeding endpoints from an uploaded document
+func (h *ProgramsHandler) HandleOpenAPIImport(w http.ResponseWriter, r *http.Request) error {
+	program, err := h.programFromPath(r)
+	if err != nil {
+		return err
+	}
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return fmt.Errorf("failed to parse form: %v", err)
+	}
+	file, _, err := r.FormFile("spec_file")
+	if err != nil {
+		return fmt.Errorf("failed to get uploaded file: %v", err)
+	}
+	defer file.Close()
+
+	title, operations, err := requests.ParseOpenAPI(file, strings.TrimSpace(r.FormValue("base_url")))
+	if err != nil {
+		return err
+	}
+	count, err := h.services.EndpointService.SeedOpenAPIEndpoints(r.Context(), program.ID, operations)
+	if err != nil {
+		return err
+	}
+
+	if title == "" {
+		title = "the document"
+	}
+	return h.renderCoverage(w, r, program, fmt.Sprintf("Seeded %d operations from %s.", count, title))
+}
+
+// HandleProgramCoverage handles GET /programs/{id}/coverage
+func (h *ProgramsHandler) HandleProgramCoverage(w http.ResponseWriter, r *http.Request) error {
+	program, err := h.programFromPath(r)
+	if err != nil {
+		return err
+	}
+	return h.renderCoverage(w, r, program, "")
+}
+
+// renderCoverage renders the documentation coverage of a program
+func (h *ProgramsHandler) renderCoverage(w http.ResponseWriter, r *http.Request, program *requests.Program, message string) error {
+	report, err := h.services.EndpointService.GetDocumentationCoverage(r.Context(), program.ID)
+	if err != nil {
+		return err
+	}
+
+	view := templates.CoverageView{
+		Covered:      report.Covered,
+		Undocumented: report.Undocumented,
+		Message:      message,
+	}
+	for _, op := range report.Operations {
+		view.Rows = append(view.Rows, templates.CoverageRow{
+			Endpoint:         op.Endpoint,
+			ResponseCount:    op.ResponseCount,
+			MatchedEndpoints: op.MatchedEndpoints,
+		})
+	}
+
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		// HTMX request - return just the content
+		return templates.ProgramCoverage(*program, view).Render(r.Context(), w)
+	} else {
+		// Direct visit - return full page with layout
+		return templates.ProgramCoveragePage(*program, view).Render(r.Context(), w)
+	}
+}
+
+// programFromPath fetches the program named by the {id} path value
+func (h *ProgramsHandler) programFromPath(r *http.Request) (*requests.Program, error) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid program ID: %v", err)
+	}
+	return h.services.ProgramService.GetProgramByID(r.Context(), uint(id))
+}
@@ -28,13 +28,39 @@ func (h *EndpointsHandler) HandleEndpointsList(w http.ResponseWriter, r *http.Re
 		return err
 	}
 
+	// Attach request and response counts
+	traffic, err := h.services.EndpointService.GetEndpointTraffic(r.Context())
+	if err != nil {
+		return err
+	}
+
+	// Flag endpoints outside the scope of their program
+	scopes, err := h.services.ProgramService.GetProgramScopes(r.Context())
+	if err != nil {
+		return err
+	}
+
+	items := make([]templates.EndpointListEntry, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		outOfScope := false
+		if endpoint.ProgramID != nil {
+			outOfScope = !scopes[*endpoint.ProgramID].ContainsEndpoint(endpoint)
+		}
+		items = append(items, templates.EndpointListEntry{
+			Endpoint:      endpoint,
+			RequestCount:  traffic[endpoint.ID].RequestCount,
+			ResponseCount: traffic[endpoint.ID].ResponseCount,
+			OutOfScope:    outOfScope,
+		})
+	}
+
 	// Check if it's an HTMX request
 	if r.Header.Get("HX-Request") == "true" {
 		// HTMX request - return just the content
-		return templates.EndpointsList(endpoints).Render(r.Context(), w)
+		return templates.EndpointsList(items).Render(r.Context(), w)
 	} else {
 		// Direct visit - return full page with layout
-		return templates.EndpointsListPage(endpoints).Render(r.Context(), w)
+		return templates.EndpointsListPage(items).Render(r.Context(), w)
 	}
 }
 
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"fmt"
+	"linn221/Requester/requests"
+	"linn221/Requester/services"
+	"linn221/Requester/views/templates"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// FuzzHandler handles payload fuzzing of stored requests
+type FuzzHandler struct {
+	services *services.ServiceContainer
+}
+
+// NewFuzzHandler creates a new FuzzHandler
+func NewFuzzHandler(services *services.ServiceContainer) *FuzzHandler {
+	return &FuzzHandler{
+		services: services,
+	}
+}
+
+// HandleFuzzForm handles GET /requests/detail/{id}/fuzz
+func (h *FuzzHandler) HandleFuzzForm(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid request ID: %v", err)
+	}
+
+	request, err := h.services.RequestService.GetRequestByID(r.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	temp, err := request.ToTempMyRequest()
+	if err != nil {
+		return err
+	}
+	importJob, err := h.services.ImportJobService.GetImportJobByID(r.Context(), request.ImportJobID)
+	if err != nil {
+		return err
+	}
+
+	view := templates.FuzzFormView{
+		Request:        *request,
+		Points:         requests.FindInsertionPoints(temp),
+		IgnoredHeaders: strings.Join(importJob.IgnoredHeaderNames(), "\n"),
+	}
+
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		// HTMX request - return just the form
+		return templates.FuzzForm(view).Render(r.Context(), w)
+	} else {
+		// Direct visit - return full page with layout
+		return templates.FuzzFormPage(view).Render(r.Context(), w)
+	}
+}
+
+// HandleFuzzStart handles POST /requests/detail/{id}/fuzz, starting a run and showing its results
+func (h *FuzzHandler) HandleFuzzStart(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid request ID: %v", err)
+	}
+
+	fuzzReq, err := h.services.FormParser.ParseFuzzForm(services.NewHTTPRequestAdapter(r))
+	if err != nil {
+		return err
+	}
+	fuzzReq.BaseRequestID = uint(id)
+
+	job, err := h.services.FuzzService.Start(r.Context(), *fuzzReq)
+	if err != nil {
+		return err
+	}
+
+	resultsURL := fmt.Sprintf("/fuzz/%d", job.ID)
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Push-Url", resultsURL)
+		return templates.FuzzResults(templates.FuzzResultsView{Job: *job}).Render(r.Context(), w)
+	}
+	http.Redirect(w, r, resultsURL, http.StatusSeeOther)
+	return nil
+}
+
+// HandleFuzzResults handles GET /fuzz/{id}
+// The results panel polls itself while the run is going, those requests only get the panel back.
+func (h *FuzzHandler) HandleFuzzResults(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid fuzz job ID: %v", err)
+	}
+
+	results, err := h.services.FuzzService.GetResults(r.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	view := templates.FuzzResultsView{Job: results.Job, Clusters: results.Clusters}
+	for _, row := range results.Rows {
+		view.Rows = append(view.Rows, templates.FuzzResultRow{
+			Attempt:     row.Attempt,
+			Request:     row.Request,
+			ClusterSize: row.ClusterSize,
+			Outlier:     row.Outlier,
+		})
+		if row.Outlier {
+			view.Outliers++
+		}
+	}
+
+	if r.Header.Get("HX-Target") == "fuzz-results" {
+		return templates.FuzzResultsPanel(view).Render(r.Context(), w)
+	}
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		// HTMX request - return just the content
+		return templates.FuzzResults(view).Render(r.Context(), w)
+	} else {
+		// Direct visit - return full page with layout
+		return templates.FuzzResultsPage(view).Render(r.Context(), w)
+	}
+}
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
+	"linn221/Requester/requests"
 	"linn221/Requester/services"
 	"linn221/Requester/views/templates"
 	"net/http"
+	"strconv"
 )
 
 // ImportJobsHandler handles import jobs related requests
@@ -18,6 +22,42 @@ func NewImportJobsHandler(services *services.ServiceContainer) *ImportJobsHandle
 	}
 }
 
+// jobResponse is the JSON representation of an import job, as described by the Job schema in openapi.yaml
+type jobResponse struct {
+	ID          uint                     `json:"id"`
+	JobType     requests.JobType         `json:"job_type"`
+	Title       string                   `json:"title"`
+	Status      requests.ImportJobStatus `json:"status"`
+	Progress    int                      `json:"progress"`
+	Requests    int                      `json:"request_count"`
+	Endpoints   int                      `json:"endpoint_count"`
+	Domains     int                      `json:"domain_count"`
+	Error       string                   `json:"error,omitempty"`
+	CreatedAt   string                   `json:"created_at"`
+	Description string                   `json:"description"`
+}
+
+// newJobResponse converts an import job to its JSON representation
+func newJobResponse(job requests.ImportJob) jobResponse {
+	description := job.Summary
+	if job.Status == requests.ImportJobStatusFailed {
+		description = job.Error
+	}
+	return jobResponse{
+		ID:          job.ID,
+		JobType:     job.JobType,
+		Title:       job.Title,
+		Status:      job.Status,
+		Progress:    job.Progress,
+		Requests:    job.RequestCount,
+		Endpoints:   job.EndpointCount,
+		Domains:     job.DomainCount,
+		Error:       job.Error,
+		CreatedAt:   formatTimestamp(job.CreatedAt),
+		Description: description,
+	}
+}
+
 // HandleImportJobsList handles GET /import-jobs
 func (h *ImportJobsHandler) HandleImportJobsList(w http.ResponseWriter, r *http.Request) error {
 	// Fetch all import jobs
@@ -35,3 +75,96 @@ func (h *ImportJobsHandler) HandleImportJobsList(w http.ResponseWriter, r *http.
 		return templates.ImportJobsListPage(importJobs).Render(r.Context(), w)
 	}
 }
+
+// HandleJobs handles GET /jobs
+// HTMX requests with an id poll the progress of a single job, everything else gets the JSON job listing.
+func (h *ImportJobsHandler) HandleJobs(w http.ResponseWriter, r *http.Request) error {
+	idStr := r.URL.Query().Get("id")
+
+	if r.Header.Get("HX-Request") == "true" && idStr != "" {
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid import job ID: %v", err)
+		}
+
+		importJob, err := h.services.ImportJobService.GetImportJobByID(r.Context(), uint(id))
+		if err != nil {
+			return err
+		}
+		return templates.ImportJobProgress(*importJob).Render(r.Context(), w)
+	}
+
+	importJobs, err := h.services.ImportJobService.GetAllImportJobs(r.Context())
+	if err != nil {
+		return err
+	}
+
+	jobs := make([]jobResponse, 0, len(importJobs))
+	for _, job := range importJobs {
+		if idStr != "" && strconv.FormatUint(uint64(job.ID), 10) != idStr {
+			continue
+		}
+		jobs = append(jobs, newJobResponse(job))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(jobs)
+}
+
+// HandleRehashForm handles GET /rehash/new?import_job_id= or ?program_id=
+func (h *ImportJobsHandler) HandleRehashForm(w http.ResponseWriter, r *http.Request) error {
+	importJobID, err := parseOptionalID(r.URL.Query().Get("import_job_id"))
+	if err != nil {
+		return fmt.Errorf("invalid import job ID: %v", err)
+	}
+	programID, err := parseOptionalID(r.URL.Query().Get("program_id"))
+	if err != nil {
+		return fmt.Errorf("invalid program ID: %v", err)
+	}
+
+	view := templates.RehashFormView{ImportJobID: importJobID, ProgramID: programID}
+	switch {
+	case importJobID != 0:
+		job, err := h.services.ImportJobService.GetImportJobByID(r.Context(), importJobID)
+		if err != nil {
+			return err
+		}
+		view.Source = job.Title
+		view.IgnoredHeaders, view.IgnoredFields = job.IgnoredHeaderNames(), job.IgnoredFieldNames()
+	case programID != 0:
+		program, err := h.services.ProgramService.GetProgramByID(r.Context(), programID)
+		if err != nil {
+			return err
+		}
+		view.Source = "Every import job of " + program.Name
+	default:
+		return fmt.Errorf("choose an import job or a program to rehash")
+	}
+
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		// HTMX request - return just the form
+		return templates.RehashForm(view).Render(r.Context(), w)
+	} else {
+		// Direct visit - return full page with layout
+		return templates.RehashFormPage(view).Render(r.Context(), w)
+	}
+}
+
+// HandleRehashStart handles POST /rehash, starting a rehash and showing it among the jobs
+func (h *ImportJobsHandler) HandleRehashStart(w http.ResponseWriter, r *http.Request) error {
+	rehashReq, err := h.services.FormParser.ParseRehashForm(services.NewHTTPRequestAdapter(r))
+	if err != nil {
+		return err
+	}
+	if _, err := h.services.RehashService.Start(r.Context(), *rehashReq); err != nil {
+		return err
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Push-Url", "/import-jobs")
+		return h.HandleImportJobsList(w, r)
+	}
+	http.Redirect(w, r, "/dashboard/import-jobs", http.StatusSeeOther)
+	return nil
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"linn221/Requester/requests"
+	"linn221/Requester/services"
+	"linn221/Requester/views/templates"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// NotesHandler handles the notes and attachments of programs, endpoints and requests
+type NotesHandler struct {
+	services *services.ServiceContainer
+}
+
+// NewNotesHandler creates a new NotesHandler
+func NewNotesHandler(services *services.ServiceContainer) *NotesHandler {
+	return &NotesHandler{
+		services: services,
+	}
+}
+
+// HandleNoteSearch handles GET /notes?search=&type=, searching the notes of every program, endpoint and request
+func (h *NotesHandler) HandleNoteSearch(w http.ResponseWriter, r *http.Request) error {
+	view := templates.NoteSearchView{Search: r.URL.Query().Get("search")}
+	if value := r.URL.Query().Get("type"); value != "" {
+		referenceType, ok := requests.ParseReferenceType(value)
+		if !ok {
+			return fmt.Errorf("unknown record type %q", value)
+		}
+		view.ReferenceType = referenceType
+	}
+
+	filter := services.NoteFilter{ReferenceType: view.ReferenceType, Search: view.Search}
+	notes, total, err := h.services.NoteService.SearchNotes(r.Context(), filter, 0, services.MaxNoteResults)
+	if err != nil {
+		return err
+	}
+	view.Total = total
+
+	labels := make(map[string]templates.NoteSearchRow)
+	for _, note := range notes {
+		key := fmt.Sprintf("%s/%d", note.ReferenceType, note.ReferenceID)
+		reference, ok := labels[key]
+		if !ok {
+			reference.Label, reference.Href = h.describeReference(r.Context(), note.ReferenceType, note.ReferenceID)
+			labels[key] = reference
+		}
+		view.Rows = append(view.Rows, templates.NoteSearchRow{Note: note, Label: reference.Label, Href: reference.Href})
+	}
+
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		// HTMX request - return just the content
+		return templates.NoteSearch(view).Render(r.Context(), w)
+	} else {
+		// Direct visit - return full page with layout
+		return templates.NoteSearchPage(view).Render(r.Context(), w)
+	}
+}
+
+// describeReference names the program, endpoint or request a note belongs to and links to its detail page
+func (h *NotesHandler) describeReference(ctx context.Context, referenceType requests.ReferenceType, referenceID uint) (string, string) {
+	switch referenceType {
+	case requests.ReferencePrograms:
+		href := fmt.Sprintf("/programs/%d", referenceID)
+		if program, err := h.services.ProgramService.GetProgramByID(ctx, referenceID); err == nil {
+			return program.Name, href
+		}
+		return fmt.Sprintf("Program #%d", referenceID), href
+	case requests.ReferenceEndpoints:
+		href := fmt.Sprintf("/endpoints/%d", referenceID)
+		if endpoint, err := h.services.EndpointService.GetEndpointByID(ctx, referenceID); err == nil {
+			return fmt.Sprintf("%s %s%s", endpoint.Method, endpoint.Domain, endpoint.URI), href
+		}
+		return fmt.Sprintf("Endpoint #%d", referenceID), href
+	default:
+		href := fmt.Sprintf("/requests/detail/%d", referenceID)
+		if request, err := h.services.RequestService.GetRequestByID(ctx, referenceID); err == nil {
+			return fmt.Sprintf("%s %s", request.Method, request.URL), href
+		}
+		return fmt.Sprintf("Request #%d", referenceID), href
+	}
+}
+
+// HandleNotesPanel handles GET /notes/panel?reference_type=&reference_id=, loaded by the detail pages
+func (h *NotesHandler) HandleNotesPanel(w http.ResponseWriter, r *http.Request) error {
+	referenceType, ok := requests.ParseReferenceType(r.URL.Query().Get("reference_type"))
+	if !ok {
+		return fmt.Errorf("unknown reference type %q", r.URL.Query().Get("reference_type"))
+	}
+	referenceID, err := parseOptionalID(r.URL.Query().Get("reference_id"))
+	if err != nil {
+		return fmt.Errorf("invalid reference ID: %v", err)
+	}
+	return h.renderPanel(w, r, referenceType, referenceID)
+}
+
+// HandleNoteStore handles POST /notes
+func (h *NotesHandler) HandleNoteStore(w http.ResponseWriter, r *http.Request) error {
+	note, err := h.services.FormParser.ParseNoteForm(services.NewHTTPRequestAdapter(r))
+	if err != nil {
+		return err
+	}
+	if err := h.services.NoteService.CreateNote(r.Context(), note); err != nil {
+		return err
+	}
+	return h.renderPanel(w, r, note.ReferenceType, note.ReferenceID)
+}
+
+// HandleNoteUpdate handles PUT /notes/{id}
+func (h *NotesHandler) HandleNoteUpdate(w http.ResponseWriter, r *http.Request) error {
+	note, err := h.noteFromPath(r)
+	if err != nil {
+		return err
+	}
+	if err := h.services.NoteService.UpdateNote(r.Context(), note.ID, r.FormValue("value")); err != nil {
+		return err
+	}
+	return h.renderPanel(w, r, note.ReferenceType, note.ReferenceID)
+}
+
+// HandleNoteDelete handles DELETE /notes/{id}
+func (h *NotesHandler) HandleNoteDelete(w http.ResponseWriter, r *http.Request) error {
+	note, err := h.noteFromPath(r)
+	if err != nil {
+		return err
+	}
+	if err := h.services.NoteService.DeleteNote(r.Context(), note.ID); err != nil {
+		return err
+	}
+	return h.renderPanel(w, r, note.ReferenceType, note.ReferenceID)
+}
+
+// HandleAttachmentStore handles POST /attachments
+func (h *NotesHandler) HandleAttachmentStore(w http.ResponseWriter, r *http.Request) error {
+	upload, err := h.services.FormParser.ParseAttachmentForm(services.NewHTTPRequestAdapter(r))
+	if err != nil {
+		return err
+	}
+	defer upload.File.Close()
+
+	if _, err := h.services.AttachmentService.CreateAttachment(r.Context(), *upload); err != nil {
+		return err
+	}
+	return h.renderPanel(w, r, upload.ReferenceType, upload.ReferenceID)
+}
+
+// HandleAttachmentDownload handles GET /attachments/{id}, sending the stored file under its uploaded name
+func (h *NotesHandler) HandleAttachmentDownload(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid attachment ID: %v", err)
+	}
+	attachment, file, err := h.services.AttachmentService.OpenAttachment(r.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(attachment.Filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, attachment.Filename, time.Unix(attachment.CreatedAt, 0), file)
+	return nil
+}
+
+// HandleAttachmentDelete handles DELETE /attachments/{id}
+func (h *NotesHandler) HandleAttachmentDelete(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid attachment ID: %v", err)
+	}
+	attachment, err := h.services.AttachmentService.GetAttachmentByID(r.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	if err := h.services.AttachmentService.DeleteAttachment(r.Context(), attachment.ID); err != nil {
+		return err
+	}
+	return h.renderPanel(w, r, attachment.ReferenceType, attachment.ReferenceID)
+}
+
+// noteFromPath fetches the note named by the {id} path value
+func (h *NotesHandler) noteFromPath(r *http.Request) (*requests.Note, error) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid note ID: %v", err)
+	}
+	return h.services.NoteService.GetNoteByID(r.Context(), uint(id))
+}
+
+// renderPanel renders the notes and attachments of a program, endpoint or request
+func (h *NotesHandler) renderPanel(w http.ResponseWriter, r *http.Request, referenceType requests.ReferenceType, referenceID uint) error {
+	view := templates.NotesPanelView{ReferenceType: referenceType, ReferenceID: referenceID}
+	var err error
+	if view.Notes, err = h.services.NoteService.GetNotes(r.Context(), referenceType, referenceID); err != nil {
+		return err
+	}
+	if view.Attachments, err = h.services.AttachmentService.GetAttachments(r.Context(), referenceType, referenceID); err != nil {
+		return err
+	}
+	return templates.NotesPanel(view).Render(r.Context(), w)
+}
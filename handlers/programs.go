@@ -61,11 +61,12 @@ func (h *ProgramsHandler) HandleProgramStore(w http.ResponseWriter, r *http.Requ
 
 	// Create program from form data
 	program := &requests.Program{
-		Name:    strings.TrimSpace(r.FormValue("name")),
-		URL:     strings.TrimSpace(r.FormValue("url")),
-		Notes:   strings.TrimSpace(r.FormValue("notes")),
-		Scope:   strings.TrimSpace(r.FormValue("scope")),
-		Domains: strings.TrimSpace(r.FormValue("domains")),
+		Name:      strings.TrimSpace(r.FormValue("name")),
+		URL:       strings.TrimSpace(r.FormValue("url")),
+		Notes:     strings.TrimSpace(r.FormValue("notes")),
+		Scope:     strings.TrimSpace(r.FormValue("scope")),
+		Domains:   strings.TrimSpace(r.FormValue("domains")),
+		PathRules: strings.TrimSpace(r.FormValue("path_rules")),
 	}
 
 	// Validate required fields
@@ -83,6 +84,40 @@ func (h *ProgramsHandler) HandleProgramStore(w http.ResponseWriter, r *http.Requ
 	return nil
 }
 
+// HandleProgramDetail handles GET /programs/{id}
+func (h *ProgramsHandler) HandleProgramDetail(w http.ResponseWriter, r *http.Request) error {
+	// Extract ID from URL
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid program ID: %v", err)
+	}
+
+	// Fetch program with the counts of its records
+	program, err := h.services.ProgramService.GetProgramByID(r.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	endpoints, err := h.services.EndpointService.GetEndpointsByProgram(r.Context(), program.ID)
+	if err != nil {
+		return err
+	}
+	requestCount, err := h.services.RequestService.CountRequests(r.Context(), services.RequestFilter{ProgramID: program.ID})
+	if err != nil {
+		return err
+	}
+	view := templates.ProgramDetailView{Program: *program, EndpointCount: len(endpoints), RequestCount: requestCount}
+
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		// HTMX request - return just the content
+		return templates.ProgramDetail(view).Render(r.Context(), w)
+	} else {
+		// Direct visit - return full page with layout
+		return templates.ProgramDetailPage(view).Render(r.Context(), w)
+	}
+}
+
 // HandleProgramEdit handles GET /programs/{id}/edit
 func (h *ProgramsHandler) HandleProgramEdit(w http.ResponseWriter, r *http.Request) error {
 	// Extract ID from URL
@@ -124,12 +159,13 @@ func (h *ProgramsHandler) HandleProgramUpdate(w http.ResponseWriter, r *http.Req
 
 	// Create program from form data
 	program := &requests.Program{
-		ID:      uint(id),
-		Name:    strings.TrimSpace(r.FormValue("name")),
-		URL:     strings.TrimSpace(r.FormValue("url")),
-		Notes:   strings.TrimSpace(r.FormValue("notes")),
-		Scope:   strings.TrimSpace(r.FormValue("scope")),
-		Domains: strings.TrimSpace(r.FormValue("domains")),
+		ID:        uint(id),
+		Name:      strings.TrimSpace(r.FormValue("name")),
+		URL:       strings.TrimSpace(r.FormValue("url")),
+		Notes:     strings.TrimSpace(r.FormValue("notes")),
+		Scope:     strings.TrimSpace(r.FormValue("scope")),
+		Domains:   strings.TrimSpace(r.FormValue("domains")),
+		PathRules: strings.TrimSpace(r.FormValue("path_rules")),
 	}
 
 	// Validate required fields
@@ -156,7 +192,7 @@ func (h *ProgramsHandler) HandleProgramDelete(w http.ResponseWriter, r *http.Req
 		return fmt.Errorf("invalid program ID: %v", err)
 	}
 
-	// Delete the program
+	// Delete the program with its notes and attachments
 	if err := h.services.ProgramService.DeleteProgram(r.Context(), uint(id)); err != nil {
 		return err
 	}
@@ -165,3 +201,26 @@ func (h *ProgramsHandler) HandleProgramDelete(w http.ResponseWriter, r *http.Req
 	http.Redirect(w, r, "/dashboard/programs", http.StatusSeeOther)
 	return nil
 }
+
+// HandleProgramRegroup handles POST /programs/{id}/regroup, merging captured endpoints into path templates
+func (h *ProgramsHandler) HandleProgramRegroup(w http.ResponseWriter, r *http.Request) error {
+	program, err := h.programFromPath(r)
+	if err != nil {
+		return err
+	}
+	if _, err := h.services.RegroupService.Start(r.Context(), program.ID); err != nil {
+		return err
+	}
+
+	// Show the job running among the others
+	if r.Header.Get("HX-Request") == "true" {
+		importJobs, err := h.services.ImportJobService.GetAllImportJobs(r.Context())
+		if err != nil {
+			return err
+		}
+		w.Header().Set("HX-Push-Url", "/import-jobs")
+		return templates.ImportJobsList(importJobs).Render(r.Context(), w)
+	}
+	http.Redirect(w, r, "/dashboard/import-jobs", http.StatusSeeOther)
+	return nil
+}
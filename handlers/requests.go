@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"linn221/Requester/requests"
 	"linn221/Requester/services"
 	"linn221/Requester/views/templates"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -36,6 +38,7 @@ func (h *RequestsHandler) HandleRequestsList(w http.ResponseWriter, r *http.Requ
 	types := r.URL.Query()["types[]"]
 	sizeMin := r.URL.Query().Get("size_min")
 	sizeMax := r.URL.Query().Get("size_max")
+	inScopeOnly := r.URL.Query().Get("in_scope") == "true"
 
 	// Parse multi-order parameters
 	orders := h.parseMultiOrderParams(r)
@@ -60,7 +63,7 @@ func (h *RequestsHandler) HandleRequestsList(w http.ResponseWriter, r *http.Requ
 		endpointIDs = []string{endpointIDStr}
 
 		// Fetch requests by endpoint with multi-order
-		requests, err = h.services.RequestService.GetRequestsByEndpointWithMultiOrderAndSearch(r.Context(), uint(endpointID), orders, search)
+		requests, err = h.services.RequestService.GetRequestsByEndpointWithMultiOrderAndSearch(r.Context(), uint(endpointID), orders, search, inScopeOnly)
 		if err != nil {
 			return err
 		}
@@ -82,7 +85,7 @@ func (h *RequestsHandler) HandleRequestsList(w http.ResponseWriter, r *http.Requ
 		if len(endpointIDUints) > 0 {
 			// TODO: Implement GetRequestsByEndpointsWithFilters in service
 			// For now, use the first endpoint
-			requests, err = h.services.RequestService.GetRequestsByEndpointWithMultiOrderAndSearch(r.Context(), endpointIDUints[0], orders, search)
+			requests, err = h.services.RequestService.GetRequestsByEndpointWithMultiOrderAndSearch(r.Context(), endpointIDUints[0], orders, search, inScopeOnly)
 			if err != nil {
 				return err
 			}
@@ -95,7 +98,7 @@ func (h *RequestsHandler) HandleRequestsList(w http.ResponseWriter, r *http.Requ
 		}
 
 		// Fetch requests by import job with multi-order
-		requests, err = h.services.RequestService.GetRequestsByImportJobWithMultiOrderAndSearch(r.Context(), uint(importJobID), orders, search)
+		requests, err = h.services.RequestService.GetRequestsByImportJobWithMultiOrderAndSearch(r.Context(), uint(importJobID), orders, search, inScopeOnly)
 		if err != nil {
 			return err
 		}
@@ -112,6 +115,7 @@ func (h *RequestsHandler) HandleRequestsList(w http.ResponseWriter, r *http.Requ
 
 	// Create filter state for template
 	filterState := h.createFilterState(importJobIDStr, endpointIDStr, search, orders, endpointIDs, methods, statuses, types, sizeMin, sizeMax)
+	filterState.InScopeOnly = inScopeOnly
 
 	// Check if it's an HTMX request
 	if r.Header.Get("HX-Request") == "true" {
@@ -250,3 +254,247 @@ func (h *RequestsHandler) HandleRequestDetail(w http.ResponseWriter, r *http.Req
 		return templates.RequestDetailPage(*request).Render(r.Context(), w)
 	}
 }
+
+// harExportBatchSize is how many requests are read at a time while a HAR export is streamed
+const harExportBatchSize = 200
+
+// HandleRequestsExport handles GET /requests/export.har
+// It accepts the filters of the requests list plus program_id and a hash cluster, and streams the selection as a HAR 1.2 document
+// in the order the requests were stored. Requests that cannot be converted are logged and left out.
+func (h *RequestsHandler) HandleRequestsExport(w http.ResponseWriter, r *http.Request) error {
+	filter := services.RequestFilter{
+		Search:      r.URL.Query().Get("search"),
+		InScopeOnly: r.URL.Query().Get("in_scope") == "true",
+		HashKind:    requests.ParseHashKind(r.URL.Query().Get("hash_kind")),
+		Hash:        r.URL.Query().Get("hash"),
+	}
+	name := "requests"
+
+	if id, err := parseOptionalID(r.URL.Query().Get("import_job_id")); err != nil {
+		return fmt.Errorf("invalid import job ID: %v", err)
+	} else if id != 0 {
+		filter.ImportJobID = id
+		name += fmt.Sprintf("-job-%d", id)
+	}
+	if id, err := parseOptionalID(r.URL.Query().Get("program_id")); err != nil {
+		return fmt.Errorf("invalid program ID: %v", err)
+	} else if id != 0 {
+		filter.ProgramID = id
+		name += fmt.Sprintf("-program-%d", id)
+	}
+	endpointIDs := append(r.URL.Query()["endpoint_ids[]"], r.URL.Query().Get("endpoint_id"))
+	for _, idStr := range endpointIDs {
+		id, err := parseOptionalID(idStr)
+		if err != nil {
+			return fmt.Errorf("invalid endpoint ID: %v", err)
+		}
+		if id != 0 {
+			filter.EndpointIDs = append(filter.EndpointIDs, id)
+		}
+	}
+	if len(filter.EndpointIDs) == 1 {
+		name += fmt.Sprintf("-endpoint-%d", filter.EndpointIDs[0])
+	}
+
+	if !filter.IsScoped() {
+		return fmt.Errorf("choose an import job, endpoint or program to export")
+	}
+
+	// The download starts with the first batch, failures after that are logged instead of being rendered into the file
+	var hw *requests.HARWriter
+	begin := func() error {
+		if hw != nil {
+			return nil
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".har"))
+		var err error
+		hw, err = requests.NewHARWriter(w)
+		return err
+	}
+	skipped := 0
+	err := h.services.RequestService.FindRequestsInBatches(r.Context(), filter, harExportBatchSize, func(batch []requests.MyRequest) error {
+		if err := begin(); err != nil {
+			return err
+		}
+		for _, req := range batch {
+			if err := hw.Add(req); errors.Is(err, requests.ErrHAREntrySkipped) {
+				log.Printf("[EXPORT] %v", err)
+				skipped++
+			} else if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil && hw == nil {
+		return err
+	}
+	if err != nil {
+		log.Printf("[EXPORT] %s.har stopped early: %v", name, err)
+		return nil
+	}
+	if err := begin(); err != nil {
+		return err
+	}
+	if skipped > 0 {
+		log.Printf("[EXPORT] %s.har: %d requests left out", name, skipped)
+	}
+	if err := hw.Close(); err != nil {
+		log.Printf("[EXPORT] failed to finish %s.har: %v", name, err)
+	}
+	return nil
+}
+
+// parseOptionalID parses an ID query parameter, treating an empty value as 0
+func parseOptionalID(idStr string) (uint, error) {
+	if idStr == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// HandleRequestSnippet handles GET /requests/detail/{id}/export?format=
+// HTMX requests get the snippet panel, direct visits get the snippet as plain text.
+func (h *RequestsHandler) HandleRequestSnippet(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid request ID: %v", err)
+	}
+
+	request, err := h.services.RequestService.GetRequestByID(r.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	format := requests.SnippetFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = requests.SnippetCurl
+	}
+
+	var opts requests.SnippetOptions
+	if r.URL.Query().Get("omit_ignored") == "true" {
+		importJob, err := h.services.ImportJobService.GetImportJobByID(r.Context(), request.ImportJobID)
+		if err != nil {
+			return err
+		}
+		opts.OmitHeaders = importJob.IgnoredHeaderNames()
+	}
+
+	snippet, err := requests.RenderSnippet(*request, format, opts)
+	if err != nil {
+		return err
+	}
+
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		return templates.RequestSnippet(snippet).Render(r.Context(), w)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, err = w.Write([]byte(snippet))
+	return err
+}
+
+// HandleReplayPanel handles GET /requests/detail/{id}/replay, the editor prefilled with the stored request
+func (h *RequestsHandler) HandleReplayPanel(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid request ID: %v", err)
+	}
+
+	request, err := h.services.RequestService.GetRequestByID(r.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	temp, err := request.ToTempMyRequest()
+	if err != nil {
+		return err
+	}
+	replays, err := h.services.ReplayService.GetReplays(r.Context(), request.ID)
+	if err != nil {
+		return err
+	}
+
+	view := templates.ReplayView{
+		Original: *request,
+		Headers:  temp.ReqHeaders.EchoAll(),
+		Replays:  replays,
+	}
+	return templates.RequestReplayPanel(view).Render(r.Context(), w)
+}
+
+// HandleReplay handles POST /requests/detail/{id}/replay, sending the edited request and showing the stored replay
+func (h *RequestsHandler) HandleReplay(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid request ID: %v", err)
+	}
+
+	edit, err := h.services.FormParser.ParseReplayForm(services.NewHTTPRequestAdapter(r))
+	if err != nil {
+		return err
+	}
+	replay, err := h.services.ReplayService.Replay(r.Context(), uint(id), *edit)
+	if err != nil {
+		return err
+	}
+
+	detailURL := fmt.Sprintf("/requests/detail/%d", replay.ID)
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Push-Url", detailURL)
+		return templates.RequestDetail(*replay).Render(r.Context(), w)
+	}
+	http.Redirect(w, r, detailURL, http.StatusSeeOther)
+	return nil
+}
+
+// HandleRequestDiff handles GET /requests/diff?a=&b=
+func (h *RequestsHandler) HandleRequestDiff(w http.ResponseWriter, r *http.Request) error {
+	aID, err := strconv.ParseUint(r.URL.Query().Get("a"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid request ID a: %v", err)
+	}
+	bID, err := strconv.ParseUint(r.URL.Query().Get("b"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid request ID b: %v", err)
+	}
+
+	diff, err := h.services.DiffService.Compare(r.Context(), uint(aID), uint(bID))
+	if err != nil {
+		return err
+	}
+
+	view := templates.DiffView{
+		A:              diff.A,
+		B:              diff.B,
+		IgnoredHeaders: diff.IgnoredHeaders,
+		ReqHeaders:     toDiffSection(requests.BodyDiff{Lines: diff.ReqHeaders}),
+		ResHeaders:     toDiffSection(requests.BodyDiff{Lines: diff.ResHeaders}),
+		ReqBody:        toDiffSection(diff.ReqBody),
+		ResBody:        toDiffSection(diff.ResBody),
+	}
+
+	// Check if it's an HTMX request
+	if r.Header.Get("HX-Request") == "true" {
+		// HTMX request - return just the content
+		return templates.RequestDiff(view).Render(r.Context(), w)
+	} else {
+		// Direct visit - return full page with layout
+		return templates.RequestDiffPage(view).Render(r.Context(), w)
+	}
+}
+
+// toDiffSection arranges a diff for side by side display
+func toDiffSection(diff requests.BodyDiff) templates.DiffSection {
+	return templates.DiffSection{
+		Rows:        requests.DiffRows(diff.Lines),
+		Changed:     requests.HasChanges(diff.Lines),
+		IsJSON:      diff.IsJSON,
+		JSONChanges: diff.JSONChanges,
+	}
+}
@@ -0,0 +1,296 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is an OpenAPI 3 document the responses of the API are checked against
+type Spec struct {
+	doc   map[string]interface{}
+	paths map[string]interface{}
+}
+
+// LoadSpec reads and parses the OpenAPI document at path
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %v", err)
+	}
+	return ParseSpec(data)
+}
+
+// ParseSpec parses an OpenAPI 3 document in YAML or JSON
+func ParseSpec(data []byte) (*Spec, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %v", err)
+	}
+	paths, ok := doc["paths"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("spec has no paths")
+	}
+	return &Spec{doc: doc, paths: paths}, nil
+}
+
+// CheckResponse validates a response of the API against the operation documented for method and path.
+// path is relative to the server URL of the spec, without the query string.
+func (s *Spec) CheckResponse(method, path string, status int, contentType string, body []byte) error {
+	operation, err := s.operation(method, path)
+	if err != nil {
+		return err
+	}
+	response, err := s.response(operation, status)
+	if err != nil {
+		return fmt.Errorf("%s %s: %v", method, path, err)
+	}
+
+	content, _ := response["content"].(map[string]interface{})
+	if len(content) == 0 {
+		if len(bytes.TrimSpace(body)) != 0 {
+			return fmt.Errorf("%s %s: status %d is documented without a body, got %d bytes", method, path, status, len(body))
+		}
+		return nil
+	}
+	media, ok := content["application/json"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if !strings.HasPrefix(contentType, "application/json") {
+		return fmt.Errorf("%s %s: expected application/json, got %q", method, path, contentType)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("%s %s: invalid JSON body: %v", method, path, err)
+	}
+	schema, _ := media["schema"].(map[string]interface{})
+	if schema == nil {
+		return nil
+	}
+	var problems []string
+	s.validate(schema, value, "$", &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("%s %s %d: %s", method, path, status, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// CheckError validates an error answered for a path the spec does not document against the Error schema
+func (s *Spec) CheckError(status int, contentType string, body []byte) error {
+	if status < 400 {
+		return fmt.Errorf("expected an error status, got %d", status)
+	}
+	if !strings.HasPrefix(contentType, "application/json") {
+		return fmt.Errorf("expected application/json, got %q", contentType)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON body: %v", err)
+	}
+	var problems []string
+	s.validate(map[string]interface{}{"$ref": "#/components/schemas/Error"}, value, "$", &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("%d: %s", status, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// operation finds the operation of method on the path template matching path, literal segments win over parameters
+func (s *Spec) operation(method, path string) (map[string]interface{}, error) {
+	segments := splitPath(path)
+	best, bestParams := "", -1
+	for template := range s.paths {
+		params, ok := matchTemplate(splitPath(template), segments)
+		if ok && (bestParams < 0 || params < bestParams) {
+			best, bestParams = template, params
+		}
+	}
+	if bestParams < 0 {
+		return nil, fmt.Errorf("%s %s: path is not documented", method, path)
+	}
+	item, _ := s.resolve(s.paths[best]).(map[string]interface{})
+	operation, ok := item[strings.ToLower(method)].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s %s: method is not documented for %s", method, path, best)
+	}
+	return operation, nil
+}
+
+// response picks the response documented for status, trying the exact code, its class such as 4XX, then default
+func (s *Spec) response(operation map[string]interface{}, status int) (map[string]interface{}, error) {
+	responses, _ := operation["responses"].(map[string]interface{})
+	code := strconv.Itoa(status)
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if response, ok := responses[key]; ok {
+			resolved, _ := s.resolve(response).(map[string]interface{})
+			return resolved, nil
+		}
+	}
+	documented := make([]string, 0, len(responses))
+	for key := range responses {
+		documented = append(documented, key)
+	}
+	sort.Strings(documented)
+	return nil, fmt.Errorf("status %d is not documented (%s)", status, strings.Join(documented, ", "))
+}
+
+// resolve follows local $ref pointers such as #/components/schemas/Job
+func (s *Spec) resolve(node interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return node
+		}
+		ref, ok := object["$ref"].(string)
+		if !ok {
+			return node
+		}
+		var target interface{} = s.doc
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			parent, _ := target.(map[string]interface{})
+			target = parent[part]
+		}
+		node = target
+	}
+	return node
+}
+
+// validate checks value against schema, appending a problem for each mismatch found under pointer
+func (s *Spec) validate(schema map[string]interface{}, value interface{}, pointer string, problems *[]string) {
+	schema, _ = s.resolve(schema).(map[string]interface{})
+	if schema == nil {
+		return
+	}
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, part := range all {
+			if partSchema, ok := part.(map[string]interface{}); ok {
+				s.validate(partSchema, value, pointer, problems)
+			}
+		}
+	}
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); !nullable && schema["type"] != nil {
+			*problems = append(*problems, fmt.Sprintf("%s is null", pointer))
+		}
+		return
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s is not an object", pointer))
+			return
+		}
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := object[fmt.Sprint(name)]; !ok {
+					*problems = append(*problems, fmt.Sprintf("%s.%v is required", pointer, name))
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, property := range properties {
+			propertySchema, _ := property.(map[string]interface{})
+			if field, ok := object[name]; ok && propertySchema != nil {
+				s.validate(propertySchema, field, pointer+"."+name, problems)
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s is not an array", pointer))
+			return
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range array {
+				s.validate(items, item, fmt.Sprintf("%s[%d]", pointer, i), problems)
+			}
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s is not a string", pointer))
+			return
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, text); err != nil {
+				*problems = append(*problems, fmt.Sprintf("%s is not a date-time: %q", pointer, text))
+			}
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if _, err := number.Int64(); !ok || err != nil {
+			*problems = append(*problems, fmt.Sprintf("%s is not an integer", pointer))
+			return
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			*problems = append(*problems, fmt.Sprintf("%s is not a number", pointer))
+			return
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			*problems = append(*problems, fmt.Sprintf("%s is not a boolean", pointer))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !inEnum(enum, value) {
+		*problems = append(*problems, fmt.Sprintf("%s is %v, not one of %v", pointer, value, enum))
+	}
+}
+
+// inEnum reports whether value equals one of the enum values of a schema
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPath splits a path into its segments, ignoring leading and trailing slashes
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// matchTemplate matches path segments against a template such as /programs/{id}, returning the number of parameters
+func matchTemplate(template, segments []string) (int, bool) {
+	if len(template) != len(segments) {
+		return 0, false
+	}
+	params := 0
+	for i, part := range template {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return 0, false
+			}
+			params++
+			continue
+		}
+		if part != segments[i] {
+			return 0, false
+		}
+	}
+	return params, true
+}
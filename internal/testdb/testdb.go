@@ -0,0 +1,35 @@
+package testdb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"linn221/Requester/migrations"
+	"linn221/Requester/store"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Open opens a migrated SQLite database in a temporary directory of the test, storing bodies as the app does
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "requester.db")
+	db, err := gorm.Open(sqlite.Open(path+"?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_txlock=immediate"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.Use(store.BodyStore{}); err != nil {
+		t.Fatalf("failed to register the body store: %v", err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
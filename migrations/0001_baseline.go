@@ -0,0 +1,175 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// The baseline creates the tables previously kept up to date by AutoMigrate on every start.
+// On databases created that way it only adds what is missing, so they adopt the migrations as they are.
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			// Disable foreign key checks temporarily, MySQL refuses to alter columns that constraints refer to
+			if tx.Dialector.Name() == "mysql" {
+				tx.Exec("SET FOREIGN_KEY_CHECKS = 0")
+				defer tx.Exec("SET FOREIGN_KEY_CHECKS = 1")
+			}
+			if err := dropLegacyForeignKeys(tx); err != nil {
+				return err
+			}
+			if err := tx.AutoMigrate(&programV1{}); err != nil {
+				return err
+			}
+			return tx.AutoMigrate(&endpointV1{}, &importJobV1{}, &myRequestV1{}, &fuzzAttemptV1{}, &identityV1{}, &authzAttemptV1{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&authzAttemptV1{}, &identityV1{}, &fuzzAttemptV1{}, &myRequestV1{}, &importJobV1{}, &endpointV1{}, &programV1{})
+		},
+	})
+}
+
+// dropLegacyForeignKeys drops the constraints of the program relationships, left by databases created before they
+// were disabled
+func dropLegacyForeignKeys(tx *gorm.DB) error {
+	legacy := []struct {
+		model interface{}
+		name  string
+	}{
+		{&endpointV1{}, "fk_programs_endpoints"},
+		{&importJobV1{}, "fk_programs_import_jobs"},
+		{&myRequestV1{}, "fk_programs_my_requests"},
+	}
+	for _, constraint := range legacy {
+		if !tx.Migrator().HasTable(constraint.model) || !tx.Migrator().HasConstraint(constraint.model, constraint.name) {
+			continue
+		}
+		if err := tx.Migrator().DropConstraint(constraint.model, constraint.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type programV1 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:255;not null"`
+	URL       string `gorm:"size:500"`
+	Notes     string `gorm:"type:text"`
+	Scope     string `gorm:"type:text"`
+	Domains   string `gorm:"type:text"`
+	PathRules string `gorm:"type:text"`
+	CreatedAt int64  `gorm:"autoCreateTime"`
+	UpdatedAt int64  `gorm:"autoUpdateTime"`
+}
+
+func (programV1) TableName() string { return "programs" }
+
+type endpointV1 struct {
+	ID           uint   `gorm:"primaryKey"`
+	ProgramID    *uint  `gorm:"index"`
+	Method       string `gorm:"size:10;not null"`
+	Domain       string `gorm:"size:255;not null"`
+	URI          string `gorm:"type:text;not null"`
+	EndpointType string `gorm:"size:20;not null;default:'API'"`
+	Source       string `gorm:"size:20;not null;default:'capture'"`
+	Notes        string `gorm:"type:text"`
+	CreatedAt    int64  `gorm:"autoCreateTime"`
+	UpdatedAt    int64  `gorm:"autoUpdateTime"`
+
+	Requests []myRequestV1 `gorm:"foreignKey:EndpointID"`
+}
+
+func (endpointV1) TableName() string { return "endpoints" }
+
+type importJobV1 struct {
+	ID             uint   `gorm:"primaryKey"`
+	ProgramID      *uint  `gorm:"index"`
+	Title          string `gorm:"not null"`
+	IgnoredHeaders string `gorm:"type:text"`
+	IgnoredFields  string `gorm:"type:text"`
+	JobType        string `gorm:"size:20;not null;default:'import_har'"`
+	Status         string `gorm:"size:20;not null;default:'done';index"`
+	Progress       int    `gorm:"not null;default:0"`
+	Error          string `gorm:"type:text"`
+	Summary        string `gorm:"type:text"`
+	RequestCount   int    `gorm:"not null;default:0"`
+	EndpointCount  int    `gorm:"not null;default:0"`
+	DomainCount    int    `gorm:"not null;default:0"`
+	StartedAt      int64
+	FinishedAt     int64
+	CreatedAt      int64 `gorm:"autoCreateTime"`
+	UpdatedAt      int64 `gorm:"autoUpdateTime"`
+
+	Requests []myRequestV1 `gorm:"foreignKey:ImportJobID"`
+}
+
+func (importJobV1) TableName() string { return "import_jobs" }
+
+type myRequestV1 struct {
+	ID          uint   `gorm:"primaryKey"`
+	ProgramID   *uint  `gorm:"index"`
+	ImportJobID uint   `gorm:"not null;index"`
+	EndpointID  uint   `gorm:"not null;index"`
+	Sequence    int    `gorm:"not null"`
+	URL         string `gorm:"type:text;not null"`
+	Method      string `gorm:"size:10;not null"`
+	Domain      string `gorm:"size:255;not null"`
+	ReqHeaders  string `gorm:"type:text"`
+	ReqBody     string `gorm:"type:longtext"`
+	ResStatus   int    `gorm:"not null"`
+	ResHeaders  string `gorm:"type:text"`
+	ResBody     string `gorm:"type:longtext"`
+	RespSize    int    `gorm:"not null"`
+	LatencyMs   int64  `gorm:"not null"`
+	RequestTime string `gorm:"size:50"`
+	ReplayOfID  *uint  `gorm:"index"`
+	OutOfScope  bool   `gorm:"not null;default:false;index"`
+	ReqHash1    string `gorm:"size:64;index"`
+	ReqHash     string `gorm:"size:64;index"`
+	ResHash     string `gorm:"size:64;index"`
+	ResBodyHash string `gorm:"size:64;index"`
+	CreatedAt   int64  `gorm:"autoCreateTime"`
+	UpdatedAt   int64  `gorm:"autoUpdateTime"`
+}
+
+func (myRequestV1) TableName() string { return "my_requests" }
+
+type fuzzAttemptV1 struct {
+	ID             uint   `gorm:"primaryKey"`
+	ImportJobID    uint   `gorm:"not null;index"`
+	RequestID      uint   `gorm:"not null;index"`
+	Sequence       int    `gorm:"not null"`
+	InsertionPoint string `gorm:"size:255;not null"`
+	Payload        string `gorm:"type:text"`
+	Error          string `gorm:"type:text"`
+	CreatedAt      int64  `gorm:"autoCreateTime"`
+}
+
+func (fuzzAttemptV1) TableName() string { return "fuzz_attempts" }
+
+type identityV1 struct {
+	ID            uint   `gorm:"primaryKey"`
+	ProgramID     uint   `gorm:"not null;index"`
+	Name          string `gorm:"size:255;not null"`
+	Privilege     int    `gorm:"not null;default:0"`
+	Headers       string `gorm:"type:text"`
+	RemoveHeaders string `gorm:"type:text"`
+	CreatedAt     int64  `gorm:"autoCreateTime"`
+	UpdatedAt     int64  `gorm:"autoUpdateTime"`
+}
+
+func (identityV1) TableName() string { return "identities" }
+
+type authzAttemptV1 struct {
+	ID          uint   `gorm:"primaryKey"`
+	ImportJobID uint   `gorm:"not null;index"`
+	OriginalID  uint   `gorm:"not null;index"`
+	IdentityID  uint   `gorm:"not null;index"`
+	RequestID   uint   `gorm:"not null;index"`
+	Verdict     string `gorm:"size:20;not null"`
+	Flagged     bool   `gorm:"not null;default:false"`
+	Error       string `gorm:"type:text"`
+	CreatedAt   int64  `gorm:"autoCreateTime"`
+}
+
+func (authzAttemptV1) TableName() string { return "authz_attempts" }
@@ -0,0 +1,40 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// Notes and attachments of programs, endpoints and requests
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "notes_attachments",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&noteV2{}, &attachmentV2{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&attachmentV2{}, &noteV2{})
+		},
+	})
+}
+
+type noteV2 struct {
+	ID            uint   `gorm:"primaryKey"`
+	ReferenceType string `gorm:"size:20;not null;index:idx_note_reference"`
+	ReferenceID   uint   `gorm:"not null;index:idx_note_reference"`
+	Value         string `gorm:"type:text;not null"`
+	CreatedAt     int64  `gorm:"autoCreateTime"`
+	UpdatedAt     int64  `gorm:"autoUpdateTime"`
+}
+
+func (noteV2) TableName() string { return "notes" }
+
+type attachmentV2 struct {
+	ID            uint   `gorm:"primaryKey"`
+	ReferenceType string `gorm:"size:20;not null;index:idx_attachment_reference"`
+	ReferenceID   uint   `gorm:"not null;index:idx_attachment_reference"`
+	Filename      string `gorm:"size:255;not null"`
+	StoredName    string `gorm:"size:64;not null"`
+	Size          int64  `gorm:"not null"`
+	CreatedAt     int64  `gorm:"autoCreateTime"`
+}
+
+func (attachmentV2) TableName() string { return "attachments" }
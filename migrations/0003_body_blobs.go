@@ -0,0 +1,187 @@
+package migrations
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// bodyBatchSize is the number of requests or blobs moved per query
+const bodyBatchSize = 200
+
+// Response bodies move out of my_requests into body_blobs, compressed and stored once per content
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "body_blobs",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&bodyBlobV3{}); err != nil {
+				return err
+			}
+			if err := tx.Migrator().AddColumn(&myRequestV3{}, "ResBodyRef"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().CreateIndex(&myRequestV3{}, "ResBodyRef"); err != nil {
+				return err
+			}
+			if err := moveBodiesToBlobs(tx); err != nil {
+				return err
+			}
+			return dropColumn(tx, "my_requests", "res_body")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&myRequestV1{}, "ResBody"); err != nil {
+				return err
+			}
+			if err := moveBlobsToBodies(tx); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropIndex(&myRequestV3{}, "ResBodyRef"); err != nil {
+				return err
+			}
+			if err := dropColumn(tx, "my_requests", "res_body_ref"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropTable(&bodyBlobV3{})
+		},
+	})
+}
+
+// moveBodiesToBlobs stores the body of every request as a blob and points the request at it
+func moveBodiesToBlobs(tx *gorm.DB) error {
+	var lastID uint
+	for {
+		var rows []struct {
+			ID      uint
+			ResBody string
+		}
+		err := tx.Table("my_requests").Select("id, res_body").Where("id > ?", lastID).Order("id ASC").Limit(bodyBatchSize).Scan(&rows).Error
+		if err != nil {
+			return fmt.Errorf("failed to read response bodies: %v", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		lastID = rows[len(rows)-1].ID
+
+		idsByRef := make(map[string][]uint)
+		blobs := make([]bodyBlobV3, 0, len(rows))
+		for _, row := range rows {
+			if row.ResBody == "" {
+				continue
+			}
+			ref := bodyRefV3(row.ResBody)
+			if _, ok := idsByRef[ref]; !ok {
+				blob, err := newBodyBlobV3(ref, row.ResBody)
+				if err != nil {
+					return err
+				}
+				blobs = append(blobs, blob)
+			}
+			idsByRef[ref] = append(idsByRef[ref], row.ID)
+		}
+		if len(blobs) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&blobs).Error; err != nil {
+				return fmt.Errorf("failed to save response bodies: %v", err)
+			}
+		}
+		for ref, ids := range idsByRef {
+			if err := tx.Table("my_requests").Where("id IN ?", ids).Update("res_body_ref", ref).Error; err != nil {
+				return fmt.Errorf("failed to point requests at their response bodies: %v", err)
+			}
+		}
+	}
+}
+
+// moveBlobsToBodies copies every blob back into the requests pointing at it
+func moveBlobsToBodies(tx *gorm.DB) error {
+	last := ""
+	for {
+		var blobs []bodyBlobV3
+		if err := tx.Where("hash > ?", last).Order("hash ASC").Limit(bodyBatchSize).Find(&blobs).Error; err != nil {
+			return fmt.Errorf("failed to read response bodies: %v", err)
+		}
+		if len(blobs) == 0 {
+			return nil
+		}
+		last = blobs[len(blobs)-1].Hash
+
+		for _, blob := range blobs {
+			body, err := blob.body()
+			if err != nil {
+				return err
+			}
+			if err := tx.Table("my_requests").Where("res_body_ref = ?", blob.Hash).Update("res_body", body).Error; err != nil {
+				return fmt.Errorf("failed to restore response bodies: %v", err)
+			}
+		}
+	}
+}
+
+type bodyBlobV3 struct {
+	Hash      string `gorm:"size:64;primaryKey"`
+	Encoding  string `gorm:"size:20;not null"`
+	Size      int64  `gorm:"not null"`
+	Data      []byte `gorm:"not null"`
+	CreatedAt int64  `gorm:"autoCreateTime"`
+}
+
+func (bodyBlobV3) TableName() string { return "body_blobs" }
+
+// The blob helpers below are copies of those in requests as they were when this migration was written,
+// so later changes to how bodies are stored do not change what it does.
+
+// bodyRefV3 is the hex sha256 of a body, the key of its blob
+func bodyRefV3(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// newBodyBlobV3 gzips a body into its blob, keeping it as is when compression does not make it smaller
+func newBodyBlobV3(ref, body string) (bodyBlobV3, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(w, body); err != nil {
+		return bodyBlobV3{}, fmt.Errorf("failed to compress body: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return bodyBlobV3{}, fmt.Errorf("failed to compress body: %v", err)
+	}
+	if buf.Len() >= len(body) {
+		return bodyBlobV3{Hash: ref, Encoding: "identity", Size: int64(len(body)), Data: []byte(body)}, nil
+	}
+	return bodyBlobV3{Hash: ref, Encoding: "gzip", Size: int64(len(body)), Data: buf.Bytes()}, nil
+}
+
+// body decompresses the blob
+func (b bodyBlobV3) body() (string, error) {
+	switch b.Encoding {
+	case "identity":
+		return string(b.Data), nil
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(b.Data))
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress body %s: %v", b.Hash, err)
+		}
+		defer r.Close()
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress body %s: %v", b.Hash, err)
+		}
+		return string(body), nil
+	default:
+		return "", fmt.Errorf("body %s has unknown encoding %q", b.Hash, b.Encoding)
+	}
+}
+
+type myRequestV3 struct {
+	ResBodyRef string `gorm:"size:64;not null;default:'';index"`
+}
+
+func (myRequestV3) TableName() string { return "my_requests" }
@@ -0,0 +1,30 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// Requests record the encodings undone on their response body and its MIME type
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "response_encoding",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&myRequestV4{}, "ResBodyEncoding"); err != nil {
+				return err
+			}
+			return tx.Migrator().AddColumn(&myRequestV4{}, "ResMimeType")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := dropColumn(tx, "my_requests", "res_mime_type"); err != nil {
+				return err
+			}
+			return dropColumn(tx, "my_requests", "res_body_encoding")
+		},
+	})
+}
+
+type myRequestV4 struct {
+	ResBodyEncoding string `gorm:"size:50;not null;default:''"`
+	ResMimeType     string `gorm:"size:255;not null;default:''"`
+}
+
+func (myRequestV4) TableName() string { return "my_requests" }
@@ -0,0 +1,136 @@
+package migrations
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Replays move out of the import job of their original into a replay job per original
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "replay_jobs",
+		Up: func(tx *gorm.DB) error {
+			var originalIDs []uint
+			if err := tx.Table("my_requests").
+				Where("replay_of_id IS NOT NULL AND import_job_id NOT IN (SELECT id FROM import_jobs WHERE job_type = ?)", jobTypeReplayV5).
+				Distinct("replay_of_id").Order("replay_of_id ASC").Pluck("replay_of_id", &originalIDs).Error; err != nil {
+				return err
+			}
+			for _, originalID := range originalIDs {
+				if err := moveReplaysV5(tx, originalID); err != nil {
+					return fmt.Errorf("failed to move replays of request %d: %v", originalID, err)
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// Replays go back into the import job and sequence of their original
+			var replays []myRequestV5
+			if err := tx.Where("replay_of_id IS NOT NULL AND import_job_id IN (SELECT id FROM import_jobs WHERE job_type = ?)", jobTypeReplayV5).
+				Find(&replays).Error; err != nil {
+				return err
+			}
+			for _, replay := range replays {
+				var original myRequestV5
+				if err := tx.First(&original, *replay.ReplayOfID).Error; err != nil {
+					return fmt.Errorf("failed to fetch the original of replay %d: %v", replay.ID, err)
+				}
+				if err := tx.Table("my_requests").Where("id = ?", replay.ID).
+					Updates(map[string]interface{}{"import_job_id": original.ImportJobID, "sequence": original.Sequence}).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Exec(`DELETE FROM import_jobs WHERE job_type = ?
+				AND NOT EXISTS (SELECT 1 FROM my_requests WHERE my_requests.import_job_id = import_jobs.id)`, jobTypeReplayV5).Error
+		},
+	})
+}
+
+const jobTypeReplayV5 = "replay"
+
+type importJobV5 struct {
+	ID             uint `gorm:"primaryKey"`
+	ProgramID      *uint
+	Title          string
+	IgnoredHeaders string
+	IgnoredFields  string
+	JobType        string
+	Status         string
+	Progress       int
+	RequestCount   int
+	EndpointCount  int
+	DomainCount    int
+	StartedAt      int64
+	FinishedAt     int64
+	CreatedAt      int64 `gorm:"autoCreateTime"`
+	UpdatedAt      int64 `gorm:"autoUpdateTime"`
+}
+
+func (importJobV5) TableName() string { return "import_jobs" }
+
+type myRequestV5 struct {
+	ID          uint `gorm:"primaryKey"`
+	ProgramID   *uint
+	ImportJobID uint
+	ReplayOfID  *uint
+	EndpointID  uint
+	Sequence    int
+	Method      string
+	URL         string
+	Domain      string
+}
+
+func (myRequestV5) TableName() string { return "my_requests" }
+
+// moveReplaysV5 creates the replay job of one original, copying the hashing settings of the original's job,
+// and renumbers its replays in the order they were sent
+func moveReplaysV5(tx *gorm.DB, originalID uint) error {
+	var original myRequestV5
+	if err := tx.First(&original, originalID).Error; err != nil {
+		return err
+	}
+	var source importJobV5
+	if err := tx.First(&source, original.ImportJobID).Error; err != nil {
+		return err
+	}
+
+	var replays []myRequestV5
+	if err := tx.Where("replay_of_id = ?", originalID).Order("id ASC").Find(&replays).Error; err != nil {
+		return err
+	}
+	endpoints := map[uint]bool{}
+	domains := map[string]bool{}
+	for _, replay := range replays {
+		endpoints[replay.EndpointID] = true
+		domains[replay.Domain] = true
+	}
+
+	now := time.Now().Unix()
+	job := importJobV5{
+		ProgramID:      original.ProgramID,
+		Title:          fmt.Sprintf("Replays of #%d %s %s", original.ID, original.Method, original.URL),
+		IgnoredHeaders: source.IgnoredHeaders,
+		IgnoredFields:  source.IgnoredFields,
+		JobType:        jobTypeReplayV5,
+		Status:         "done",
+		Progress:       100,
+		RequestCount:   len(replays),
+		EndpointCount:  len(endpoints),
+		DomainCount:    len(domains),
+		StartedAt:      now,
+		FinishedAt:     now,
+	}
+	if err := tx.Create(&job).Error; err != nil {
+		return err
+	}
+	for i, replay := range replays {
+		if err := tx.Table("my_requests").Where("id = ?", replay.ID).
+			Updates(map[string]interface{}{"import_job_id": job.ID, "sequence": i + 1}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
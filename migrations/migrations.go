@@ -0,0 +1,157 @@
+package migrations
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a numbered, reversible change of the database schema.
+// Migrations work on their own snapshot structs, not the live models, so later model changes never alter them.
+// They change the schema through the gorm migrator so they run on every supported database.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration in the schema_migrations table
+type SchemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255;not null"`
+	AppliedAt int64  `gorm:"not null"`
+}
+
+// TableName returns the table of applied migrations
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// dropColumn drops a column that is not part of an index.
+// The SQLite migrator of gorm drops columns by copying the table, which loses its indexes, so both databases use
+// ALTER TABLE instead.
+func dropColumn(tx *gorm.DB, table, column string) error {
+	return tx.Exec(fmt.Sprintf("ALTER TABLE `%s` DROP COLUMN `%s`", table, column)).Error
+}
+
+// Status is a known migration and whether it has been applied
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt int64
+}
+
+// registry holds the migrations registered by the numbered files of this package
+var registry []Migration
+
+// register adds a migration, called from the init function of its file
+func register(migration Migration) {
+	registry = append(registry, migration)
+}
+
+// All returns the known migrations by version, failing on duplicate or missing versions
+func All() ([]Migration, error) {
+	all := make([]Migration, len(registry))
+	copy(all, registry)
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	for i, migration := range all {
+		if migration.Version != i+1 {
+			return nil, fmt.Errorf("migration %d %q is out of sequence, expected version %d", migration.Version, migration.Name, i+1)
+		}
+		if migration.Up == nil || migration.Down == nil {
+			return nil, fmt.Errorf("migration %d %q needs both up and down steps", migration.Version, migration.Name)
+		}
+	}
+	return all, nil
+}
+
+// Up applies the pending migrations in order, each in its own transaction, and returns the applied ones.
+// MySQL commits schema changes implicitly, so a failed migration there can leave part of its changes behind.
+func Up(db *gorm.DB) ([]Migration, error) {
+	statuses, err := GetStatus(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, status := range statuses {
+		if status.Applied {
+			continue
+		}
+		migration := status.Migration
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			record := SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now().Unix()}
+			return tx.Create(&record).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("failed to apply migration %d %s: %v", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// Down rolls back the latest steps applied migrations, newest first, and returns the rolled back ones
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	statuses, err := GetStatus(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var rolledBack []Migration
+	for i := len(statuses) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		if !statuses[i].Applied {
+			continue
+		}
+		migration := statuses[i].Migration
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("failed to roll back migration %d %s: %v", migration.Version, migration.Name, err)
+		}
+		rolledBack = append(rolledBack, migration)
+	}
+	return rolledBack, nil
+}
+
+// GetStatus lists every known migration with the time it was applied, creating the schema_migrations table when missing
+func GetStatus(db *gorm.DB) ([]Status, error) {
+	all, err := All()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+
+	var records []SchemaMigration
+	if err := db.Order("version ASC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+	appliedAt := make(map[int]int64, len(records))
+	for _, record := range records {
+		appliedAt[record.Version] = record.AppliedAt
+	}
+	for version := range appliedAt {
+		if version > len(all) {
+			return nil, fmt.Errorf("database has migration %d applied, which this build does not know", version)
+		}
+	}
+
+	statuses := make([]Status, 0, len(all))
+	for _, migration := range all {
+		at, ok := appliedAt[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: ok, AppliedAt: at})
+	}
+	return statuses, nil
+}
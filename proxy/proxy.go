@@ -0,0 +1,353 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"linn221/Requester/requests"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder stores the exchanges seen by the proxy
+type Recorder interface {
+	Record(ctx context.Context, temp requests.TempMyRequest) error
+	// Skip counts an exchange that was proxied but not handed to Record, it must not block
+	Skip()
+}
+
+// recordQueueSize is how many exchanges may wait for the recorder before new ones are skipped
+const recordQueueSize = 256
+
+// hopHeaders only apply to a single connection and are not forwarded
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// Proxy is an intercepting HTTP proxy. Plain requests are forwarded as they come,
+// CONNECT tunnels are terminated with a certificate signed by the CA so HTTPS can be recorded too.
+type Proxy struct {
+	ca        *CA
+	recorder  Recorder
+	transport *http.Transport
+
+	// Exchanges are recorded by a single worker so a slow store never holds up the traffic
+	mu       sync.RWMutex
+	closed   bool
+	queue    chan requests.TempMyRequest
+	recorded chan struct{}
+}
+
+// New creates a Proxy recording every exchange with recorder.
+// Close must be called to wait for the queued exchanges once the server stopped.
+func New(ca *CA, recorder Recorder) *Proxy {
+	p := &Proxy{
+		ca:       ca,
+		recorder: recorder,
+		queue:    make(chan requests.TempMyRequest, recordQueueSize),
+		recorded: make(chan struct{}),
+		transport: &http.Transport{
+			Proxy:                 nil, // never loop back into another proxy
+			TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
+			MaxIdleConnsPerHost:   10,
+			IdleConnTimeout:       90 * time.Second,
+			ResponseHeaderTimeout: 60 * time.Second,
+		},
+	}
+	go p.recordQueued()
+	return p
+}
+
+// recordQueued stores the queued exchanges until the queue is closed
+func (p *Proxy) recordQueued() {
+	defer close(p.recorded)
+	for temp := range p.queue {
+		if err := p.recorder.Record(context.Background(), temp); err != nil {
+			log.Printf("[PROXY] failed to record %s %s: %v", temp.Method, temp.URL, err)
+		}
+	}
+}
+
+// enqueue hands an exchange to the recording worker, skipping it when the queue is full or closed
+func (p *Proxy) enqueue(temp requests.TempMyRequest) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		p.recorder.Skip()
+		return
+	}
+	select {
+	case p.queue <- temp:
+	default:
+		log.Printf("[PROXY] recording queue is full, skipped %s %s", temp.Method, temp.URL)
+		p.recorder.Skip()
+	}
+}
+
+// Close stops accepting exchanges and waits until the queued ones are recorded
+func (p *Proxy) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+	<-p.recorded
+}
+
+// ServeHTTP handles a request sent to the proxy
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.handleConnect(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "This is a proxy, configure it in the browser instead of opening it", http.StatusBadRequest)
+		return
+	}
+	p.forward(w, r)
+}
+
+// handleConnect terminates a CONNECT tunnel and serves the requests sent through it
+func (p *Proxy) handleConnect(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "CONNECT is not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		log.Printf("[PROXY] failed to hijack %s: %v", r.Host, err)
+		return
+	}
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		conn.Close()
+		return
+	}
+
+	tunnelHost := r.Host
+	defaultName, _, err := net.SplitHostPort(tunnelHost)
+	if err != nil {
+		defaultName = tunnelHost
+	}
+	tlsConn := tls.Server(conn, &tls.Config{
+		NextProtos: []string{"http/1.1"},
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			name := hello.ServerName
+			if name == "" {
+				name = defaultName
+			}
+			return p.ca.CertFor(name)
+		},
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		log.Printf("[PROXY] TLS handshake with client for %s failed, is the CA trusted? %v", tunnelHost, err)
+		conn.Close()
+		return
+	}
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.URL.Scheme = "https"
+			r.URL.Host = r.Host
+			if r.URL.Host == "" {
+				r.URL.Host = tunnelHost
+			}
+			p.forward(w, r)
+		}),
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	server.Serve(newSingleConnListener(tlsConn))
+}
+
+// forward sends a request upstream, streams the response back and queues the exchange to be recorded.
+// Bodies are captured up to requests.MaxBodySize, exchanges with a larger body are passed through but not recorded.
+func (p *Proxy) forward(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Upgrade") != "" {
+		http.Error(w, "Protocol upgrades such as WebSocket are not supported by the proxy", http.StatusNotImplemented)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	reqCapture := &captureBuffer{limit: requests.MaxBodySize}
+	if r.Body != nil && r.Body != http.NoBody {
+		out.Body = io.NopCloser(io.TeeReader(r.Body, reqCapture))
+	}
+	removeHopHeaders(out.Header)
+	// The transport negotiates compression itself and hands back a decoded body to store
+	out.Header.Del("Accept-Encoding")
+
+	started := time.Now()
+	res, err := p.transport.RoundTrip(out)
+	if err != nil {
+		log.Printf("[PROXY] %s %s: %v", r.Method, r.URL, err)
+		http.Error(w, "Upstream request failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer res.Body.Close()
+
+	removeHopHeaders(res.Header)
+	res.Header.Del("Content-Length")
+	for name, values := range res.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.WriteHeader(res.StatusCode)
+
+	resCapture := &captureBuffer{limit: requests.MaxBodySize}
+	if err := streamBody(w, io.TeeReader(res.Body, resCapture)); err != nil {
+		log.Printf("[PROXY] %s %s: failed to stream response: %v", r.Method, r.URL, err)
+		p.recorder.Skip()
+		return
+	}
+	latency := time.Since(started)
+
+	if reqCapture.truncated || resCapture.truncated {
+		log.Printf("[PROXY] %s %s: body exceeds %d MB, not recorded", r.Method, r.URL, requests.MaxBodySize>>20)
+		p.recorder.Skip()
+		return
+	}
+	temp := newTempMyRequest(r, reqCapture.buf.Bytes())
+	temp.SetResponse(res, resCapture.buf.Bytes(), started, latency)
+	p.enqueue(temp)
+}
+
+// streamBody copies a response body to the client, flushing every chunk so event streams are not held back
+func streamBody(w http.ResponseWriter, body io.Reader) error {
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// captureBuffer keeps the first limit bytes written to it and notes whether more were written.
+// Writes never fail so the stream it is teed from is not interrupted.
+type captureBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write keeps what still fits under the limit
+func (c *captureBuffer) Write(p []byte) (int, error) {
+	if room := c.limit - c.buf.Len(); len(p) > room {
+		c.buf.Write(p[:room])
+		c.truncated = true
+	} else {
+		c.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// newTempMyRequest converts a request received by the proxy, headers sorted by name after Host
+func newTempMyRequest(r *http.Request, body []byte) requests.TempMyRequest {
+	header := r.Header.Clone()
+	removeHopHeaders(header)
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := requests.HeaderSlice{{Name: "Host", Value: r.Host}}
+	for _, name := range names {
+		for _, value := range header[name] {
+			headers = append(headers, requests.Header{Name: name, Value: value})
+		}
+	}
+
+	return requests.TempMyRequest{
+		URL:        r.URL.String(),
+		Method:     r.Method,
+		Domain:     r.URL.Hostname(),
+		ReqHeaders: headers,
+		ReqBody:    string(body),
+	}
+}
+
+// removeHopHeaders deletes the connection specific headers, including those named by Connection
+func removeHopHeaders(header http.Header) {
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				header.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+}
+
+// singleConnListener hands out one connection, then blocks until it is closed
+type singleConnListener struct {
+	conn   net.Conn
+	once   sync.Once
+	closed chan struct{}
+}
+
+// newSingleConnListener serves a single connection with an http.Server
+func newSingleConnListener(conn net.Conn) *singleConnListener {
+	return &singleConnListener{conn: conn, closed: make(chan struct{})}
+}
+
+// Accept returns the connection on the first call, later calls wait for it to close
+func (l *singleConnListener) Accept() (net.Conn, error) {
+	var conn net.Conn
+	l.once.Do(func() {
+		conn = &notifyConn{Conn: l.conn, closed: l.closed}
+	})
+	if conn != nil {
+		return conn, nil
+	}
+	<-l.closed
+	return nil, net.ErrClosed
+}
+
+// Close does nothing, the connection is closed by the server
+func (l *singleConnListener) Close() error {
+	return nil
+}
+
+// Addr returns the local address of the connection
+func (l *singleConnListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// notifyConn reports its closing to the listener it came from
+type notifyConn struct {
+	net.Conn
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Close closes the connection and releases the listener
+func (c *notifyConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() { close(c.closed) })
+	return err
+}
@@ -1,6 +1,7 @@
 package requests
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"linn221/Requester/utils"
@@ -17,6 +18,160 @@ const (
 	EndpointTypeGraphQL EndpointType = "GraphQL"
 )
 
+// EndpointSource records where an endpoint was first discovered
+type EndpointSource string
+
+const (
+	EndpointSourceCapture    EndpointSource = "capture"    // seen in captured traffic
+	EndpointSourceCollection EndpointSource = "collection" // documented in a Postman or Insomnia collection
+	EndpointSourceOpenAPI    EndpointSource = "openapi"    // seeded from an OpenAPI or Swagger document
+	EndpointSourceManual     EndpointSource = "manual"     // added by hand through the API
+)
+
+// IsDocumented reports whether endpoints from this source come from API documentation rather than traffic
+func (s EndpointSource) IsDocumented() bool {
+	return s == EndpointSourceCollection || s == EndpointSourceOpenAPI || s == EndpointSourceManual
+}
+
+// ImportJobStatus represents the lifecycle state of an import job
+type ImportJobStatus string
+
+const (
+	ImportJobStatusQueued  ImportJobStatus = "queued"
+	ImportJobStatusRunning ImportJobStatus = "running"
+	ImportJobStatusFailed  ImportJobStatus = "failed"
+	ImportJobStatusDone    ImportJobStatus = "done"
+)
+
+// JobType represents the kind of work an import job performs
+type JobType string
+
+const (
+	JobTypeImportHAR       JobType = "import_har"
+	JobTypeImportXML       JobType = "import_xml"
+	JobTypeImportZAP       JobType = "import_zap"
+	JobTypeImportMitmproxy JobType = "import_mitmproxy"
+	JobTypeImportRaw       JobType = "import_raw"
+	JobTypeImportCurl      JobType = "import_curl"
+	JobTypeImportPostman   JobType = "import_postman"
+	JobTypeImportInsomnia  JobType = "import_insomnia"
+	JobTypeFuzz            JobType = "fuzz"
+	JobTypeAuthz           JobType = "authz"
+	JobTypeReplay          JobType = "replay"
+	JobTypeCaptureProxy    JobType = "capture_proxy"
+	JobTypeRegroup         JobType = "regroup"
+	JobTypeRehash          JobType = "rehash"
+)
+
+// ImportFormat identifies the capture format of an uploaded file
+type ImportFormat string
+
+const (
+	ImportFormatHAR       ImportFormat = "har"
+	ImportFormatBurp      ImportFormat = "burp"
+	ImportFormatZAP       ImportFormat = "zap"
+	ImportFormatMitmproxy ImportFormat = "mitmproxy"
+	ImportFormatRaw       ImportFormat = "raw"
+	ImportFormatCurl      ImportFormat = "curl"
+	ImportFormatPostman   ImportFormat = "postman"
+	ImportFormatInsomnia  ImportFormat = "insomnia"
+)
+
+// ReferenceType names the kind of record a note or attachment belongs to
+type ReferenceType string
+
+const (
+	ReferencePrograms  ReferenceType = "programs"
+	ReferenceEndpoints ReferenceType = "endpoints"
+	ReferenceRequests  ReferenceType = "requests"
+)
+
+// ReferenceTypes lists the record kinds notes and attachments can belong to
+var ReferenceTypes = []ReferenceType{ReferencePrograms, ReferenceEndpoints, ReferenceRequests}
+
+// ParseReferenceType reads a reference type, accepting the singular names used by filters such as ?type=endpoint
+func ParseReferenceType(name string) (ReferenceType, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, referenceType := range ReferenceTypes {
+		if name == string(referenceType) || name+"s" == string(referenceType) {
+			return referenceType, true
+		}
+	}
+	return "", false
+}
+
+// Label returns the singular display name of the reference type
+func (t ReferenceType) Label() string {
+	switch t {
+	case ReferencePrograms:
+		return "Program"
+	case ReferenceEndpoints:
+		return "Endpoint"
+	case ReferenceRequests:
+		return "Request"
+	default:
+		return string(t)
+	}
+}
+
+// HashKind names a stored hash requests can be clustered by
+type HashKind string
+
+const (
+	HashKindRequest  HashKind = "request"  // method, URL, headers and body
+	HashKindResponse HashKind = "response" // status, headers and body
+	HashKindBody     HashKind = "body"     // response body only
+)
+
+// HashKinds lists the hash kinds in the order they are shown
+var HashKinds = []HashKind{HashKindResponse, HashKindBody, HashKindRequest}
+
+// ParseHashKind returns the named hash kind, defaulting to the response hash
+func ParseHashKind(name string) HashKind {
+	switch HashKind(name) {
+	case HashKindRequest, HashKindBody:
+		return HashKind(name)
+	default:
+		return HashKindResponse
+	}
+}
+
+// Column returns the MyRequest column holding the hash
+func (k HashKind) Column() string {
+	switch k {
+	case HashKindRequest:
+		return "req_hash"
+	case HashKindBody:
+		return "res_body_hash"
+	default:
+		return "res_hash"
+	}
+}
+
+// Label returns the display name of the hash kind
+func (k HashKind) Label() string {
+	switch k {
+	case HashKindRequest:
+		return "Request"
+	case HashKindBody:
+		return "Response Body"
+	default:
+		return "Response"
+	}
+}
+
+// Hash returns the request's hash of this kind
+func (k HashKind) Hash(request MyRequest) string {
+	switch k {
+	case HashKindRequest:
+		return request.ReqHash
+	case HashKindBody:
+		return request.ResBodyHash
+	default:
+		return request.ResHash
+	}
+}
+
 // Program represents a program/project
 type Program struct {
 	ID        uint   `gorm:"primaryKey"`
@@ -25,6 +180,7 @@ type Program struct {
 	Notes     string `gorm:"type:text"`
 	Scope     string `gorm:"type:text"`
 	Domains   string `gorm:"type:text"` // Store as JSON string
+	PathRules string `gorm:"type:text"` // templating rules of endpoint paths, see ParsePathRules
 	CreatedAt int64  `gorm:"autoCreateTime"`
 	UpdatedAt int64  `gorm:"autoUpdateTime"`
 
@@ -36,32 +192,71 @@ type Program struct {
 
 // Endpoint represents an API endpoint
 type Endpoint struct {
-	ID           uint         `gorm:"primaryKey"`
-	ProgramID    *uint        `gorm:"index"` // Foreign key to Program (nullable for migration)
-	Method       string       `gorm:"size:10;not null"`
-	Domain       string       `gorm:"size:255;not null"`
-	URI          string       `gorm:"type:text;not null"`
-	EndpointType EndpointType `gorm:"size:20;not null;default:'API'"`
-	Notes        string       `gorm:"type:text"`
-	CreatedAt    int64        `gorm:"autoCreateTime"`
-	UpdatedAt    int64        `gorm:"autoUpdateTime"`
+	ID           uint           `gorm:"primaryKey"`
+	ProgramID    *uint          `gorm:"index"` // Foreign key to Program (nullable for migration)
+	Method       string         `gorm:"size:10;not null"`
+	Domain       string         `gorm:"size:255;not null"`
+	URI          string         `gorm:"type:text;not null"`
+	EndpointType EndpointType   `gorm:"size:20;not null;default:'API'"`
+	Source       EndpointSource `gorm:"size:20;not null;default:'capture'"`
+	Notes        string         `gorm:"type:text"`
+	CreatedAt    int64          `gorm:"autoCreateTime"`
+	UpdatedAt    int64          `gorm:"autoUpdateTime"`
 
 	// One-to-many relationship
 	Requests []MyRequest `gorm:"foreignKey:EndpointID"`
 }
 
 type ImportJob struct {
-	ID             uint   `gorm:"primaryKey"`
-	ProgramID      *uint  `gorm:"index"` // Foreign key to Program (nullable for migration)
-	Title          string `gorm:"not null"`
-	IgnoredHeaders string `gorm:"type:text"` // Store as JSON string
-	CreatedAt      int64  `gorm:"autoCreateTime"`
-	UpdatedAt      int64  `gorm:"autoUpdateTime"`
+	ID             uint            `gorm:"primaryKey"`
+	ProgramID      *uint           `gorm:"index"` // Foreign key to Program (nullable for migration)
+	Title          string          `gorm:"not null"`
+	IgnoredHeaders string          `gorm:"type:text"` // Store as JSON string
+	IgnoredFields  string          `gorm:"type:text"` // comma separated JSON body fields left out of the hashes
+	JobType        JobType         `gorm:"size:20;not null;default:'import_har'"`
+	Status         ImportJobStatus `gorm:"size:20;not null;default:'done';index"`
+	Progress       int             `gorm:"not null;default:0"` // Percent from 0 to 100
+	Error          string          `gorm:"type:text"`
+	Summary        string          `gorm:"type:text"`
+	RequestCount   int             `gorm:"not null;default:0"`
+	EndpointCount  int             `gorm:"not null;default:0"`
+	DomainCount    int             `gorm:"not null;default:0"`
+	StartedAt      int64
+	FinishedAt     int64
+	CreatedAt      int64 `gorm:"autoCreateTime"`
+	UpdatedAt      int64 `gorm:"autoUpdateTime"`
 
 	// One-to-many relationship
 	Requests []MyRequest `gorm:"foreignKey:ImportJobID"`
 }
 
+// IsFinished reports whether the job has stopped running, successfully or not
+func (j ImportJob) IsFinished() bool {
+	return j.Status == ImportJobStatusDone || j.Status == ImportJobStatusFailed
+}
+
+// IgnoredHeaderNames returns the header names excluded from hashing when the job ran
+func (j ImportJob) IgnoredHeaderNames() []string {
+	var names []string
+	for _, name := range strings.Split(j.IgnoredHeaders, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// IgnoredFieldNames returns the JSON body fields excluded from hashing when the job ran
+func (j ImportJob) IgnoredFieldNames() []string {
+	var names []string
+	for _, name := range strings.Split(j.IgnoredFields, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 type MyRequest struct {
 	ID          uint   `gorm:"primaryKey"`
 	ProgramID   *uint  `gorm:"index"`          // Foreign key to Program (nullable for migration)
@@ -76,12 +271,18 @@ type MyRequest struct {
 	ReqBody    string `gorm:"type:longtext"`
 
 	ResStatus  int    `gorm:"not null"`
-	ResHeaders string `gorm:"type:text"` // Store as JSON string
-	ResBody    string `gorm:"type:longtext"`
+	ResHeaders string `gorm:"type:text"`                         // Store as JSON string
+	ResBody    string `gorm:"-"`                                 // kept in body_blobs, stored and loaded by store.BodyStore
+	ResBodyRef string `gorm:"size:64;not null;default:'';index"` // BodyBlob of ResBody, empty for an empty body
 	RespSize   int    `gorm:"not null"`
 	LatencyMs  int64  `gorm:"not null"`
 
+	ResBodyEncoding string `gorm:"size:50;not null;default:''"`  // encodings undone on import, such as base64,gzip
+	ResMimeType     string `gorm:"size:255;not null;default:''"` // MIME type of the response body as captured
+
 	RequestTime string `gorm:"size:50"`
+	ReplayOfID  *uint  `gorm:"index"`                        // original request when this record was sent by the replay service
+	OutOfScope  bool   `gorm:"not null;default:false;index"` // URL matched none of the program's scope rules when stored
 	// hashes
 	ReqHash1    string `gorm:"size:64;index"` // hash raw request
 	ReqHash     string `gorm:"size:64;index"`
@@ -92,6 +293,73 @@ type MyRequest struct {
 	UpdatedAt int64 `gorm:"autoUpdateTime"`
 }
 
+// BodyBlob is a compressed response body shared by every request with the same content, keyed by its SHA-256
+type BodyBlob struct {
+	Hash      string `gorm:"size:64;primaryKey"`
+	Encoding  string `gorm:"size:20;not null"` // BodyEncodingGzip or BodyEncodingIdentity
+	Size      int64  `gorm:"not null"`         // length of the uncompressed body
+	Data      []byte `gorm:"not null"`
+	CreatedAt int64  `gorm:"autoCreateTime"`
+}
+
+// FuzzAttempt links a request sent by a fuzz run to the payload that produced it
+type FuzzAttempt struct {
+	ID             uint   `gorm:"primaryKey"`
+	ImportJobID    uint   `gorm:"not null;index"` // the fuzz run
+	RequestID      uint   `gorm:"not null;index"` // the request as sent, with its response
+	Sequence       int    `gorm:"not null"`       // order of the payload within the run
+	InsertionPoint string `gorm:"size:255;not null"`
+	Payload        string `gorm:"type:text"`
+	Error          string `gorm:"type:text"` // why no response was received
+	CreatedAt      int64  `gorm:"autoCreateTime"`
+}
+
+// Identity is a credential set of a program, swapped into requests to test authorization
+type Identity struct {
+	ID            uint   `gorm:"primaryKey"`
+	ProgramID     uint   `gorm:"not null;index"`
+	Name          string `gorm:"size:255;not null"`
+	Privilege     int    `gorm:"not null;default:0"` // higher values have more access, 0 for unauthenticated
+	Headers       string `gorm:"type:text"`          // Store as JSON string, set on every request
+	RemoveHeaders string `gorm:"type:text"`          // comma separated names dropped from every request
+	CreatedAt     int64  `gorm:"autoCreateTime"`
+	UpdatedAt     int64  `gorm:"autoUpdateTime"`
+}
+
+// AuthzAttempt links a request resent as an identity to the captured request it was made from
+type AuthzAttempt struct {
+	ID          uint         `gorm:"primaryKey"`
+	ImportJobID uint         `gorm:"not null;index"` // the authorization run
+	OriginalID  uint         `gorm:"not null;index"`
+	IdentityID  uint         `gorm:"not null;index"`
+	RequestID   uint         `gorm:"not null;index"` // the request as sent, with its response
+	Verdict     AuthzVerdict `gorm:"size:20;not null"`
+	Flagged     bool         `gorm:"not null;default:false"` // a lower privilege identity got the original response
+	Error       string       `gorm:"type:text"`              // why no response was received
+	CreatedAt   int64        `gorm:"autoCreateTime"`
+}
+
+// Note is a free-text note attached to a program, endpoint or request
+type Note struct {
+	ID            uint          `gorm:"primaryKey"`
+	ReferenceType ReferenceType `gorm:"size:20;not null;index:idx_note_reference"`
+	ReferenceID   uint          `gorm:"not null;index:idx_note_reference"`
+	Value         string        `gorm:"type:text;not null"`
+	CreatedAt     int64         `gorm:"autoCreateTime"`
+	UpdatedAt     int64         `gorm:"autoUpdateTime"`
+}
+
+// Attachment is an uploaded file of a program, endpoint or request, stored under the attachment directory
+type Attachment struct {
+	ID            uint          `gorm:"primaryKey"`
+	ReferenceType ReferenceType `gorm:"size:20;not null;index:idx_attachment_reference"`
+	ReferenceID   uint          `gorm:"not null;index:idx_attachment_reference"`
+	Filename      string        `gorm:"size:255;not null"` // name of the uploaded file
+	StoredName    string        `gorm:"size:64;not null"`  // name of the file in the attachment directory
+	Size          int64         `gorm:"not null"`
+	CreatedAt     int64         `gorm:"autoCreateTime"`
+}
+
 // Temporary struct for parsing HAR files (with HeaderSlice fields)
 type TempMyRequest struct {
 	Sequence    int
@@ -106,6 +374,10 @@ type TempMyRequest struct {
 	RespSize    int
 	LatencyMs   int64
 	RequestTime string
+	// ResBodyEncoding lists the encodings undone on ResBody, ResMimeType defaults to the Content-Type response header
+	ResBodyEncoding string
+	ResMimeType     string
+	Folder          string // folder path of a collection request, kept as an endpoint note
 	// hashes
 	ReqHash1    string
 	ReqHash     string
@@ -196,6 +468,11 @@ func (temp *TempMyRequest) ToMyRequest(programID, importJobID, endpointID uint)
 		return nil, fmt.Errorf("failed to convert response headers to JSON: %v", err)
 	}
 
+	mimeType := temp.ResMimeType
+	if mimeType == "" {
+		mimeType = temp.ResHeaders.Get("Content-Type")
+	}
+
 	return &MyRequest{
 		ProgramID:   &programID,
 		ImportJobID: importJobID,
@@ -216,69 +493,72 @@ func (temp *TempMyRequest) ToMyRequest(programID, importJobID, endpointID uint)
 		ReqHash:     temp.ReqHash,
 		ResHash:     temp.ResHash,
 		ResBodyHash: temp.ResBodyHash,
+
+		ResBodyEncoding: temp.ResBodyEncoding,
+		ResMimeType:     mimeType,
 	}, nil
 }
 
+// ApplyHashes computes the request and response hashes, resHashFunc decides which parts are hashed
+func (my *TempMyRequest) ApplyHashes(resHashFunc func(*TempMyRequest) (string, string)) {
+	requestText, responseText := resHashFunc(my)
+
+	my.ReqHash = utils.HashString(requestText)
+	my.ResHash = utils.HashString(responseText)
+	my.ResBodyHash = utils.HashString(my.ResBody)
+	my.ReqHash1 = utils.HashString(my.requestText())
+}
+
+// ParseHAR parses a whole HAR document held in memory
 func ParseHAR(bs []byte, resHashFunc func(*TempMyRequest) (string, string)) ([]TempMyRequest, error) {
-	var har HAR
-	if err := json.Unmarshal(bs, &har); err != nil {
+	var result []TempMyRequest
+	err := StreamHAR(bytes.NewReader(bs), ImportOptions{HashFunc: resHashFunc}, func(my TempMyRequest) error {
+		result = append(result, my)
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
+	return result, nil
+}
 
-	var result []TempMyRequest
-
-	for i, entry := range har.Log.Entries {
-		reqHeaders := make([]Header, 0, len(entry.Request.Headers))
-		for _, h := range entry.Request.Headers {
-			reqHeaders = append(reqHeaders, Header{Name: h.Name, Value: h.Value})
-		}
-
-		resHeaders := make([]Header, 0, len(entry.Response.Headers))
-		for _, h := range entry.Response.Headers {
-			resHeaders = append(resHeaders, Header{Name: h.Name, Value: h.Value})
-		}
-
-		u, err := url.Parse(entry.Request.URL)
-		domain := ""
-		if err == nil {
-			domain = u.Hostname()
-		}
-
-		resBody := entry.Response.Content.Text
-		// Decode base64 if needed
-		// if strings.ToLower(entry.Response.Content.Encoding) == "base64" {
-		// 	decoded, err := decodeBase64(resBody)
-		// 	if err == nil {
-		// 		resBody = decoded
-		// 	}
-		// }
-
-		my := TempMyRequest{
-			Sequence:    i + 1,
-			URL:         entry.Request.URL,
-			Domain:      domain,
-			ReqHeaders:  reqHeaders,
-			ReqBody:     entry.Request.PostData.Text,
-			ResHeaders:  resHeaders,
-			ResStatus:   entry.Response.Status,
-			ResBody:     resBody,
-			RespSize:    len(resBody),
-			LatencyMs:   int64(entry.Time),
-			RequestTime: entry.StartedDateTime,
-			Method:      entry.Request.Method,
-		}
-
-		requestText, responseText := resHashFunc(&my)
+// toTempMyRequest converts a HAR entry to a TempMyRequest without hashes
+func (entry HAREntry) toTempMyRequest(sequence int) TempMyRequest {
+	reqHeaders := make([]Header, 0, len(entry.Request.Headers))
+	for _, h := range entry.Request.Headers {
+		reqHeaders = append(reqHeaders, Header{Name: h.Name, Value: h.Value})
+	}
 
-		my.ReqHash = utils.HashString(requestText)
-		my.ResHash = utils.HashString(responseText)
-		my.ResBodyHash = utils.HashString(my.ResBody)
-		my.ReqHash1 = utils.HashString(my.requestText())
+	resHeaders := make([]Header, 0, len(entry.Response.Headers))
+	for _, h := range entry.Response.Headers {
+		resHeaders = append(resHeaders, Header{Name: h.Name, Value: h.Value})
+	}
 
-		result = append(result, my)
+	u, err := url.Parse(entry.Request.URL)
+	domain := ""
+	if err == nil {
+		domain = u.Hostname()
 	}
 
-	return result, nil
+	resBody, resBodyEncoding := decodeHARContent(entry.Response.Content.Text, entry.Response.Content.Encoding, resHeaders)
+
+	return TempMyRequest{
+		Sequence:    sequence,
+		URL:         entry.Request.URL,
+		Domain:      domain,
+		ReqHeaders:  reqHeaders,
+		ReqBody:     entry.Request.PostData.Text,
+		ResHeaders:  resHeaders,
+		ResStatus:   entry.Response.Status,
+		ResBody:     resBody,
+		RespSize:    len(resBody),
+		LatencyMs:   int64(entry.Time),
+		RequestTime: entry.StartedDateTime,
+		Method:      entry.Request.Method,
+
+		ResBodyEncoding: resBodyEncoding,
+		ResMimeType:     entry.Response.Content.MimeType,
+	}
 }
 
 // ExtractURIWithoutQuery extracts the URI path without query parameters
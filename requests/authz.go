@@ -0,0 +1,111 @@
+package requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AuthzVerdict compares the response an identity got with the captured response
+type AuthzVerdict string
+
+const (
+	AuthzSameBody   AuthzVerdict = "same_body"   // identical response body
+	AuthzSameStatus AuthzVerdict = "same_status" // different body with the same status code
+	AuthzDiffers    AuthzVerdict = "differs"     // different status code
+	AuthzFailed     AuthzVerdict = "failed"      // no response was received
+)
+
+// Rank orders verdicts from the least to the most suspicious
+func (v AuthzVerdict) Rank() int {
+	switch v {
+	case AuthzSameBody:
+		return 3
+	case AuthzSameStatus:
+		return 2
+	case AuthzDiffers:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// CompareAuthz judges the response of a variant sent as another identity against the original
+func CompareAuthz(original, variant MyRequest) AuthzVerdict {
+	switch {
+	case variant.ResStatus == 0:
+		return AuthzFailed
+	case variant.ResBodyHash == original.ResBodyHash && variant.ResStatus == original.ResStatus:
+		return AuthzSameBody
+	case variant.ResStatus == original.ResStatus:
+		return AuthzSameStatus
+	default:
+		return AuthzDiffers
+	}
+}
+
+// IsAuthzFinding reports whether a verdict means the identity was let through.
+// Only successful originals count, an identity also getting a 403 is not a finding.
+func IsAuthzFinding(original MyRequest, verdict AuthzVerdict) bool {
+	if original.ResStatus < 200 || original.ResStatus >= 300 {
+		return false
+	}
+	return verdict == AuthzSameBody || verdict == AuthzSameStatus
+}
+
+// HeaderSet decodes the headers the identity sets on every request
+func (i Identity) HeaderSet() (HeaderSlice, error) {
+	headers, err := decodeStoredHeaders(i.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid headers of identity %q: %v", i.Name, err)
+	}
+	return headers, nil
+}
+
+// RemoveHeaderNames returns the header names the identity drops from every request
+func (i Identity) RemoveHeaderNames() []string {
+	var names []string
+	for _, name := range strings.Split(i.RemoveHeaders, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Apply returns a copy of temp sent as the identity.
+// Removed headers and the headers the identity sets are dropped, then the identity's headers are added.
+func (i Identity) Apply(temp TempMyRequest) (TempMyRequest, error) {
+	set, err := i.HeaderSet()
+	if err != nil {
+		return temp, err
+	}
+
+	drop := make(map[string]bool)
+	for _, name := range i.RemoveHeaderNames() {
+		drop[strings.ToLower(name)] = true
+	}
+	for _, h := range set {
+		drop[strings.ToLower(h.Name)] = true
+	}
+
+	out := temp
+	out.ReqHeaders = nil
+	for _, h := range temp.ReqHeaders {
+		if !drop[strings.ToLower(h.Name)] {
+			out.ReqHeaders = append(out.ReqHeaders, h)
+		}
+	}
+	out.ReqHeaders = append(out.ReqHeaders, set...)
+	return out, nil
+}
+
+// SetHeaders stores the headers the identity sets on every request
+func (i *Identity) SetHeaders(headers HeaderSlice) error {
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode identity headers: %v", err)
+	}
+	i.Headers = string(data)
+	return nil
+}
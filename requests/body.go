@@ -0,0 +1,64 @@
+package requests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Encodings of body blobs
+const (
+	BodyEncodingIdentity = "identity" // stored as is, small bodies gzip would only grow
+	BodyEncodingGzip     = "gzip"
+)
+
+// BodyRef returns the content address of a body, the hex SHA-256 of its bytes, or empty for an empty body
+func BodyRef(body string) string {
+	if body == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewBodyBlob compresses a non empty body into its blob, keeping it as is when compression does not make it smaller
+func NewBodyBlob(body string) (BodyBlob, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(w, body); err != nil {
+		return BodyBlob{}, fmt.Errorf("failed to compress body: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return BodyBlob{}, fmt.Errorf("failed to compress body: %v", err)
+	}
+	blob := BodyBlob{Hash: BodyRef(body), Encoding: BodyEncodingGzip, Size: int64(len(body)), Data: buf.Bytes()}
+	if len(blob.Data) >= len(body) {
+		blob.Encoding, blob.Data = BodyEncodingIdentity, []byte(body)
+	}
+	return blob, nil
+}
+
+// Body decompresses the blob
+func (b BodyBlob) Body() (string, error) {
+	switch b.Encoding {
+	case BodyEncodingIdentity:
+		return string(b.Data), nil
+	case BodyEncodingGzip:
+		r, err := gzip.NewReader(bytes.NewReader(b.Data))
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress body %s: %v", b.Hash, err)
+		}
+		defer r.Close()
+		body := make([]byte, 0, b.Size)
+		buf := bytes.NewBuffer(body)
+		if _, err := buf.ReadFrom(r); err != nil {
+			return "", fmt.Errorf("failed to decompress body %s: %v", b.Hash, err)
+		}
+		return buf.String(), nil
+	default:
+		return "", fmt.Errorf("body %s has unknown encoding %q", b.Hash, b.Encoding)
+	}
+}
@@ -0,0 +1,147 @@
+package requests
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterImporter(ImporterRegistration{
+		Format:     ImportFormatBurp,
+		Label:      "Burp Suite XML (\"Save items\")",
+		JobType:    JobTypeImportXML,
+		Extensions: []string{".xml"},
+		Importer:   ImporterFunc(StreamBurpXML),
+	})
+}
+
+// BurpItem is a single <item> of a Burp Suite "Save items" XML export
+type BurpItem struct {
+	Time     string    `xml:"time"`
+	URL      string    `xml:"url"`
+	Host     string    `xml:"host"`
+	Port     string    `xml:"port"`
+	Protocol string    `xml:"protocol"`
+	Method   string    `xml:"method"`
+	Path     string    `xml:"path"`
+	Request  burpBlock `xml:"request"`
+	Status   string    `xml:"status"`
+	Response burpBlock `xml:"response"`
+	MimeType string    `xml:"mimetype"`
+}
+
+// burpBlock is a raw request or response, base64 encoded when the base64 attribute is true
+type burpBlock struct {
+	Base64 string `xml:"base64,attr"`
+	Data   string `xml:",chardata"`
+}
+
+// bytes returns the raw HTTP message of the block
+func (b burpBlock) bytes() ([]byte, error) {
+	data := strings.TrimSpace(b.Data)
+	if b.Base64 != "true" {
+		return []byte(data), nil
+	}
+	return base64.StdEncoding.DecodeString(data)
+}
+
+// burpTimeLayout is the Java Date.toString format used by Burp
+const burpTimeLayout = "Mon Jan 02 15:04:05 MST 2006"
+
+// StreamBurpXML walks the <item> elements of a Burp Suite XML export one at a time,
+// calling fn for each converted request
+func StreamBurpXML(r io.Reader, opts ImportOptions, fn func(TempMyRequest) error) error {
+	dec := xml.NewDecoder(r)
+	sequence := 0
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "item" {
+			continue
+		}
+
+		var item BurpItem
+		if err := dec.DecodeElement(&item, &start); err != nil {
+			return fmt.Errorf("invalid item %d: %v", sequence+1, err)
+		}
+		sequence++
+
+		my, err := item.toTempMyRequest(sequence)
+		if err != nil {
+			return fmt.Errorf("invalid item %d: %v", sequence, err)
+		}
+		my.ApplyHashes(opts.HashFunc)
+		if err := fn(my); err != nil {
+			return err
+		}
+	}
+}
+
+// toTempMyRequest converts a Burp item to a TempMyRequest without hashes
+func (item BurpItem) toTempMyRequest(sequence int) (TempMyRequest, error) {
+	rawReq, err := item.Request.bytes()
+	if err != nil {
+		return TempMyRequest{}, fmt.Errorf("failed to decode request: %v", err)
+	}
+	reqMsg, err := parseRawMessage(rawReq)
+	if err != nil {
+		return TempMyRequest{}, fmt.Errorf("failed to parse request: %v", err)
+	}
+
+	method := strings.TrimSpace(item.Method)
+	if method == "" {
+		method, _, _ = reqMsg.requestLine()
+	}
+
+	domain := strings.TrimSpace(item.Host)
+	if u, err := url.Parse(item.URL); err == nil && u.Hostname() != "" {
+		domain = u.Hostname()
+	}
+
+	my := TempMyRequest{
+		Sequence:    sequence,
+		URL:         strings.TrimSpace(item.URL),
+		Method:      method,
+		Domain:      domain,
+		ReqHeaders:  reqMsg.Headers,
+		ReqBody:     string(reqMsg.Body),
+		RequestTime: strings.TrimSpace(item.Time),
+	}
+	if t, err := time.Parse(burpTimeLayout, my.RequestTime); err == nil {
+		my.RequestTime = t.UTC().Format(time.RFC3339)
+	}
+
+	// Items without a response (dropped or timed out) are kept request-only
+	rawRes, err := item.Response.bytes()
+	if err != nil {
+		return TempMyRequest{}, fmt.Errorf("failed to decode response: %v", err)
+	}
+	if len(rawRes) > 0 {
+		resMsg, err := parseRawMessage(rawRes)
+		if err != nil {
+			return TempMyRequest{}, fmt.Errorf("failed to parse response: %v", err)
+		}
+		my.ResHeaders = resMsg.Headers
+		my.ResBody = string(resMsg.Body)
+		my.ResStatus, _ = resMsg.statusCode()
+	}
+	if my.ResStatus == 0 {
+		my.ResStatus, _ = strconv.Atoi(strings.TrimSpace(item.Status))
+	}
+	my.RespSize = len(my.ResBody)
+
+	return my, nil
+}
@@ -0,0 +1,172 @@
+package requests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// variablePattern matches Postman {{name}} and Insomnia {{ _.name }} template tags
+var variablePattern = regexp.MustCompile(`\{\{\s*(?:_\.)?([A-Za-z0-9_.\-$]+)\s*\}\}`)
+
+// maxVariableDepth bounds how often variables that reference other variables are expanded
+const maxVariableDepth = 5
+
+// collectionRequest is a documented request of a Postman or Insomnia collection before variables are resolved
+type collectionRequest struct {
+	Folder  string
+	Method  string
+	URL     string
+	Headers HeaderSlice
+	Body    string
+}
+
+// resolveVariables replaces known {{name}} tags in s, leaving unknown ones untouched
+func resolveVariables(s string, vars map[string]string) string {
+	for i := 0; i < maxVariableDepth && strings.Contains(s, "{{"); i++ {
+		resolved := variablePattern.ReplaceAllStringFunc(s, func(tag string) string {
+			name := variablePattern.FindStringSubmatch(tag)[1]
+			if value, ok := vars[name]; ok {
+				return value
+			}
+			return tag
+		})
+		if resolved == s {
+			break
+		}
+		s = resolved
+	}
+	return s
+}
+
+// mergeVariables returns the union of the given variable sets, later sets taking precedence
+func mergeVariables(sets ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, set := range sets {
+		for name, value := range set {
+			merged[name] = value
+		}
+	}
+	return merged
+}
+
+// toTempMyRequest resolves the request's variables and converts it to a request-only record
+func (c collectionRequest) toTempMyRequest(vars map[string]string) (TempMyRequest, error) {
+	rawURL := strings.TrimSpace(resolveVariables(c.URL, vars))
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	// unresolved path variables are kept as written, but the host has to be known
+	_, rest, _ := strings.Cut(rawURL, "://")
+	if host, _, _ := strings.Cut(rest, "/"); strings.Contains(host, "{{") {
+		return TempMyRequest{}, fmt.Errorf("unresolved variable in host of %q, upload an environment file that defines it", rawURL)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return TempMyRequest{}, fmt.Errorf("invalid URL %q: %v", rawURL, err)
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	headers := make(HeaderSlice, 0, len(c.Headers))
+	for _, h := range c.Headers {
+		headers = append(headers, Header{Name: resolveVariables(h.Name, vars), Value: resolveVariables(h.Value, vars)})
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(c.Method))
+	if method == "" {
+		method = "GET"
+	}
+
+	return TempMyRequest{
+		URL:        u.String(),
+		Method:     method,
+		Domain:     u.Hostname(),
+		ReqHeaders: headers,
+		ReqBody:    resolveVariables(c.Body, vars),
+		Folder:     c.Folder,
+	}, nil
+}
+
+// ParseEnvironment reads variables from a Postman environment or globals export,
+// or from a plain JSON object such as an Insomnia environment. Nested objects are flattened to dotted names.
+func ParseEnvironment(r io.Reader) (map[string]string, error) {
+	var doc map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid environment file: %v", err)
+	}
+
+	vars := make(map[string]string)
+	if values, ok := doc["values"]; ok {
+		// Postman environment
+		var entries []struct {
+			Key     string          `json:"key"`
+			Value   json.RawMessage `json:"value"`
+			Enabled *bool           `json:"enabled"`
+		}
+		if err := json.Unmarshal(values, &entries); err != nil {
+			return nil, fmt.Errorf("invalid Postman environment values: %v", err)
+		}
+		for _, entry := range entries {
+			if entry.Enabled == nil || *entry.Enabled {
+				vars[entry.Key] = jsonScalar(entry.Value)
+			}
+		}
+		return vars, nil
+	}
+
+	for name, value := range doc {
+		flattenVariable(vars, name, value)
+	}
+	return vars, nil
+}
+
+// flattenVariable stores a JSON value under name, descending into objects with dotted names
+func flattenVariable(vars map[string]string, name string, value json.RawMessage) {
+	var object map[string]json.RawMessage
+	if json.Unmarshal(value, &object) == nil {
+		for key, nested := range object {
+			flattenVariable(vars, name+"."+key, nested)
+		}
+		return
+	}
+	vars[name] = jsonScalar(value)
+}
+
+// jsonScalar returns a JSON string without quotes and any other value as written
+func jsonScalar(value json.RawMessage) string {
+	var s string
+	if json.Unmarshal(value, &s) == nil {
+		return s
+	}
+	if string(value) == "null" {
+		return ""
+	}
+	return string(value)
+}
+
+// basicAuthHeader returns the Authorization header for "user:password" credentials
+func basicAuthHeader(credentials string) Header {
+	return Header{Name: "Authorization", Value: "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))}
+}
+
+// contentTypeForLanguage maps a Postman raw body language to a Content-Type
+func contentTypeForLanguage(language string) string {
+	switch language {
+	case "json":
+		return "application/json"
+	case "xml":
+		return "application/xml"
+	case "html":
+		return "text/html"
+	case "javascript":
+		return "application/javascript"
+	default:
+		return "text/plain"
+	}
+}
@@ -0,0 +1,114 @@
+package requests
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Encodings undone on response bodies, recorded comma separated in MyRequest.ResBodyEncoding
+const (
+	ContentEncodingBase64  = "base64"
+	ContentEncodingGzip    = "gzip"
+	ContentEncodingDeflate = "deflate"
+	ContentEncodingBrotli  = "br"
+)
+
+// MaxBodySize caps a response body read from the network, guarding against memory exhaustion
+const MaxBodySize = 64 << 20
+
+// maxDecodedBodySize caps a decompressed response body, guarding against decompression bombs
+const maxDecodedBodySize = MaxBodySize
+
+// decodeHARContent returns the body of a HAR response content along with the encodings undone to get it.
+// Binary bodies come base64 encoded. When the capture kept the wire bytes they are also still compressed with the
+// Content-Encoding of the response, browsers usually store the decoded bytes though, so a body that does not
+// decompress is kept as it is.
+func decodeHARContent(text, encoding string, headers HeaderSlice) (string, string) {
+	if !strings.EqualFold(strings.TrimSpace(encoding), ContentEncodingBase64) {
+		return text, ""
+	}
+	body, err := decodeBase64(text)
+	if err != nil {
+		return text, ""
+	}
+	undone := []string{ContentEncodingBase64}
+
+	// Content-Encoding lists the codings in the order they were applied, undo them from the last one
+	codings := strings.Split(headers.Get("Content-Encoding"), ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+		if coding == "" || coding == "identity" {
+			continue
+		}
+		decoded, ok := decompressBody(coding, body)
+		if !ok {
+			break
+		}
+		body = decoded
+		undone = append(undone, coding)
+	}
+	return string(body), strings.Join(undone, ",")
+}
+
+// decodeBase64 decodes standard base64, with or without padding and line breaks
+func decodeBase64(text string) ([]byte, error) {
+	text = strings.Join(strings.Fields(text), "")
+	if body, err := base64.StdEncoding.DecodeString(text); err == nil {
+		return body, nil
+	}
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(text, "="))
+}
+
+// decompressBody undoes one content coding, reporting false when body is not encoded with it
+func decompressBody(coding string, body []byte) ([]byte, bool) {
+	var r io.Reader
+	switch coding {
+	case ContentEncodingGzip, "x-gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, false
+		}
+		defer gz.Close()
+		r = gz
+	case ContentEncodingDeflate:
+		// Servers send either zlib wrapped or raw deflate data under this name
+		if zr, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+			defer zr.Close()
+			r = zr
+		} else {
+			fr := flate.NewReader(bytes.NewReader(body))
+			defer fr.Close()
+			r = fr
+		}
+	case ContentEncodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return nil, false
+	}
+
+	decoded, err := io.ReadAll(io.LimitReader(r, maxDecodedBodySize+1))
+	if err != nil || len(decoded) > maxDecodedBodySize {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// ReadResponseBody reads a response body of at most MaxBodySize bytes
+func ReadResponseBody(r io.Reader) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, MaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > MaxBodySize {
+		return nil, fmt.Errorf("response body exceeds %d MB", MaxBodySize>>20)
+	}
+	return body, nil
+}
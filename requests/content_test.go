@@ -0,0 +1,243 @@
+package requests
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+const contentFixture = `{"users":[{"id":1,"name":"alice"},{"id":2,"name":"bob"}]}`
+
+func TestDecodeHARContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		encoding string
+		coding   string // Content-Encoding header of the response
+		body     string
+		undone   string
+	}{
+		{
+			name: "plain text",
+			text: contentFixture,
+			body: contentFixture,
+		},
+		{
+			name:     "base64",
+			text:     base64.StdEncoding.EncodeToString([]byte(contentFixture)),
+			encoding: "base64",
+			body:     contentFixture,
+			undone:   "base64",
+		},
+		{
+			name:     "base64 without padding and with line breaks",
+			text:     wrapLines(base64.RawStdEncoding.EncodeToString([]byte(contentFixture)), 16),
+			encoding: " Base64 ",
+			body:     contentFixture,
+			undone:   "base64",
+		},
+		{
+			name:     "invalid base64 is kept",
+			text:     "not base64!",
+			encoding: "base64",
+			body:     "not base64!",
+		},
+		{
+			name:     "gzip",
+			text:     base64.StdEncoding.EncodeToString(gzipBytes(t, []byte(contentFixture))),
+			encoding: "base64",
+			coding:   "gzip",
+			body:     contentFixture,
+			undone:   "base64,gzip",
+		},
+		{
+			name:     "x-gzip",
+			text:     base64.StdEncoding.EncodeToString(gzipBytes(t, []byte(contentFixture))),
+			encoding: "base64",
+			coding:   "X-Gzip",
+			body:     contentFixture,
+			undone:   "base64,x-gzip",
+		},
+		{
+			name:     "zlib deflate",
+			text:     base64.StdEncoding.EncodeToString(zlibBytes(t, []byte(contentFixture))),
+			encoding: "base64",
+			coding:   "deflate",
+			body:     contentFixture,
+			undone:   "base64,deflate",
+		},
+		{
+			name:     "raw deflate",
+			text:     base64.StdEncoding.EncodeToString(flateBytes(t, []byte(contentFixture))),
+			encoding: "base64",
+			coding:   "deflate",
+			body:     contentFixture,
+			undone:   "base64,deflate",
+		},
+		{
+			name:     "brotli",
+			text:     base64.StdEncoding.EncodeToString(brotliBytes(t, []byte(contentFixture))),
+			encoding: "base64",
+			coding:   "br",
+			body:     contentFixture,
+			undone:   "base64,br",
+		},
+		{
+			name:     "stacked encodings are undone from the last",
+			text:     base64.StdEncoding.EncodeToString(brotliBytes(t, gzipBytes(t, []byte(contentFixture)))),
+			encoding: "base64",
+			coding:   "gzip, identity, br",
+			body:     contentFixture,
+			undone:   "base64,br,gzip",
+		},
+		{
+			name:     "body stored decoded by the browser is kept",
+			text:     base64.StdEncoding.EncodeToString([]byte(contentFixture)),
+			encoding: "base64",
+			coding:   "gzip",
+			body:     contentFixture,
+			undone:   "base64",
+		},
+		{
+			name:     "decoding stops at the first coding that fails",
+			text:     base64.StdEncoding.EncodeToString(gzipBytes(t, []byte(contentFixture))),
+			encoding: "base64",
+			coding:   "br, gzip",
+			body:     contentFixture,
+			undone:   "base64,gzip",
+		},
+		{
+			name:     "unknown coding is kept",
+			text:     base64.StdEncoding.EncodeToString([]byte(contentFixture)),
+			encoding: "base64",
+			coding:   "zstd",
+			body:     contentFixture,
+			undone:   "base64",
+		},
+		{
+			name:   "compressed text is not decoded without base64",
+			text:   contentFixture,
+			coding: "gzip",
+			body:   contentFixture,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers HeaderSlice
+			if tt.coding != "" {
+				headers = HeaderSlice{{Name: "Content-Encoding", Value: tt.coding}}
+			}
+			body, undone := decodeHARContent(tt.text, tt.encoding, headers)
+			if body != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+			if undone != tt.undone {
+				t.Errorf("encodings = %q, want %q", undone, tt.undone)
+			}
+		})
+	}
+}
+
+func TestDecompressBody(t *testing.T) {
+	body := []byte(strings.Repeat(contentFixture, 100))
+	tests := []struct {
+		coding string
+		data   []byte
+		ok     bool
+	}{
+		{coding: "gzip", data: gzipBytes(t, body), ok: true},
+		{coding: "x-gzip", data: gzipBytes(t, body), ok: true},
+		{coding: "deflate", data: zlibBytes(t, body), ok: true},
+		{coding: "deflate", data: flateBytes(t, body), ok: true},
+		{coding: "br", data: brotliBytes(t, body), ok: true},
+		{coding: "gzip", data: body, ok: false},
+		{coding: "gzip", data: gzipBytes(t, body)[:40], ok: false},
+		{coding: "deflate", data: body, ok: false},
+		{coding: "br", data: body, ok: false},
+		{coding: "zstd", data: body, ok: false},
+	}
+
+	for _, tt := range tests {
+		decoded, ok := decompressBody(tt.coding, tt.data)
+		if ok != tt.ok {
+			t.Errorf("decompressBody(%q) ok = %v, want %v", tt.coding, ok, tt.ok)
+			continue
+		}
+		if ok && !bytes.Equal(decoded, body) {
+			t.Errorf("decompressBody(%q) = %d bytes, want %d", tt.coding, len(decoded), len(body))
+		}
+	}
+}
+
+func TestDecompressBodyCutoff(t *testing.T) {
+	atLimit := gzipBytes(t, make([]byte, maxDecodedBodySize))
+	if decoded, ok := decompressBody("gzip", atLimit); !ok || len(decoded) != maxDecodedBodySize {
+		t.Errorf("decompressBody of %d bytes ok = %v, got %d bytes", maxDecodedBodySize, ok, len(decoded))
+	}
+
+	overLimit := gzipBytes(t, make([]byte, maxDecodedBodySize+1))
+	if _, ok := decompressBody("gzip", overLimit); ok {
+		t.Errorf("decompressBody of %d bytes succeeded, want it refused", maxDecodedBodySize+1)
+	}
+}
+
+// gzipBytes compresses data with gzip
+func gzipBytes(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writeCompressed(t, gzip.NewWriter(&buf), data)
+	return buf.Bytes()
+}
+
+// zlibBytes compresses data with zlib wrapped deflate
+func zlibBytes(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writeCompressed(t, zlib.NewWriter(&buf), data)
+	return buf.Bytes()
+}
+
+// flateBytes compresses data with raw deflate
+func flateBytes(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		t.Fatalf("flate.NewWriter: %v", err)
+	}
+	writeCompressed(t, w, data)
+	return buf.Bytes()
+}
+
+// brotliBytes compresses data with brotli
+func brotliBytes(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writeCompressed(t, brotli.NewWriter(&buf), data)
+	return buf.Bytes()
+}
+
+// writeCompressed writes data through a compressor and flushes it
+func writeCompressed(t *testing.T, w io.WriteCloser, data []byte) {
+	t.Helper()
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+}
+
+// wrapLines breaks text into lines of n characters
+func wrapLines(text string, n int) string {
+	var lines []string
+	for len(text) > n {
+		lines = append(lines, text[:n])
+		text = text[n:]
+	}
+	return strings.Join(append(lines, text), "\n")
+}
@@ -0,0 +1,656 @@
+package requests
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterImporter(ImporterRegistration{
+		Format:     ImportFormatCurl,
+		Label:      "curl commands (DevTools \"Copy as cURL\")",
+		JobType:    JobTypeImportCurl,
+		Extensions: []string{".txt", ".sh", ".curl"},
+		Importer:   ImporterFunc(StreamCurlCommands),
+	})
+}
+
+// curlValueOptions lists the curl options that consume the following argument.
+// Options not handled by parseCurlArgs are skipped together with their value.
+var curlValueOptions = map[string]bool{
+	"-X": true, "--request": true,
+	"-H": true, "--header": true,
+	"-d": true, "--data": true, "--data-ascii": true, "--data-binary": true, "--data-raw": true, "--data-urlencode": true, "--json": true,
+	"-F": true, "--form": true, "--form-string": true,
+	"-b": true, "--cookie": true,
+	"-u": true, "--user": true,
+	"-A": true, "--user-agent": true,
+	"-e": true, "--referer": true,
+	"--url": true,
+	"-o":    true, "--output": true,
+	"-x": true, "--proxy": true, "-U": true, "--proxy-user": true,
+	"-m": true, "--max-time": true, "--connect-timeout": true,
+	"-w": true, "--write-out": true,
+	"-c": true, "--cookie-jar": true,
+	"-T": true, "--upload-file": true,
+	"-E": true, "--cert": true, "--key": true, "--cacert": true, "--capath": true,
+	"-K": true, "--config": true,
+	"-r": true, "--range": true,
+	"-Y": true, "--speed-limit": true, "-y": true, "--speed-time": true,
+	"--resolve": true, "--connect-to": true, "--retry": true, "--max-redirs": true, "--interface": true,
+}
+
+// curlCommand collects the parts of a curl invocation that shape the request
+type curlCommand struct {
+	Method    string
+	URLs      []string
+	Headers   HeaderSlice
+	Removed   []string // headers disabled with -H "Name:"
+	Data      []string
+	Form      []string
+	JSON      bool
+	Get       bool
+	Head      bool
+	UserAgent string
+	Referer   string
+	Cookie    string
+	User      string
+}
+
+// StreamCurlCommands imports a text file of curl commands as request-only records.
+// Commands may span lines with bash (\) or Windows cmd (^) continuations;
+// lines that are not curl commands, such as comments, are ignored.
+func StreamCurlCommands(r io.Reader, opts ImportOptions, fn func(TempMyRequest) error) error {
+	reader := bufio.NewReader(r)
+	sequence := 0
+	line := 1
+	for {
+		startLine := line
+		args, lines, err := readShellCommand(reader)
+		line += lines
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("line %d: %v", startLine, err)
+		}
+
+		if len(args) > 0 && isCurlProgram(args[0]) {
+			cmd, perr := parseCurlArgs(args[1:])
+			if perr != nil {
+				return fmt.Errorf("line %d: %v", startLine, perr)
+			}
+			for _, rawURL := range cmd.URLs {
+				my, cerr := cmd.toTempMyRequest(rawURL)
+				if cerr != nil {
+					return fmt.Errorf("line %d: %v", startLine, cerr)
+				}
+				sequence++
+				my.Sequence = sequence
+				my.ApplyHashes(opts.HashFunc)
+				if ferr := fn(my); ferr != nil {
+					return ferr
+				}
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// isCurlProgram reports whether a command name invokes curl
+func isCurlProgram(name string) bool {
+	name = strings.ToLower(path.Base(strings.ReplaceAll(name, "\\", "/")))
+	return name == "curl" || name == "curl.exe"
+}
+
+// shellStyle is the shell a command was written for, as far as it can be told from what was read so far
+type shellStyle int
+
+const (
+	shellUnknown shellStyle = iota
+	shellBash               // seen a backslash continuation
+	shellCmd                // seen a caret continuation or a ^"...^" string
+)
+
+// cmdSpecialChars are the characters a caret escapes in commands copied for cmd.exe
+const cmdSpecialChars = "\"\r\n&|<>()%!^ \t"
+
+// caretEscapes reports whether the ^ just read is the cmd.exe escape character. It always is in a command written for cmd
+// and never in one written for a POSIX shell, where it is plain text; otherwise only before a character cmd needs escaped.
+func caretEscapes(reader *bufio.Reader, style shellStyle) bool {
+	switch style {
+	case shellCmd:
+		return true
+	case shellBash:
+		return false
+	}
+	next, err := reader.Peek(1)
+	return err == nil && strings.IndexByte(cmdSpecialChars, next[0]) >= 0
+}
+
+// readShellCommand reads one command worth of arguments using POSIX shell quoting,
+// ANSI-C $'...' strings and line continuations, or cmd.exe carets once the command turns out to be written for cmd.
+// It returns the arguments and the number of lines consumed.
+func readShellCommand(reader *bufio.Reader) ([]string, int, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inWord  bool
+		lines   int
+		style   shellStyle
+	)
+	endWord := func() {
+		if inWord {
+			args = append(args, current.String())
+			current.Reset()
+			inWord = false
+		}
+	}
+
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			endWord()
+			if err == io.EOF && len(args) == 0 && lines == 0 {
+				return nil, 0, io.EOF
+			}
+			return args, lines, err
+		}
+
+		switch {
+		case r == '\n':
+			lines++
+			endWord()
+			return args, lines, nil
+		case r == ' ' || r == '\t' || r == '\r':
+			endWord()
+		case r == '#' && !inWord:
+			// comment until the end of the line
+			if _, err := reader.ReadString('\n'); err != nil {
+				return args, lines, err
+			}
+			lines++
+			return args, lines, nil
+		case r == '^' && !caretEscapes(reader, style):
+			inWord = true
+			current.WriteRune(r)
+		case r == '\\' && style == shellCmd:
+			// cmd.exe has no backslash escapes, Windows paths keep their separators
+			inWord = true
+			current.WriteRune(r)
+		case r == '\\' || r == '^':
+			next, _, err := reader.ReadRune()
+			if err != nil {
+				return args, lines, err
+			}
+			if next == '\r' {
+				if peek, _ := reader.Peek(1); len(peek) == 1 && peek[0] == '\n' {
+					next, _, _ = reader.ReadRune()
+				}
+			}
+			if next == '\n' {
+				// line continuation, the word goes on unless the next line starts with a blank
+				lines++
+				if r == '^' {
+					style = shellCmd
+				} else {
+					style = shellBash
+				}
+				continue
+			}
+			inWord = true
+			if r == '^' && next == '"' {
+				style = shellCmd
+				s, n, err := readCmdQuoted(reader)
+				lines += n
+				if err != nil {
+					return args, lines, err
+				}
+				current.WriteString(s)
+				continue
+			}
+			current.WriteRune(next)
+		case r == '\'':
+			inWord = true
+			s, n, err := readSingleQuoted(reader)
+			lines += n
+			if err != nil {
+				return args, lines, err
+			}
+			current.WriteString(s)
+		case r == '"':
+			inWord = true
+			s, n, err := readDoubleQuoted(reader)
+			lines += n
+			if err != nil {
+				return args, lines, err
+			}
+			current.WriteString(s)
+		case r == '$':
+			inWord = true
+			if peek, _ := reader.Peek(1); len(peek) == 1 && peek[0] == '\'' {
+				reader.ReadRune()
+				s, n, err := readANSIQuoted(reader)
+				lines += n
+				if err != nil {
+					return args, lines, err
+				}
+				current.WriteString(s)
+				continue
+			}
+			current.WriteRune(r)
+		default:
+			inWord = true
+			current.WriteRune(r)
+		}
+	}
+}
+
+// readSingleQuoted reads up to the closing single quote, taking everything literally
+func readSingleQuoted(reader *bufio.Reader) (string, int, error) {
+	s, err := reader.ReadString('\'')
+	if err != nil {
+		return "", strings.Count(s, "\n"), fmt.Errorf("unterminated single quote")
+	}
+	return s[:len(s)-1], strings.Count(s, "\n"), nil
+}
+
+// readDoubleQuoted reads up to the closing double quote, where a backslash only escapes \, ", $, ` and newlines
+func readDoubleQuoted(reader *bufio.Reader) (string, int, error) {
+	var sb strings.Builder
+	lines := 0
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			return "", lines, fmt.Errorf("unterminated double quote")
+		}
+		switch r {
+		case '"':
+			return sb.String(), lines, nil
+		case '\\':
+			next, _, err := reader.ReadRune()
+			if err != nil {
+				return "", lines, fmt.Errorf("unterminated double quote")
+			}
+			switch next {
+			case '\n':
+				lines++
+			case '"', '\\', '$', '`':
+				sb.WriteRune(next)
+			default:
+				sb.WriteRune('\\')
+				sb.WriteRune(next)
+			}
+		default:
+			if r == '\n' {
+				lines++
+			}
+			sb.WriteRune(r)
+		}
+	}
+}
+
+// readCmdQuoted reads a ^"..."^ string as written by Chrome's "Copy as cURL (cmd)".
+// Carets escape the next character, \" and \\ follow the C runtime rules, and ^ + two newlines is a literal newline.
+func readCmdQuoted(reader *bufio.Reader) (string, int, error) {
+	var sb strings.Builder
+	lines := 0
+	unescaped := func() (rune, bool, error) {
+		r, _, err := reader.ReadRune()
+		if err != nil || r != '^' {
+			return r, false, err
+		}
+		r, _, err = reader.ReadRune()
+		return r, true, err
+	}
+	for {
+		r, escaped, err := unescaped()
+		if err != nil {
+			return "", lines, fmt.Errorf("unterminated ^\" quote")
+		}
+		switch {
+		case r == '"':
+			// ^" closes the string, a bare " can't occur inside it
+			return sb.String(), lines, nil
+		case r == '\r':
+			continue
+		case r == '\n' && escaped:
+			lines++
+			if peek, _ := reader.Peek(1); len(peek) == 1 && peek[0] == '\r' {
+				reader.ReadByte()
+			}
+			if peek, _ := reader.Peek(1); len(peek) == 1 && peek[0] == '\n' {
+				reader.ReadByte()
+				lines++
+			}
+			sb.WriteByte('\n')
+		case r == '\\':
+			next, _, err := unescaped()
+			if err != nil {
+				return "", lines, fmt.Errorf("unterminated ^\" quote")
+			}
+			if next == '"' || next == '\\' {
+				sb.WriteRune(next)
+				continue
+			}
+			sb.WriteRune('\\')
+			reader.UnreadRune()
+		case r == '%':
+			// %^ keeps cmd from expanding %VAR%
+			if peek, _ := reader.Peek(1); len(peek) == 1 && peek[0] == '^' {
+				reader.ReadByte()
+			}
+			sb.WriteRune('%')
+		default:
+			if r == '\n' {
+				lines++
+			}
+			sb.WriteRune(r)
+		}
+	}
+}
+
+// readANSIQuoted reads the rest of a bash $'...' string, expanding its backslash escapes
+func readANSIQuoted(reader *bufio.Reader) (string, int, error) {
+	var sb strings.Builder
+	lines := 0
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			return "", lines, fmt.Errorf("unterminated $' quote")
+		}
+		if r == '\'' {
+			return sb.String(), lines, nil
+		}
+		if r == '\n' {
+			lines++
+		}
+		if r != '\\' {
+			sb.WriteRune(r)
+			continue
+		}
+
+		next, _, err := reader.ReadRune()
+		if err != nil {
+			return "", lines, fmt.Errorf("unterminated $' quote")
+		}
+		switch next {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'a':
+			sb.WriteByte('\a')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'v':
+			sb.WriteByte('\v')
+		case 'e', 'E':
+			sb.WriteByte(0x1b)
+		case 'x':
+			sb.WriteByte(byte(readHexDigits(reader, 2)))
+		case 'u':
+			sb.WriteRune(rune(readHexDigits(reader, 4)))
+		case 'U':
+			sb.WriteRune(rune(readHexDigits(reader, 8)))
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			value := int(next - '0')
+			for i := 0; i < 2; i++ {
+				peek, _ := reader.Peek(1)
+				if len(peek) == 0 || peek[0] < '0' || peek[0] > '7' {
+					break
+				}
+				reader.ReadByte()
+				value = value*8 + int(peek[0]-'0')
+			}
+			sb.WriteByte(byte(value))
+		default:
+			// \\, \', \" and unknown escapes keep the escaped character
+			sb.WriteRune(next)
+		}
+	}
+}
+
+// readHexDigits consumes up to max hex digits and returns their value
+func readHexDigits(reader *bufio.Reader, max int) int {
+	value := 0
+	for i := 0; i < max; i++ {
+		peek, _ := reader.Peek(1)
+		if len(peek) == 0 {
+			break
+		}
+		digit, err := strconv.ParseUint(string(peek), 16, 8)
+		if err != nil {
+			break
+		}
+		reader.ReadByte()
+		value = value*16 + int(digit)
+	}
+	if max > 2 && !utf8.ValidRune(rune(value)) {
+		return utf8.RuneError
+	}
+	return value
+}
+
+// parseCurlArgs interprets the arguments following the curl program name
+func parseCurlArgs(args []string) (curlCommand, error) {
+	var cmd curlCommand
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "" || arg[0] != '-' || arg == "-" {
+			cmd.URLs = append(cmd.URLs, arg)
+			continue
+		}
+
+		name, value, hasValue := arg, "", false
+		if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			// short options can be combined (-sSL) or carry their value (-XPOST)
+			for j := 1; j < len(arg); j++ {
+				short := "-" + string(arg[j])
+				if curlValueOptions[short] {
+					name, value, hasValue = short, arg[j+1:], j+1 < len(arg)
+					break
+				}
+				name = short
+				cmd.applyFlag(short)
+			}
+			if !curlValueOptions[name] {
+				continue
+			}
+		}
+
+		if !curlValueOptions[name] {
+			cmd.applyFlag(name)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return cmd, fmt.Errorf("option %s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		cmd.applyOption(name, value)
+	}
+
+	if len(cmd.URLs) == 0 {
+		return cmd, fmt.Errorf("curl command has no URL")
+	}
+	return cmd, nil
+}
+
+// applyFlag records a curl option without a value
+func (cmd *curlCommand) applyFlag(name string) {
+	switch name {
+	case "-G", "--get":
+		cmd.Get = true
+	case "-I", "--head":
+		cmd.Head = true
+	}
+}
+
+// applyOption records a curl option and its value
+func (cmd *curlCommand) applyOption(name, value string) {
+	switch name {
+	case "-X", "--request":
+		cmd.Method = strings.ToUpper(value)
+	case "-H", "--header":
+		headerName, headerValue, found := strings.Cut(value, ":")
+		headerName, headerValue = strings.TrimSpace(headerName), strings.TrimSpace(headerValue)
+		switch {
+		case found && headerValue == "":
+			// "-H 'X-Drop:'" removes a header curl would otherwise send
+			cmd.Removed = append(cmd.Removed, headerName)
+		case found:
+			cmd.Headers = append(cmd.Headers, Header{Name: headerName, Value: headerValue})
+		case strings.HasSuffix(headerName, ";"):
+			// "-H 'X-Empty;'" sends a header without a value
+			cmd.Headers = append(cmd.Headers, Header{Name: strings.TrimSuffix(headerName, ";")})
+		}
+	case "-d", "--data", "--data-ascii", "--data-binary", "--data-raw":
+		cmd.Data = append(cmd.Data, value)
+	case "--data-urlencode":
+		cmd.Data = append(cmd.Data, curlURLEncode(value))
+	case "--json":
+		cmd.JSON = true
+		cmd.Data = append(cmd.Data, value)
+	case "-F", "--form", "--form-string":
+		cmd.Form = append(cmd.Form, value)
+	case "-b", "--cookie":
+		// without "=" the value names a cookie file, which we can't read
+		if strings.Contains(value, "=") {
+			if cmd.Cookie != "" {
+				cmd.Cookie += "; "
+			}
+			cmd.Cookie += value
+		}
+	case "-u", "--user":
+		cmd.User = value
+	case "-A", "--user-agent":
+		cmd.UserAgent = value
+	case "-e", "--referer":
+		cmd.Referer = value
+	case "--url":
+		cmd.URLs = append(cmd.URLs, value)
+	}
+}
+
+// curlURLEncode applies the --data-urlencode rules to a single value
+func curlURLEncode(value string) string {
+	if strings.HasPrefix(value, "=") {
+		return url.QueryEscape(value[1:])
+	}
+	if name, content, found := strings.Cut(value, "="); found && !strings.Contains(name, "@") {
+		return name + "=" + url.QueryEscape(content)
+	}
+	return url.QueryEscape(value)
+}
+
+// toTempMyRequest builds a request-only record for one of the command's URLs
+func (cmd curlCommand) toTempMyRequest(rawURL string) (TempMyRequest, error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL // curl's default scheme
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return TempMyRequest{}, fmt.Errorf("invalid URL %q: %v", rawURL, err)
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	headers := make(HeaderSlice, 0, len(cmd.Headers)+4)
+	addDefault := func(name, value string) {
+		if value != "" && !cmd.hasHeader(name) {
+			headers = append(headers, Header{Name: name, Value: value})
+		}
+	}
+	addDefault("User-Agent", cmd.UserAgent)
+	addDefault("Referer", cmd.Referer)
+	addDefault("Cookie", cmd.Cookie)
+	if cmd.User != "" {
+		addDefault("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(cmd.User)))
+	}
+
+	body := strings.Join(cmd.Data, "&")
+	method := "GET"
+	switch {
+	case cmd.Head:
+		method = "HEAD"
+	case cmd.Get:
+		if body != "" {
+			if u.RawQuery != "" {
+				u.RawQuery += "&"
+			}
+			u.RawQuery += body
+			body = ""
+		}
+	case len(cmd.Form) > 0:
+		method = "POST"
+		fields := make([]formField, 0, len(cmd.Form))
+		for _, field := range cmd.Form {
+			name, value, _ := strings.Cut(field, "=")
+			if strings.HasPrefix(value, "@") {
+				// referenced files can't be read, keep the field and file names
+				filename, _, _ := strings.Cut(value[1:], ";")
+				fields = append(fields, formField{Name: name, Filename: path.Base(filename), IsFile: true})
+				continue
+			}
+			fields = append(fields, formField{Name: name, Value: value})
+		}
+		formBody, contentType, err := buildMultipartBody(fields)
+		if err != nil {
+			return TempMyRequest{}, err
+		}
+		body = formBody
+		addDefault("Content-Type", contentType)
+	case len(cmd.Data) > 0:
+		method = "POST"
+		if cmd.JSON {
+			addDefault("Content-Type", "application/json")
+			addDefault("Accept", "application/json")
+		} else {
+			addDefault("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if cmd.Method != "" {
+		method = cmd.Method
+	}
+
+	// explicit -H headers win and keep their order
+	headers = append(headers, cmd.Headers...)
+
+	return TempMyRequest{
+		URL:        u.String(),
+		Method:     method,
+		Domain:     u.Hostname(),
+		ReqHeaders: headers,
+		ReqBody:    body,
+	}, nil
+}
+
+// hasHeader reports whether a header was set or removed with -H
+func (cmd curlCommand) hasHeader(name string) bool {
+	for _, h := range cmd.Headers {
+		if strings.EqualFold(h.Name, name) {
+			return true
+		}
+	}
+	for _, removed := range cmd.Removed {
+		if strings.EqualFold(removed, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package requests
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadShellCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		args  []string
+		lines int
+	}{
+		{
+			name:  "bash continuations",
+			input: "curl 'https://example.com/api' \\\n  -H 'Accept: */*' \\\n  --data-raw '{\"a\":1}'\n",
+			args:  []string{"curl", "https://example.com/api", "-H", "Accept: */*", "--data-raw", `{"a":1}`},
+			lines: 3,
+		},
+		{
+			name:  "bash continuation inside a word joins it",
+			input: "curl https://example.com/fo\\\no\n",
+			args:  []string{"curl", "https://example.com/foo"},
+			lines: 2,
+		},
+		{
+			name:  "bash keeps carets",
+			input: "curl 'https://example.com/' \\\n  -d a^b -d c^&d\n",
+			args:  []string{"curl", "https://example.com/", "-d", "a^b", "-d", "c^&d"},
+			lines: 2,
+		},
+		{
+			name:  "caret before an ordinary character is literal",
+			input: "curl https://example.com/?q=^abc\n",
+			args:  []string{"curl", "https://example.com/?q=^abc"},
+			lines: 1,
+		},
+		{
+			name:  "bash double quotes",
+			input: `curl "https://example.com/\$x" -H "X-A: \"q\" \n"` + "\n",
+			args:  []string{"curl", "https://example.com/$x", "-H", `X-A: "q" \n`},
+			lines: 1,
+		},
+		{
+			name:  "comment line",
+			input: "# curl https://example.com/\n",
+			args:  nil,
+			lines: 1,
+		},
+		{
+			name:  "cmd quoted strings and continuations",
+			input: "curl ^\"https://example.com/api?a=1^&b=2^\" ^\r\n  -H ^\"Accept: */*^\" ^\r\n  --data-raw ^\"^{^\\^\"a^\\^\":1^}^\"\r\n",
+			args:  []string{"curl", "https://example.com/api?a=1&b=2", "-H", "Accept: */*", "--data-raw", `{"a":1}`},
+			lines: 3,
+		},
+		{
+			name:  "cmd keeps backslashes",
+			input: "curl ^\"https://example.com/^\" ^\n  -o C:\\out\\res.json\n",
+			args:  []string{"curl", "https://example.com/", "-o", `C:\out\res.json`},
+			lines: 2,
+		},
+		{
+			name:  "cmd escaped percent",
+			input: "curl ^\"https://example.com/?q=100^%^\"\n",
+			args:  []string{"curl", "https://example.com/?q=100%"},
+			lines: 1,
+		},
+		{
+			name:  "cmd caret escapes an ampersand before the style is known",
+			input: "curl https://example.com/?a=1^&b=2\n",
+			args:  []string{"curl", "https://example.com/?a=1&b=2"},
+			lines: 1,
+		},
+		{
+			name:  "ANSI-C string",
+			input: `curl https://example.com/ --data-raw $'{"a":"it\'s"}\n\x41\u00e9\101'` + "\n",
+			args:  []string{"curl", "https://example.com/", "--data-raw", "{\"a\":\"it's\"}\nAéA"},
+			lines: 1,
+		},
+		{
+			name:  "ANSI-C string across a continuation",
+			input: "curl https://example.com/ \\\n  -H $'X-Tab: a\\tb'\n",
+			args:  []string{"curl", "https://example.com/", "-H", "X-Tab: a\tb"},
+			lines: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, lines, err := readShellCommand(bufio.NewReader(strings.NewReader(tt.input)))
+			if err != nil {
+				t.Fatalf("readShellCommand: %v", err)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %q, want %q", args, tt.args)
+			}
+			if lines != tt.lines {
+				t.Errorf("lines = %d, want %d", lines, tt.lines)
+			}
+		})
+	}
+}
+
+func TestReadShellCommandUnterminated(t *testing.T) {
+	for _, input := range []string{"curl 'https://example.com/\n", `curl "https://example.com/`, "curl $'abc", "curl ^\"abc"} {
+		if _, _, err := readShellCommand(bufio.NewReader(strings.NewReader(input))); err == nil {
+			t.Errorf("readShellCommand(%q) succeeded, want an unterminated quote error", input)
+		}
+	}
+}
+
+func TestStreamCurlCommands(t *testing.T) {
+	input := "curl 'https://example.com/a' \\\n  -H 'X-A: 1' \\\n  --data-raw 'x=1'\n\n" +
+		"curl ^\"https://example.com/b^\" ^\n  -X ^\"PUT^\"\n"
+	var got []TempMyRequest
+	err := StreamCurlCommands(strings.NewReader(input), ImportOptions{HashFunc: noHashes}, func(req TempMyRequest) error {
+		got = append(got, req)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamCurlCommands: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d requests, want 2", len(got))
+	}
+	if got[0].Method != "POST" || got[0].URL != "https://example.com/a" || got[0].ReqBody != "x=1" || got[0].ReqHeaders.Get("X-A") != "1" {
+		t.Errorf("first request = %s %s %q %v", got[0].Method, got[0].URL, got[0].ReqBody, got[0].ReqHeaders)
+	}
+	if got[1].Method != "PUT" || got[1].URL != "https://example.com/b" || got[1].Sequence != 2 {
+		t.Errorf("second request = %s %s sequence %d", got[1].Method, got[1].URL, got[1].Sequence)
+	}
+}
+
+// noHashes stands in for the hash function of an import
+func noHashes(*TempMyRequest) (string, string) {
+	return "", ""
+}
@@ -0,0 +1,285 @@
+package requests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxDiffCells bounds the line table of a diff, larger inputs only get their common prefix and suffix matched
+const maxDiffCells = 4_000_000
+
+// DiffOp tells whether a line or value is kept, removed from the first text, added by the second or changed
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffDelete DiffOp = "delete"
+	DiffInsert DiffOp = "insert"
+	DiffChange DiffOp = "change" // JSON values present in both documents
+)
+
+// DiffLine is a single line of a line-level diff
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// DiffRow pairs the lines shown side by side, either side is nil where the other text has no counterpart
+type DiffRow struct {
+	Left  *DiffLine
+	Right *DiffLine
+}
+
+// JSONChange is a value that differs between two JSON documents, Path is written as $.key[index]
+type JSONChange struct {
+	Path string
+	Op   DiffOp // delete or insert when only one document has the path, change otherwise
+	Old  string
+	New  string
+}
+
+// BodyDiff compares two bodies by line and, when both are JSON, by structure
+type BodyDiff struct {
+	Lines       []DiffLine
+	IsJSON      bool
+	JSONChanges []JSONChange
+}
+
+// DiffLines computes a line-level diff of a and b
+func DiffLines(a, b string) []DiffLine {
+	return diffLineSlices(splitLines(a), splitLines(b))
+}
+
+// DiffBodies compares two bodies, pretty printing JSON first so the line diff follows its structure
+func DiffBodies(a, b string) BodyDiff {
+	av, aErr := decodeJSONValue(a)
+	bv, bErr := decodeJSONValue(b)
+	if aErr != nil || bErr != nil {
+		return BodyDiff{Lines: DiffLines(a, b)}
+	}
+
+	var changes []JSONChange
+	diffJSONValues("$", av, bv, &changes)
+	return BodyDiff{
+		Lines:       DiffLines(indentJSON(a), indentJSON(b)),
+		IsJSON:      true,
+		JSONChanges: changes,
+	}
+}
+
+// DiffRows arranges a line diff into side by side rows, pairing the removed and added lines of each change
+func DiffRows(lines []DiffLine) []DiffRow {
+	var rows []DiffRow
+	for i := 0; i < len(lines); {
+		if lines[i].Op == DiffEqual {
+			rows = append(rows, DiffRow{Left: &lines[i], Right: &lines[i]})
+			i++
+			continue
+		}
+		var deleted, inserted []*DiffLine
+		for ; i < len(lines) && lines[i].Op != DiffEqual; i++ {
+			if lines[i].Op == DiffDelete {
+				deleted = append(deleted, &lines[i])
+			} else {
+				inserted = append(inserted, &lines[i])
+			}
+		}
+		for j := 0; j < len(deleted) || j < len(inserted); j++ {
+			var row DiffRow
+			if j < len(deleted) {
+				row.Left = deleted[j]
+			}
+			if j < len(inserted) {
+				row.Right = inserted[j]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// HasChanges reports whether a line diff contains anything but equal lines
+func HasChanges(lines []DiffLine) bool {
+	for _, line := range lines {
+		if line.Op != DiffEqual {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLines splits text into lines, an empty text has no lines
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n"), "\n")
+}
+
+// diffLineSlices matches the common prefix and suffix, then the longest common subsequence of the middle
+func diffLineSlices(a, b []string) []DiffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	out := make([]DiffLine, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		out = append(out, DiffLine{Op: DiffEqual, Text: line})
+	}
+	out = append(out, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		out = append(out, DiffLine{Op: DiffEqual, Text: line})
+	}
+	return out
+}
+
+// diffMiddle runs the longest common subsequence table over the lines that differ
+func diffMiddle(a, b []string) []DiffLine {
+	var out []DiffLine
+	if len(a) == 0 || len(b) == 0 || (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, line := range a {
+			out = append(out, DiffLine{Op: DiffDelete, Text: line})
+		}
+		for _, line := range b {
+			out = append(out, DiffLine{Op: DiffInsert, Text: line})
+		}
+		return out
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	width := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else if lcs[(i+1)*width+j] >= lcs[i*width+j+1] {
+				lcs[i*width+j] = lcs[(i+1)*width+j]
+			} else {
+				lcs[i*width+j] = lcs[i*width+j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, DiffLine{Op: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[(i+1)*width+j] >= lcs[i*width+j+1]:
+			out = append(out, DiffLine{Op: DiffDelete, Text: a[i]})
+			i++
+		default:
+			out = append(out, DiffLine{Op: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, DiffLine{Op: DiffDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		out = append(out, DiffLine{Op: DiffInsert, Text: b[j]})
+	}
+	return out
+}
+
+// decodeJSONValue decodes a JSON object or array, keeping numbers as written
+func decodeJSONValue(s string) (interface{}, error) {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return nil, fmt.Errorf("not a JSON object or array")
+	}
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// indentJSON pretty prints a JSON document, returning it unchanged when it does not parse
+func indentJSON(s string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(strings.TrimSpace(s)), "", "  "); err != nil {
+		return s
+	}
+	return buf.String()
+}
+
+// diffJSONValues walks two decoded JSON values and records every path whose value differs
+func diffJSONValues(path string, a, b interface{}, changes *[]JSONChange) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, found := av[k]; !found {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := path + "." + k
+			aChild, inA := av[k]
+			bChild, inB := bv[k]
+			switch {
+			case !inB:
+				*changes = append(*changes, JSONChange{Path: childPath, Op: DiffDelete, Old: compactJSON(aChild)})
+			case !inA:
+				*changes = append(*changes, JSONChange{Path: childPath, Op: DiffInsert, New: compactJSON(bChild)})
+			default:
+				diffJSONValues(childPath, aChild, bChild, changes)
+			}
+		}
+		return
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(av) || i < len(bv); i++ {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(bv):
+				*changes = append(*changes, JSONChange{Path: childPath, Op: DiffDelete, Old: compactJSON(av[i])})
+			case i >= len(av):
+				*changes = append(*changes, JSONChange{Path: childPath, Op: DiffInsert, New: compactJSON(bv[i])})
+			default:
+				diffJSONValues(childPath, av[i], bv[i], changes)
+			}
+		}
+		return
+	}
+
+	if oldValue, newValue := compactJSON(a), compactJSON(b); oldValue != newValue {
+		*changes = append(*changes, JSONChange{Path: path, Op: DiffChange, Old: oldValue, New: newValue})
+	}
+}
+
+// compactJSON renders a decoded JSON value on one line
+func compactJSON(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,353 @@
+package requests
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MaxFuzzPayloads caps the payloads of a single fuzz run
+const MaxFuzzPayloads = 10000
+
+// InsertionPointKind is the part of a request a payload is written into
+type InsertionPointKind string
+
+const (
+	InsertionQuery  InsertionPointKind = "query"  // value of a query parameter
+	InsertionJSON   InsertionPointKind = "json"   // value of a JSON body field, Name is a dotted path such as user.roles.0
+	InsertionForm   InsertionPointKind = "form"   // value of a urlencoded body field
+	InsertionHeader InsertionPointKind = "header" // value of a request header
+	InsertionPath   InsertionPointKind = "path"   // path segment, Name is its index starting at 0
+)
+
+// InsertionPoint is a place in a request where payloads replace the original value
+type InsertionPoint struct {
+	Kind     InsertionPointKind
+	Name     string
+	Original string // value found in the base request
+}
+
+// String encodes the point as kind:name, the form it is submitted and stored in
+func (p InsertionPoint) String() string {
+	return string(p.Kind) + ":" + p.Name
+}
+
+// ParseInsertionPoint decodes a point written by InsertionPoint.String
+func ParseInsertionPoint(s string) (InsertionPoint, error) {
+	kind, name, found := strings.Cut(s, ":")
+	if !found || name == "" {
+		return InsertionPoint{}, fmt.Errorf("invalid insertion point %q", s)
+	}
+	switch InsertionPointKind(kind) {
+	case InsertionQuery, InsertionJSON, InsertionForm, InsertionHeader:
+	case InsertionPath:
+		if _, err := strconv.Atoi(name); err != nil {
+			return InsertionPoint{}, fmt.Errorf("invalid path segment %q", name)
+		}
+	default:
+		return InsertionPoint{}, fmt.Errorf("unknown insertion point kind %q", kind)
+	}
+	return InsertionPoint{Kind: InsertionPointKind(kind), Name: name}, nil
+}
+
+// FindInsertionPoints lists the query parameters, path segments, body fields and headers of a request.
+// Pseudo headers and headers managed by the client are left out.
+func FindInsertionPoints(temp TempMyRequest) []InsertionPoint {
+	var points []InsertionPoint
+	u, err := url.Parse(temp.URL)
+	if err != nil {
+		return nil
+	}
+
+	for i, segment := range pathSegments(u) {
+		if segment != "" {
+			points = append(points, InsertionPoint{Kind: InsertionPath, Name: strconv.Itoa(i), Original: segment})
+		}
+	}
+	for _, pair := range splitPairs(u.RawQuery) {
+		points = append(points, InsertionPoint{Kind: InsertionQuery, Name: pair[0], Original: pair[1]})
+	}
+
+	if v, err := decodeJSONValue(temp.ReqBody); err == nil {
+		var fields []InsertionPoint
+		collectJSONFields("", v, &fields)
+		points = append(points, fields...)
+	} else if strings.Contains(strings.ToLower(temp.ReqHeaders.Get("Content-Type")), "application/x-www-form-urlencoded") {
+		for _, pair := range splitPairs(temp.ReqBody) {
+			points = append(points, InsertionPoint{Kind: InsertionForm, Name: pair[0], Original: pair[1]})
+		}
+	}
+
+	for _, h := range temp.ReqHeaders {
+		if strings.HasPrefix(h.Name, ":") || strings.EqualFold(h.Name, "Content-Length") || strings.EqualFold(h.Name, "Host") {
+			continue
+		}
+		points = append(points, InsertionPoint{Kind: InsertionHeader, Name: h.Name, Original: h.Value})
+	}
+	return dedupeInsertionPoints(points)
+}
+
+// Apply returns a copy of temp with payload written at the insertion point
+func (p InsertionPoint) Apply(temp TempMyRequest, payload string) (TempMyRequest, error) {
+	out := temp
+	out.ReqHeaders = append(HeaderSlice(nil), temp.ReqHeaders...)
+
+	switch p.Kind {
+	case InsertionQuery, InsertionPath:
+		u, err := url.Parse(temp.URL)
+		if err != nil {
+			return out, fmt.Errorf("invalid request URL: %v", err)
+		}
+		if p.Kind == InsertionQuery {
+			u.RawQuery = replacePair(u.RawQuery, p.Name, payload)
+		} else {
+			segments := pathSegments(u)
+			index, _ := strconv.Atoi(p.Name)
+			if index >= len(segments) {
+				return out, fmt.Errorf("request has no path segment %d", index)
+			}
+			segments[index] = url.PathEscape(payload)
+			u.RawPath = "/" + strings.Join(segments, "/")
+			u.Path, _ = url.PathUnescape(u.RawPath)
+		}
+		out.URL = u.String()
+	case InsertionForm:
+		out.ReqBody = replacePair(temp.ReqBody, p.Name, payload)
+	case InsertionJSON:
+		v, err := decodeJSONValue(temp.ReqBody)
+		if err != nil {
+			return out, fmt.Errorf("request body is not JSON: %v", err)
+		}
+		v, err = setJSONField(v, strings.Split(p.Name, "."), payload)
+		if err != nil {
+			return out, err
+		}
+		out.ReqBody = compactJSON(v)
+	case InsertionHeader:
+		replaced := false
+		for i, h := range out.ReqHeaders {
+			if strings.EqualFold(h.Name, p.Name) {
+				out.ReqHeaders[i].Value = payload
+				replaced = true
+			}
+		}
+		if !replaced {
+			out.ReqHeaders = append(out.ReqHeaders, Header{Name: p.Name, Value: payload})
+		}
+	default:
+		return out, fmt.Errorf("unknown insertion point kind %q", p.Kind)
+	}
+	return out, nil
+}
+
+// PayloadSpec describes the payloads of a fuzz run
+type PayloadSpec struct {
+	Words        []string // from the payload list and uploaded wordlists
+	RangeFrom    int
+	RangeTo      int
+	RangeStep    int  // 0 disables the number range
+	CaseVariants bool // adds lower, upper, title and swapped case variants of every word
+}
+
+// ReadWordlist reads one payload per line, skipping empty lines
+func ReadWordlist(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if word := strings.TrimRight(scanner.Text(), "\r"); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words, scanner.Err()
+}
+
+// Payloads expands the spec into a list without duplicates, in the order words, range, variants
+func (spec PayloadSpec) Payloads() ([]string, error) {
+	var payloads []string
+	seen := make(map[string]bool)
+	add := func(p string) error {
+		if seen[p] {
+			return nil
+		}
+		if len(payloads) >= MaxFuzzPayloads {
+			return fmt.Errorf("more than %d payloads", MaxFuzzPayloads)
+		}
+		seen[p] = true
+		payloads = append(payloads, p)
+		return nil
+	}
+
+	for _, word := range spec.Words {
+		if err := add(word); err != nil {
+			return nil, err
+		}
+	}
+	if spec.RangeStep != 0 {
+		if (spec.RangeStep > 0 && spec.RangeFrom > spec.RangeTo) || (spec.RangeStep < 0 && spec.RangeFrom < spec.RangeTo) {
+			return nil, fmt.Errorf("range step %d never reaches %d from %d", spec.RangeStep, spec.RangeTo, spec.RangeFrom)
+		}
+		for n := spec.RangeFrom; (spec.RangeStep > 0 && n <= spec.RangeTo) || (spec.RangeStep < 0 && n >= spec.RangeTo); n += spec.RangeStep {
+			if err := add(strconv.Itoa(n)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if spec.CaseVariants {
+		for _, word := range spec.Words {
+			for _, variant := range caseVariants(word) {
+				if err := add(variant); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return payloads, nil
+}
+
+// caseVariants returns the lower, upper, title and swapped case forms of word
+func caseVariants(word string) []string {
+	title := []rune(strings.ToLower(word))
+	if len(title) > 0 {
+		title[0] = unicode.ToUpper(title[0])
+	}
+	swapped := []rune(word)
+	for i, r := range swapped {
+		if unicode.IsUpper(r) {
+			swapped[i] = unicode.ToLower(r)
+		} else {
+			swapped[i] = unicode.ToUpper(r)
+		}
+	}
+	return []string{strings.ToLower(word), strings.ToUpper(word), string(title), string(swapped)}
+}
+
+// pathSegments splits the escaped path of u without its leading slash
+func pathSegments(u *url.URL) []string {
+	return strings.Split(strings.TrimPrefix(u.EscapedPath(), "/"), "/")
+}
+
+// splitPairs splits a query string or urlencoded body into decoded name/value pairs, keeping their order
+func splitPairs(raw string) [][2]string {
+	var pairs [][2]string
+	for _, part := range strings.Split(raw, "&") {
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		if n, err := url.QueryUnescape(name); err == nil {
+			name = n
+		}
+		if v, err := url.QueryUnescape(value); err == nil {
+			value = v
+		}
+		pairs = append(pairs, [2]string{name, value})
+	}
+	return pairs
+}
+
+// replacePair sets every value of name in a query string or urlencoded body, appending it when missing
+func replacePair(raw, name, value string) string {
+	parts := strings.Split(raw, "&")
+	replaced := false
+	for i, part := range parts {
+		partName, _, _ := strings.Cut(part, "=")
+		if n, err := url.QueryUnescape(partName); err == nil && n == name {
+			parts[i] = partName + "=" + url.QueryEscape(value)
+			replaced = true
+		}
+	}
+	if !replaced {
+		parts = append(parts, url.QueryEscape(name)+"="+url.QueryEscape(value))
+	}
+	return strings.TrimPrefix(strings.Join(parts, "&"), "&")
+}
+
+// collectJSONFields lists the scalar fields of a decoded JSON value as dotted paths
+func collectJSONFields(prefix string, v interface{}, points *[]InsertionPoint) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch value := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			collectJSONFields(join(k), value[k], points)
+		}
+	case []interface{}:
+		for i, item := range value {
+			collectJSONFields(join(strconv.Itoa(i)), item, points)
+		}
+	default:
+		original := compactJSON(value)
+		if s, ok := value.(string); ok {
+			original = s
+		}
+		*points = append(*points, InsertionPoint{Kind: InsertionJSON, Name: prefix, Original: original})
+	}
+}
+
+// setJSONField writes payload at path. A string field receives the payload as a string,
+// other fields receive it as raw JSON when it parses, so numbers and booleans can be fuzzed too.
+func setJSONField(v interface{}, path []string, payload string) (interface{}, error) {
+	if len(path) == 0 {
+		if _, isString := v.(string); !isString {
+			var raw interface{}
+			if err := json.Unmarshal([]byte(payload), &raw); err == nil {
+				return json.RawMessage(payload), nil
+			}
+		}
+		return payload, nil
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		child, err := setJSONField(value[path[0]], path[1:], payload)
+		if err != nil {
+			return nil, err
+		}
+		value[path[0]] = child
+		return value, nil
+	case []interface{}:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 || index >= len(value) {
+			return nil, fmt.Errorf("JSON array has no index %q", path[0])
+		}
+		child, err := setJSONField(value[index], path[1:], payload)
+		if err != nil {
+			return nil, err
+		}
+		value[index] = child
+		return value, nil
+	default:
+		return nil, fmt.Errorf("JSON field %q does not exist", strings.Join(path, "."))
+	}
+}
+
+// dedupeInsertionPoints drops repeated query parameters and headers, a payload replaces all of them
+func dedupeInsertionPoints(points []InsertionPoint) []InsertionPoint {
+	var out []InsertionPoint
+	seen := make(map[string]bool)
+	for _, p := range points {
+		key := strings.ToLower(p.String())
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, p)
+	}
+	return out
+}
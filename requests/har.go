@@ -1,33 +1,157 @@
 package requests
 
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+func init() {
+	RegisterImporter(ImporterRegistration{
+		Format:     ImportFormatHAR,
+		Label:      "HAR (browser, ZAP)",
+		JobType:    JobTypeImportHAR,
+		Extensions: []string{".har", ".json"},
+		Importer:   ImporterFunc(StreamHAR),
+	})
+}
+
 // AI generated struct
 type HAR struct {
 	Log struct {
-		Entries []struct {
-			StartedDateTime string  `json:"startedDateTime"`
-			Time            float64 `json:"time"`
-			Request         struct {
-				Method  string `json:"method"`
-				URL     string `json:"url"`
-				Headers []struct {
-					Name  string `json:"name"`
-					Value string `json:"value"`
-				} `json:"headers"`
-				PostData struct {
-					Text string `json:"text"`
-				} `json:"postData"`
-			} `json:"request"`
-			Response struct {
-				Status  int `json:"status"`
-				Headers []struct {
-					Name  string `json:"name"`
-					Value string `json:"value"`
-				} `json:"headers"`
-				Content struct {
-					Text     string `json:"text"`
-					Encoding string `json:"encoding,omitempty"`
-				} `json:"content"`
-			} `json:"response"`
-		} `json:"entries"`
+		Entries []HAREntry `json:"entries"`
 	} `json:"log"`
 }
+
+// HAREntry is a single request/response pair of a HAR log
+type HAREntry struct {
+	StartedDateTime string  `json:"startedDateTime"`
+	Time            float64 `json:"time"`
+	Request         struct {
+		Method   string      `json:"method"`
+		URL      string      `json:"url"`
+		Headers  []HARHeader `json:"headers"`
+		PostData struct {
+			Text string `json:"text"`
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status  int         `json:"status"`
+		Headers []HARHeader `json:"headers"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding,omitempty"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// HARHeader is a name/value pair of a HAR request or response
+type HARHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// StreamHAR walks log.entries of a HAR document one entry at a time,
+// calling fn for each converted request without loading the whole file into memory
+func StreamHAR(r io.Reader, opts ImportOptions, fn func(TempMyRequest) error) error {
+	dec := json.NewDecoder(r)
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if key != "log" {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := streamHARLog(dec, opts, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamHARLog walks the keys of the log object until it reaches the entries array
+func streamHARLog(dec *json.Decoder, opts ImportOptions, fn func(TempMyRequest) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if key != "entries" {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := expectDelim(dec, '['); err != nil {
+			return err
+		}
+		sequence := 0
+		for dec.More() {
+			var entry HAREntry
+			if err := dec.Decode(&entry); err != nil {
+				return fmt.Errorf("invalid entry %d: %v", sequence+1, err)
+			}
+			sequence++
+
+			my := entry.toTempMyRequest(sequence)
+			my.ApplyHashes(opts.HashFunc)
+			if err := fn(my); err != nil {
+				return err
+			}
+		}
+		// Closing bracket of entries
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+	// Closing brace of log
+	_, err := dec.Token()
+	return err
+}
+
+// expectDelim reads the next token and checks that it is the given delimiter
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := token.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("invalid HAR: expected %q but got %v", delim, token)
+	}
+	return nil
+}
+
+// skipValue consumes the next JSON value, including nested objects and arrays
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := token.(json.Delim); ok {
+			switch d {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package requests
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// HARCreatorName is written as log.creator.name of exported HAR documents
+const HARCreatorName = "Requester"
+
+// harDocumentHead opens the log object, entries are streamed into the array after it
+const harDocumentHead = `{"log":{"version":"1.2","creator":{"name":"` + HARCreatorName + `","version":"1.0"},"entries":[`
+
+// harExportEntry is a HAR 1.2 entry with every field the specification requires
+type harExportEntry struct {
+	StartedDateTime string            `json:"startedDateTime"`
+	Time            float64           `json:"time"`
+	Request         harExportRequest  `json:"request"`
+	Response        harExportResponse `json:"response"`
+	Cache           struct{}          `json:"cache"`
+	Timings         harExportTimings  `json:"timings"`
+}
+
+type harExportRequest struct {
+	Method      string             `json:"method"`
+	URL         string             `json:"url"`
+	HTTPVersion string             `json:"httpVersion"`
+	Cookies     []harExportCookie  `json:"cookies"`
+	Headers     []HARHeader        `json:"headers"`
+	QueryString []HARHeader        `json:"queryString"`
+	PostData    *harExportPostData `json:"postData,omitempty"`
+	HeadersSize int                `json:"headersSize"`
+	BodySize    int                `json:"bodySize"`
+}
+
+type harExportPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harExportResponse struct {
+	Status      int               `json:"status"`
+	StatusText  string            `json:"statusText"`
+	HTTPVersion string            `json:"httpVersion"`
+	Cookies     []harExportCookie `json:"cookies"`
+	Headers     []HARHeader       `json:"headers"`
+	Content     harExportContent  `json:"content"`
+	RedirectURL string            `json:"redirectURL"`
+	HeadersSize int               `json:"headersSize"`
+	BodySize    int               `json:"bodySize"`
+}
+
+type harExportContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harExportCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harExportTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HARWriter streams stored requests into a HAR 1.2 document, encoding one entry at a time
+type HARWriter struct {
+	bw      *bufio.Writer
+	entries int
+}
+
+// NewHARWriter starts a HAR document on w, Close must be called to finish it
+func NewHARWriter(w io.Writer) (*HARWriter, error) {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(harDocumentHead); err != nil {
+		return nil, err
+	}
+	return &HARWriter{bw: bw}, nil
+}
+
+// Add writes req as the next entry.
+// A request that cannot be converted, such as one with malformed header JSON, is left out and reported as ErrHAREntrySkipped,
+// the document stays valid and more entries can be added. Any other error is a failed write.
+func (hw *HARWriter) Add(req MyRequest) error {
+	entry, err := req.toHAREntry()
+	if err != nil {
+		return fmt.Errorf("%w: request %d: %v", ErrHAREntrySkipped, req.ID, err)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("%w: request %d: %v", ErrHAREntrySkipped, req.ID, err)
+	}
+	if hw.entries > 0 {
+		hw.bw.WriteByte(',')
+	}
+	hw.entries++
+	_, err = hw.bw.Write(data)
+	return err
+}
+
+// Close ends the document and flushes it
+func (hw *HARWriter) Close() error {
+	if _, err := hw.bw.WriteString("]}}"); err != nil {
+		return err
+	}
+	return hw.bw.Flush()
+}
+
+// ErrHAREntrySkipped is returned by HARWriter.Add for a request left out of the document
+var ErrHAREntrySkipped = errors.New("request left out of the HAR export")
+
+// toHAREntry converts a stored request back into a HAR entry, decoding its JSON header columns
+func (req MyRequest) toHAREntry() (harExportEntry, error) {
+	reqHeaders, err := decodeStoredHeaders(req.ReqHeaders)
+	if err != nil {
+		return harExportEntry{}, fmt.Errorf("invalid request headers: %v", err)
+	}
+	resHeaders, err := decodeStoredHeaders(req.ResHeaders)
+	if err != nil {
+		return harExportEntry{}, fmt.Errorf("invalid response headers: %v", err)
+	}
+
+	entry := harExportEntry{
+		StartedDateTime: req.harStartedDateTime(),
+		Time:            float64(req.LatencyMs),
+		Request: harExportRequest{
+			Method:      req.Method,
+			URL:         req.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     harCookies(reqHeaders, "Cookie"),
+			Headers:     toHARHeaders(reqHeaders),
+			QueryString: harQueryString(req.URL),
+			HeadersSize: -1,
+			BodySize:    len(req.ReqBody),
+		},
+		Response: harExportResponse{
+			Status:      req.ResStatus,
+			StatusText:  http.StatusText(req.ResStatus),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     harCookies(resHeaders, "Set-Cookie"),
+			Headers:     toHARHeaders(resHeaders),
+			Content:     req.harContent(resHeaders),
+			RedirectURL: resHeaders.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    req.RespSize,
+		},
+		Timings: harExportTimings{Wait: float64(req.LatencyMs)},
+	}
+	if req.ReqBody != "" {
+		entry.Request.PostData = &harExportPostData{
+			MimeType: reqHeaders.Get("Content-Type"),
+			Text:     req.ReqBody,
+		}
+	}
+	return entry, nil
+}
+
+// harContent returns the response body for the export, base64 encoding binary bodies as HAR requires
+func (req MyRequest) harContent(resHeaders HeaderSlice) harExportContent {
+	content := harExportContent{Size: len(req.ResBody), MimeType: req.ResMimeType, Text: req.ResBody}
+	if content.MimeType == "" {
+		content.MimeType = resHeaders.Get("Content-Type")
+	}
+	if !utf8.ValidString(req.ResBody) {
+		content.Text = base64.StdEncoding.EncodeToString([]byte(req.ResBody))
+		content.Encoding = ContentEncodingBase64
+	}
+	return content
+}
+
+// harStartedDateTime keeps the captured request time when it is ISO 8601 and falls back to the row's creation time
+func (req MyRequest) harStartedDateTime() string {
+	if t, err := time.Parse(time.RFC3339Nano, req.RequestTime); err == nil {
+		return t.Format(time.RFC3339Nano)
+	}
+	return time.Unix(req.CreatedAt, 0).UTC().Format(time.RFC3339Nano)
+}
+
+// decodeStoredHeaders decodes a JSON header column, an empty column has no headers
+func decodeStoredHeaders(jsonStr string) (HeaderSlice, error) {
+	if strings.TrimSpace(jsonStr) == "" {
+		return HeaderSlice{}, nil
+	}
+	return HeaderSliceFromJSON(jsonStr)
+}
+
+// toHARHeaders converts headers to HAR name/value pairs, never returning nil
+func toHARHeaders(headers HeaderSlice) []HARHeader {
+	out := make([]HARHeader, 0, len(headers))
+	for _, h := range headers {
+		out = append(out, HARHeader{Name: h.Name, Value: h.Value})
+	}
+	return out
+}
+
+// harQueryString lists the query parameters of rawURL in their original order
+func harQueryString(rawURL string) []HARHeader {
+	out := []HARHeader{}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return out
+	}
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		if n, err := url.QueryUnescape(name); err == nil {
+			name = n
+		}
+		if v, err := url.QueryUnescape(value); err == nil {
+			value = v
+		}
+		out = append(out, HARHeader{Name: name, Value: value})
+	}
+	return out
+}
+
+// harCookies parses the cookies carried by the Cookie or Set-Cookie headers
+func harCookies(headers HeaderSlice, headerName string) []harExportCookie {
+	h := http.Header{}
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, headerName) {
+			h.Add(headerName, header.Value)
+		}
+	}
+
+	var cookies []*http.Cookie
+	if headerName == "Set-Cookie" {
+		cookies = (&http.Response{Header: h}).Cookies()
+	} else {
+		cookies = (&http.Request{Header: h}).Cookies()
+	}
+	out := make([]harExportCookie, 0, len(cookies))
+	for _, c := range cookies {
+		out = append(out, harExportCookie{Name: c.Name, Value: c.Value})
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+package requests
+
+import (
+	"io"
+)
+
+// ImportOptions configures how an importer converts and hashes requests
+type ImportOptions struct {
+	// HashFunc decides which parts of a request and response are hashed
+	HashFunc func(*TempMyRequest) (string, string)
+	// Scheme is used to rebuild URLs of raw requests that only carry a Host header
+	Scheme string
+	// Delimiter separates messages of a raw HTTP file, on a line of its own
+	Delimiter string
+	// Variables override the {{name}} values defined inside a collection
+	Variables map[string]string
+}
+
+// Importer streams the requests of a capture file, calling fn once per request
+type Importer interface {
+	Stream(r io.Reader, opts ImportOptions, fn func(TempMyRequest) error) error
+}
+
+// ImporterFunc adapts a plain function to the Importer interface
+type ImporterFunc func(r io.Reader, opts ImportOptions, fn func(TempMyRequest) error) error
+
+// Stream calls f(r, opts, fn)
+func (f ImporterFunc) Stream(r io.Reader, opts ImportOptions, fn func(TempMyRequest) error) error {
+	return f(r, opts, fn)
+}
+
+// ImporterRegistration describes a registered capture format
+type ImporterRegistration struct {
+	Format     ImportFormat
+	Label      string // shown on the import form
+	JobType    JobType
+	Extensions []string
+	Source     EndpointSource // source of endpoints created by the import, capture when empty
+	Importer   Importer
+}
+
+var (
+	importers     = make(map[ImportFormat]ImporterRegistration)
+	importerOrder []ImportFormat
+)
+
+// RegisterImporter makes a capture format available to imports, replacing any importer with the same format name
+func RegisterImporter(reg ImporterRegistration) {
+	if _, exists := importers[reg.Format]; !exists {
+		importerOrder = append(importerOrder, reg.Format)
+	}
+	importers[reg.Format] = reg
+}
+
+// LookupImporter returns the importer registered for a format name
+func LookupImporter(format ImportFormat) (ImporterRegistration, bool) {
+	reg, ok := importers[format]
+	return reg, ok
+}
+
+// Importers returns all registered importers in registration order
+func Importers() []ImporterRegistration {
+	result := make([]ImporterRegistration, 0, len(importerOrder))
+	for _, format := range importerOrder {
+		result = append(result, importers[format])
+	}
+	return result
+}
@@ -0,0 +1,232 @@
+package requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	RegisterImporter(ImporterRegistration{
+		Format:     ImportFormatInsomnia,
+		Label:      "Insomnia export (v4 JSON)",
+		JobType:    JobTypeImportInsomnia,
+		Extensions: []string{".json"},
+		Source:     EndpointSourceCollection,
+		Importer:   ImporterFunc(StreamInsomniaExport),
+	})
+}
+
+// InsomniaExport is an Insomnia v4 export, a flat list of resources linked by parent IDs
+type InsomniaExport struct {
+	Type         string             `json:"_type"`
+	ExportFormat int                `json:"__export_format"`
+	Resources    []InsomniaResource `json:"resources"`
+}
+
+// InsomniaResource is a workspace, folder (request_group), request or environment
+type InsomniaResource struct {
+	ID             string                     `json:"_id"`
+	ParentID       string                     `json:"parentId"`
+	Type           string                     `json:"_type"`
+	Name           string                     `json:"name"`
+	Method         string                     `json:"method"`
+	URL            string                     `json:"url"`
+	Headers        []InsomniaPair             `json:"headers"`
+	Parameters     []InsomniaPair             `json:"parameters"`
+	Body           InsomniaBody               `json:"body"`
+	Authentication map[string]json.RawMessage `json:"authentication"`
+	Environment    map[string]json.RawMessage `json:"environment"` // folder environment
+	Data           map[string]json.RawMessage `json:"data"`        // environment data
+}
+
+// InsomniaPair is a name/value pair of headers, query parameters and form fields
+type InsomniaPair struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Type     string `json:"type"`
+	FileName string `json:"fileName"`
+	Disabled bool   `json:"disabled"`
+}
+
+// InsomniaBody is an Insomnia request body
+type InsomniaBody struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Params   []InsomniaPair `json:"params"`
+}
+
+// StreamInsomniaExport imports the requests of an Insomnia v4 export as request-only records.
+// Variables come from the base environments and folder environments and are overridden by opts.Variables.
+func StreamInsomniaExport(r io.Reader, opts ImportOptions, fn func(TempMyRequest) error) error {
+	var export InsomniaExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return fmt.Errorf("invalid Insomnia export: %v", err)
+	}
+	if export.Type != "export" || export.ExportFormat != 4 {
+		return fmt.Errorf("unsupported Insomnia export, expected format 4 but got %d", export.ExportFormat)
+	}
+
+	byID := make(map[string]InsomniaResource, len(export.Resources))
+	for _, res := range export.Resources {
+		byID[res.ID] = res
+	}
+
+	// Base environments hang off a workspace; sub environments are alternatives, so pick one via opts.Variables
+	baseVars := make(map[string]string)
+	for _, res := range export.Resources {
+		if res.Type == "environment" && byID[res.ParentID].Type == "workspace" {
+			for name, value := range res.Data {
+				flattenVariable(baseVars, name, value)
+			}
+		}
+	}
+
+	sequence := 0
+	for _, res := range export.Resources {
+		if res.Type != "request" {
+			continue
+		}
+
+		// Walk up the folders, collecting names and environments; nearer folders win
+		var folders []string
+		var folderEnvs []map[string]string // nearest first
+		parent, ok := byID[res.ParentID]
+		for depth := 0; ok && parent.Type == "request_group" && depth < len(export.Resources); depth++ {
+			folders = append([]string{parent.Name}, folders...)
+			env := make(map[string]string)
+			for name, value := range parent.Environment {
+				flattenVariable(env, name, value)
+			}
+			folderEnvs = append(folderEnvs, env)
+			parent, ok = byID[parent.ParentID]
+		}
+		sets := []map[string]string{baseVars}
+		for i := len(folderEnvs) - 1; i >= 0; i-- {
+			sets = append(sets, folderEnvs[i])
+		}
+		vars := mergeVariables(append(sets, opts.Variables)...)
+
+		req, err := res.collectionRequest(strings.Join(folders, " / "), vars)
+		if err != nil {
+			return fmt.Errorf("request %q: %v", res.Name, err)
+		}
+		my, err := req.toTempMyRequest(vars)
+		if err != nil {
+			return fmt.Errorf("request %q: %v", res.Name, err)
+		}
+		sequence++
+		my.Sequence = sequence
+		my.ApplyHashes(opts.HashFunc)
+		if err := fn(my); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectionRequest converts an Insomnia request resource with its query parameters, body and authentication
+func (res InsomniaResource) collectionRequest(folder string, vars map[string]string) (collectionRequest, error) {
+	result := collectionRequest{
+		Folder: folder,
+		Method: res.Method,
+		URL:    res.URL,
+	}
+
+	query := make([]string, 0, len(res.Parameters))
+	for _, p := range res.Parameters {
+		if !p.Disabled {
+			query = append(query, url.QueryEscape(resolveVariables(p.Name, vars))+"="+url.QueryEscape(resolveVariables(p.Value, vars)))
+		}
+	}
+	if len(query) > 0 {
+		sep := "?"
+		if strings.Contains(result.URL, "?") {
+			sep = "&"
+		}
+		result.URL += sep + strings.Join(query, "&")
+	}
+
+	for _, h := range res.Headers {
+		if !h.Disabled && h.Name != "" {
+			result.Headers = append(result.Headers, Header{Name: h.Name, Value: h.Value})
+		}
+	}
+
+	contentType := res.Body.MimeType
+	switch res.Body.MimeType {
+	case "":
+	case "application/x-www-form-urlencoded":
+		values := make([]string, 0, len(res.Body.Params))
+		for _, p := range res.Body.Params {
+			if !p.Disabled {
+				values = append(values, url.QueryEscape(resolveVariables(p.Name, vars))+"="+url.QueryEscape(resolveVariables(p.Value, vars)))
+			}
+		}
+		result.Body = strings.Join(values, "&")
+	case "multipart/form-data":
+		fields := make([]formField, 0, len(res.Body.Params))
+		for _, p := range res.Body.Params {
+			if p.Disabled {
+				continue
+			}
+			if p.Type == "file" {
+				fields = append(fields, formField{Name: p.Name, Filename: p.FileName[strings.LastIndexAny(p.FileName, `/\`)+1:], IsFile: true})
+				continue
+			}
+			fields = append(fields, formField{Name: p.Name, Value: p.Value})
+		}
+		body, formContentType, err := buildMultipartBody(fields)
+		if err != nil {
+			return collectionRequest{}, fmt.Errorf("failed to build form body: %v", err)
+		}
+		result.Body, contentType = body, formContentType
+	case "application/graphql":
+		// Insomnia stores GraphQL as the JSON payload it sends
+		result.Body, contentType = res.Body.Text, "application/json"
+	default:
+		result.Body = res.Body.Text
+	}
+	if result.Body != "" && result.Headers.Get("Content-Type") == "" {
+		result.Headers = append(result.Headers, Header{Name: "Content-Type", Value: contentType})
+	}
+
+	result.applyInsomniaAuth(res.Authentication, vars)
+	return result, nil
+}
+
+// applyInsomniaAuth adds the header of bearer, basic and API key authentication unless it is disabled
+func (c *collectionRequest) applyInsomniaAuth(auth map[string]json.RawMessage, vars map[string]string) {
+	if len(auth) == 0 || c.Headers.Get("Authorization") != "" {
+		return
+	}
+	field := func(name string) string {
+		return jsonScalar(auth[name])
+	}
+	if field("disabled") == "true" {
+		return
+	}
+
+	switch field("type") {
+	case "bearer":
+		prefix := field("prefix")
+		if prefix == "" {
+			prefix = "Bearer"
+		}
+		c.Headers = append(c.Headers, Header{Name: "Authorization", Value: prefix + " " + field("token")})
+	case "basic":
+		c.Headers = append(c.Headers, basicAuthHeader(resolveVariables(field("username")+":"+field("password"), vars)))
+	case "apikey":
+		if field("addTo") == "queryParams" {
+			sep := "?"
+			if strings.Contains(c.URL, "?") {
+				sep = "&"
+			}
+			c.URL += sep + field("key") + "=" + field("value")
+			return
+		}
+		c.Headers = append(c.Headers, Header{Name: field("key"), Value: field("value")})
+	}
+}
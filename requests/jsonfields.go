@@ -0,0 +1,67 @@
+package requests
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// StripJSONFields removes the named fields from a JSON body so values that change on every
+// exchange, such as timestamps, CSRF tokens and nonces, don't change its hash.
+// A plain name matches the key at any depth, a dotted path such as data.meta.ts only from the root,
+// array elements share the path of their array. The result is compact JSON with sorted keys.
+// Bodies that aren't JSON are returned as they are.
+func StripJSONFields(body string, fields []string) string {
+	trimmed := strings.TrimSpace(body)
+	if len(fields) == 0 || trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return body
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return body
+	}
+
+	names := make(map[string]bool)
+	paths := make(map[string]bool)
+	for _, field := range fields {
+		if strings.Contains(field, ".") {
+			paths[field] = true
+		} else {
+			names[field] = true
+		}
+	}
+	stripJSONValue(value, "", names, paths)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return body
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// stripJSONValue deletes the matching keys of value and its children in place
+func stripJSONValue(value interface{}, path string, names, paths map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			if names[key] || paths[childPath] {
+				delete(v, key)
+				continue
+			}
+			stripJSONValue(child, childPath, names, paths)
+		}
+	case []interface{}:
+		for _, child := range v {
+			stripJSONValue(child, path, names, paths)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package requests
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+func init() {
+	RegisterImporter(ImporterRegistration{
+		Format:     ImportFormatMitmproxy,
+		Label:      "mitmproxy flows dump",
+		JobType:    JobTypeImportMitmproxy,
+		Extensions: []string{".flows", ".mitm", ".dump"},
+		Importer:   ImporterFunc(StreamMitmproxyFlows),
+	})
+}
+
+// StreamMitmproxyFlows walks a mitmproxy flows dump (a sequence of tnetstring encoded flows)
+// one flow at a time, calling fn for each HTTP flow
+func StreamMitmproxyFlows(r io.Reader, opts ImportOptions, fn func(TempMyRequest) error) error {
+	reader := bufio.NewReader(r)
+	sequence := 0
+	for {
+		if _, err := reader.Peek(1); err == io.EOF {
+			return nil
+		}
+
+		value, err := readTNetString(reader)
+		if err != nil {
+			return fmt.Errorf("invalid flow %d: %v", sequence+1, err)
+		}
+
+		flow := tnDict(value)
+		// TCP, UDP and DNS flows have no HTTP request to import
+		if tnString(flow["type"]) != "http" {
+			continue
+		}
+		sequence++
+
+		my, err := mitmproxyFlowToTempMyRequest(flow, sequence)
+		if err != nil {
+			return fmt.Errorf("invalid flow %d: %v", sequence, err)
+		}
+		my.ApplyHashes(opts.HashFunc)
+		if err := fn(my); err != nil {
+			return err
+		}
+	}
+}
+
+// mitmproxyFlowToTempMyRequest converts a decoded HTTP flow to a TempMyRequest without hashes
+func mitmproxyFlowToTempMyRequest(flow map[string]interface{}, sequence int) (TempMyRequest, error) {
+	request := tnDict(flow["request"])
+	if len(request) == 0 {
+		return TempMyRequest{}, fmt.Errorf("flow has no request")
+	}
+
+	scheme := tnString(request["scheme"])
+	host := tnString(request["host"])
+	port, _ := strconv.Atoi(tnString(request["port"]))
+	authority := host
+	if port != 0 && !(scheme == "http" && port == 80) && !(scheme == "https" && port == 443) {
+		authority = net.JoinHostPort(host, strconv.Itoa(port))
+	}
+
+	startedAt := tnFloat(request["timestamp_start"])
+	my := TempMyRequest{
+		Sequence:   sequence,
+		URL:        scheme + "://" + authority + tnString(request["path"]),
+		Method:     tnString(request["method"]),
+		Domain:     host,
+		ReqHeaders: mitmproxyHeaders(request["headers"]),
+		ReqBody:    tnString(request["content"]),
+	}
+	if startedAt > 0 {
+		my.RequestTime = time.UnixMilli(int64(startedAt * 1000)).UTC().Format(time.RFC3339Nano)
+	}
+
+	// Flows that were killed or errored have no response
+	if response := tnDict(flow["response"]); len(response) > 0 {
+		status, _ := strconv.Atoi(tnString(response["status_code"]))
+		my.ResStatus = status
+		my.ResHeaders = mitmproxyHeaders(response["headers"])
+		my.ResBody = tnString(response["content"])
+		my.RespSize = len(my.ResBody)
+		if finishedAt := tnFloat(response["timestamp_end"]); finishedAt > 0 && startedAt > 0 {
+			my.LatencyMs = int64((finishedAt - startedAt) * 1000)
+		}
+	}
+
+	return my, nil
+}
+
+// mitmproxyHeaders converts mitmproxy's list of [name, value] pairs to a HeaderSlice
+func mitmproxyHeaders(v interface{}) HeaderSlice {
+	pairs, _ := v.([]interface{})
+	headers := make(HeaderSlice, 0, len(pairs))
+	for _, pair := range pairs {
+		fields, ok := pair.([]interface{})
+		if !ok || len(fields) != 2 {
+			continue
+		}
+		headers = append(headers, Header{Name: tnString(fields[0]), Value: tnString(fields[1])})
+	}
+	return headers
+}
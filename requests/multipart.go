@@ -0,0 +1,41 @@
+package requests
+
+import (
+	"bytes"
+	"mime/multipart"
+)
+
+// importMultipartBoundary keeps generated multipart bodies stable so identical requests hash the same
+const importMultipartBoundary = "RequesterFormBoundary"
+
+// formField is a single multipart form field of an imported request
+type formField struct {
+	Name     string
+	Value    string
+	Filename string
+	IsFile   bool // file contents are never available, so file parts are empty
+}
+
+// buildMultipartBody renders form fields as a multipart/form-data body and returns it with its Content-Type
+func buildMultipartBody(fields []formField) (string, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := w.SetBoundary(importMultipartBoundary); err != nil {
+		return "", "", err
+	}
+	for _, field := range fields {
+		if field.IsFile {
+			if _, err := w.CreateFormFile(field.Name, field.Filename); err != nil {
+				return "", "", err
+			}
+			continue
+		}
+		if err := w.WriteField(field.Name, field.Value); err != nil {
+			return "", "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", "", err
+	}
+	return buf.String(), w.FormDataContentType(), nil
+}
@@ -0,0 +1,117 @@
+package requests
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PathRule replaces path segments matching Pattern with the {Name} parameter
+type PathRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// DefaultPathRules detect the identifiers commonly found in paths, tried in order
+var DefaultPathRules = []PathRule{
+	{Name: "uuid", Pattern: regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)},
+	{Name: "date", Pattern: regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)},
+	{Name: "id", Pattern: regexp.MustCompile(`^\d+$`)},
+	{Name: "hash", Pattern: regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)},
+	// at least four hyphenated words, such as the title of a blog post
+	{Name: "slug", Pattern: regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+){3,}$`)},
+}
+
+// pathRuleName restricts parameter names so templates stay readable and match PathTemplatePattern
+var pathRuleName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// PathNormalizer turns concrete request paths into endpoint templates such as /users/{id}.
+// A nil PathNormalizer uses DefaultPathRules.
+type PathNormalizer struct {
+	Rules []PathRule
+}
+
+// ParsePathRules parses the path rules of a program into a PathNormalizer.
+//
+// One rule per line, lines starting with # are comments. "name: regex" adds a rule templating
+// whole segments matching regex as {name}, tried before the defaults. "-name" disables the default
+// rule of that name (uuid, date, id, hash, slug) and "-*" disables all of them.
+func ParsePathRules(text string) (*PathNormalizer, error) {
+	var custom []PathRule
+	disabled := make(map[string]bool)
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "-") {
+			name := strings.TrimSpace(line[1:])
+			if name != "*" && !isDefaultPathRule(name) {
+				return nil, fmt.Errorf("invalid path rule %q: no default rule named %q", line, name)
+			}
+			disabled[name] = true
+			continue
+		}
+
+		name, pattern, found := strings.Cut(line, ":")
+		name, pattern = strings.TrimSpace(name), strings.TrimSpace(pattern)
+		if !found || pattern == "" {
+			return nil, fmt.Errorf("invalid path rule %q: expected name: regex", line)
+		}
+		if !pathRuleName.MatchString(name) {
+			return nil, fmt.Errorf("invalid path rule %q: name must be a word", line)
+		}
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid path rule %q: %v", line, err)
+		}
+		custom = append(custom, PathRule{Name: name, Pattern: re})
+	}
+
+	normalizer := &PathNormalizer{Rules: custom}
+	if !disabled["*"] {
+		for _, rule := range DefaultPathRules {
+			if !disabled[rule.Name] {
+				normalizer.Rules = append(normalizer.Rules, rule)
+			}
+		}
+	}
+	return normalizer, nil
+}
+
+// isDefaultPathRule reports whether name is one of DefaultPathRules
+func isDefaultPathRule(name string) bool {
+	for _, rule := range DefaultPathRules {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize replaces the segments of path matching a rule with its parameter.
+// Segments that are already parameters, like {id} or :id, are kept as they are.
+func (n *PathNormalizer) Normalize(path string) string {
+	rules := DefaultPathRules
+	if n != nil {
+		rules = n.Rules
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" || strings.HasPrefix(segment, ":") || strings.Contains(segment, "{") {
+			continue
+		}
+		for _, rule := range rules {
+			if rule.Pattern.MatchString(segment) {
+				segments[i] = "{" + rule.Name + "}"
+				break
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// PathNormalizer parses the path rules of the program
+func (p Program) PathNormalizer() (*PathNormalizer, error) {
+	return ParsePathRules(p.PathRules)
+}
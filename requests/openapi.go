@@ -0,0 +1,184 @@
+package requests
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPIMethods lists the path item keys that describe operations
+var openAPIMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// templateParamPattern matches {name} parameters of server URLs and path templates
+var templateParamPattern = regexp.MustCompile(`\{\{?([^{}]+)\}?\}`)
+
+// OpenAPIOperation is a single method and path template described by an API document
+type OpenAPIOperation struct {
+	Method      string
+	Domain      string
+	URI         string // server base path plus the path template, {param} segments kept
+	OperationID string
+	Summary     string
+	Tags        []string
+}
+
+// openAPIDocument holds the fields shared by Swagger 2.0 and OpenAPI 3.x documents
+type openAPIDocument struct {
+	Swagger string `yaml:"swagger"`
+	OpenAPI string `yaml:"openapi"`
+	Info    struct {
+		Title string `yaml:"title"`
+	} `yaml:"info"`
+	// Swagger 2.0
+	Host     string   `yaml:"host"`
+	BasePath string   `yaml:"basePath"`
+	Schemes  []string `yaml:"schemes"`
+	// OpenAPI 3.x
+	Servers []struct {
+		URL       string `yaml:"url"`
+		Variables map[string]struct {
+			Default string `yaml:"default"`
+		} `yaml:"variables"`
+	} `yaml:"servers"`
+	Paths yaml.Node `yaml:"paths"`
+}
+
+// openAPIOperationDoc is the part of an operation object kept on the endpoint
+type openAPIOperationDoc struct {
+	OperationID string   `yaml:"operationId"`
+	Summary     string   `yaml:"summary"`
+	Tags        []string `yaml:"tags"`
+}
+
+// ParseOpenAPI reads a Swagger 2.0 or OpenAPI 3.x document in YAML or JSON and returns its title and operations
+// in document order. baseURL replaces the document's server, or completes it when the server URL is relative.
+func ParseOpenAPI(r io.Reader, baseURL string) (string, []OpenAPIOperation, error) {
+	var doc openAPIDocument
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return "", nil, fmt.Errorf("invalid OpenAPI document: %v", err)
+	}
+	if !strings.HasPrefix(doc.Swagger, "2.") && !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return "", nil, fmt.Errorf("unsupported document, expected swagger 2.0 or openapi 3.x")
+	}
+
+	server, err := doc.serverURL(baseURL)
+	if err != nil {
+		return "", nil, err
+	}
+	basePath := strings.TrimSuffix(server.Path, "/")
+
+	if doc.Paths.Kind != yaml.MappingNode {
+		return doc.Info.Title, nil, nil
+	}
+	var operations []OpenAPIOperation
+	for i := 0; i+1 < len(doc.Paths.Content); i += 2 {
+		path, item := doc.Paths.Content[i].Value, doc.Paths.Content[i+1]
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(item.Content); j += 2 {
+			method := strings.ToLower(item.Content[j].Value)
+			if !openAPIMethods[method] {
+				continue
+			}
+			var op openAPIOperationDoc
+			if err := item.Content[j+1].Decode(&op); err != nil {
+				return "", nil, fmt.Errorf("invalid operation %s %s: %v", strings.ToUpper(method), path, err)
+			}
+			operations = append(operations, OpenAPIOperation{
+				Method:      strings.ToUpper(method),
+				Domain:      server.Hostname(),
+				URI:         basePath + "/" + strings.TrimPrefix(path, "/"),
+				OperationID: op.OperationID,
+				Summary:     op.Summary,
+				Tags:        op.Tags,
+			})
+		}
+	}
+	return doc.Info.Title, operations, nil
+}
+
+// serverURL resolves the URL operations are served from
+func (doc openAPIDocument) serverURL(baseURL string) (*url.URL, error) {
+	server := ""
+	if strings.HasPrefix(doc.Swagger, "2.") {
+		if doc.Host != "" {
+			scheme := "https"
+			if len(doc.Schemes) > 0 {
+				scheme = doc.Schemes[0]
+			}
+			server = scheme + "://" + doc.Host
+		}
+		server += doc.BasePath
+	} else if len(doc.Servers) > 0 {
+		first := doc.Servers[0]
+		server = templateParamPattern.ReplaceAllStringFunc(first.URL, func(tag string) string {
+			if v, ok := first.Variables[templateParamPattern.FindStringSubmatch(tag)[1]]; ok {
+				return v.Default
+			}
+			return tag
+		})
+	}
+
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL %q: %v", server, err)
+	}
+	if baseURL != "" {
+		base, err := url.Parse(baseURL)
+		if err != nil || base.Host == "" {
+			return nil, fmt.Errorf("invalid base URL %q", baseURL)
+		}
+		if u.Host != "" {
+			// an absolute server is replaced entirely
+			return base, nil
+		}
+		base.Path = strings.TrimSuffix(base.Path, "/") + "/" + strings.TrimPrefix(u.Path, "/")
+		return base, nil
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("the document has no absolute server URL, enter a base URL")
+	}
+	return u, nil
+}
+
+// pathTemplatePatterns caches the compiled pattern of every template, documented endpoints rarely change
+var pathTemplatePatterns sync.Map
+
+// PathTemplatePattern compiles a documented path such as /users/{id} or /users/:id
+// into a pattern matching concrete request paths, once per template
+func PathTemplatePattern(template string) *regexp.Regexp {
+	if pattern, ok := pathTemplatePatterns.Load(template); ok {
+		return pattern.(*regexp.Regexp)
+	}
+	pattern, _ := pathTemplatePatterns.LoadOrStore(template, compilePathTemplate(template))
+	return pattern.(*regexp.Regexp)
+}
+
+// compilePathTemplate builds the pattern of a path template
+func compilePathTemplate(template string) *regexp.Regexp {
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":") && len(segment) > 1:
+			segments[i] = `[^/]+`
+		case strings.Contains(segment, "{"):
+			parts := templateParamPattern.Split(segment, -1)
+			for j, part := range parts {
+				parts[j] = regexp.QuoteMeta(part)
+			}
+			segments[i] = strings.Join(parts, `[^/]+`)
+		default:
+			segments[i] = regexp.QuoteMeta(segment)
+		}
+	}
+	return regexp.MustCompile("^" + strings.Join(segments, "/") + "/?$")
+}
@@ -0,0 +1,393 @@
+package requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	RegisterImporter(ImporterRegistration{
+		Format:     ImportFormatPostman,
+		Label:      "Postman collection (v2.1)",
+		JobType:    JobTypeImportPostman,
+		Extensions: []string{".json"},
+		Source:     EndpointSourceCollection,
+		Importer:   ImporterFunc(StreamPostmanCollection),
+	})
+}
+
+// PostmanCollection is the subset of a Postman v2.1 collection used for import
+type PostmanCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []PostmanItem     `json:"item"`
+	Variable []PostmanKeyValue `json:"variable"`
+	Auth     *PostmanAuth      `json:"auth"`
+}
+
+// PostmanItem is either a folder (with Item) or a request
+type PostmanItem struct {
+	Name    string          `json:"name"`
+	Item    []PostmanItem   `json:"item"`
+	Request json.RawMessage `json:"request"`
+	Auth    *PostmanAuth    `json:"auth"`
+}
+
+// PostmanRequest is the request of a Postman item in its object form
+type PostmanRequest struct {
+	Method string            `json:"method"`
+	Header []PostmanKeyValue `json:"header"`
+	URL    json.RawMessage   `json:"url"`
+	Body   *PostmanBody      `json:"body"`
+	Auth   *PostmanAuth      `json:"auth"`
+}
+
+// PostmanURL is the object form of a Postman request URL
+type PostmanURL struct {
+	Raw      string            `json:"raw"`
+	Protocol string            `json:"protocol"`
+	Host     json.RawMessage   `json:"host"`
+	Port     string            `json:"port"`
+	Path     json.RawMessage   `json:"path"`
+	Query    []PostmanKeyValue `json:"query"`
+	Variable []PostmanKeyValue `json:"variable"` // values of :name path segments
+}
+
+// PostmanBody is a Postman request body in any of its modes
+type PostmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw"`
+	URLEncoded []PostmanKeyValue `json:"urlencoded"`
+	FormData   []PostmanKeyValue `json:"formdata"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+// PostmanKeyValue is the key/value pair Postman uses for headers, variables, query and form fields
+type PostmanKeyValue struct {
+	Key      string          `json:"key"`
+	Value    json.RawMessage `json:"value"`
+	Type     string          `json:"type"`
+	Src      json.RawMessage `json:"src"`
+	Disabled bool            `json:"disabled"`
+	In       string          `json:"in"`
+}
+
+// PostmanAuth is a Postman auth block, inherited from folders and the collection
+type PostmanAuth struct {
+	Type   string            `json:"type"`
+	Bearer []PostmanKeyValue `json:"bearer"`
+	Basic  []PostmanKeyValue `json:"basic"`
+	APIKey []PostmanKeyValue `json:"apikey"`
+}
+
+// StreamPostmanCollection imports the requests of a Postman v2.1 collection as request-only records.
+// Variables come from the collection and are overridden by opts.Variables, folder names are kept in Folder.
+func StreamPostmanCollection(r io.Reader, opts ImportOptions, fn func(TempMyRequest) error) error {
+	var collection PostmanCollection
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return fmt.Errorf("invalid Postman collection: %v", err)
+	}
+	if collection.Info.Schema != "" && !strings.Contains(collection.Info.Schema, "v2.") {
+		return fmt.Errorf("unsupported Postman collection schema %q, export as v2.1", collection.Info.Schema)
+	}
+
+	collectionVars := make(map[string]string)
+	for _, v := range collection.Variable {
+		if !v.Disabled {
+			collectionVars[v.Key] = v.value()
+		}
+	}
+	vars := mergeVariables(collectionVars, opts.Variables)
+
+	sequence := 0
+	var walk func(items []PostmanItem, folder []string, auth *PostmanAuth) error
+	walk = func(items []PostmanItem, folder []string, auth *PostmanAuth) error {
+		for _, item := range items {
+			itemAuth := auth
+			if item.Auth != nil {
+				itemAuth = item.Auth
+			}
+			if item.Request == nil {
+				if err := walk(item.Item, append(folder, item.Name), itemAuth); err != nil {
+					return err
+				}
+				continue
+			}
+
+			req, err := item.collectionRequest(strings.Join(folder, " / "), itemAuth, vars)
+			if err != nil {
+				return fmt.Errorf("request %q: %v", item.Name, err)
+			}
+			my, err := req.toTempMyRequest(vars)
+			if err != nil {
+				return fmt.Errorf("request %q: %v", item.Name, err)
+			}
+			sequence++
+			my.Sequence = sequence
+			my.ApplyHashes(opts.HashFunc)
+			if err := fn(my); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(collection.Item, nil, collection.Auth)
+}
+
+// collectionRequest converts a Postman request item, applying the auth it inherits
+func (item PostmanItem) collectionRequest(folder string, auth *PostmanAuth, vars map[string]string) (collectionRequest, error) {
+	var req PostmanRequest
+	var rawURL string
+	if err := json.Unmarshal(item.Request, &rawURL); err != nil {
+		// object form
+		if err := json.Unmarshal(item.Request, &req); err != nil {
+			return collectionRequest{}, fmt.Errorf("invalid request: %v", err)
+		}
+		if rawURL, err = postmanURL(req.URL); err != nil {
+			return collectionRequest{}, err
+		}
+	}
+	if req.Auth != nil {
+		auth = req.Auth
+	}
+
+	result := collectionRequest{
+		Folder: folder,
+		Method: req.Method,
+		URL:    rawURL,
+	}
+	for _, h := range req.Header {
+		if !h.Disabled {
+			result.Headers = append(result.Headers, Header{Name: h.Key, Value: h.value()})
+		}
+	}
+	if err := result.applyPostmanBody(req.Body, vars); err != nil {
+		return collectionRequest{}, err
+	}
+	result.applyPostmanAuth(auth, vars)
+	return result, nil
+}
+
+// postmanURL returns the raw form of a Postman URL, building it from its parts when raw is missing,
+// with its :name path variables substituted
+func postmanURL(data json.RawMessage) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("request has no URL")
+	}
+	var raw string
+	if json.Unmarshal(data, &raw) == nil {
+		return raw, nil
+	}
+
+	var u PostmanURL
+	if err := json.Unmarshal(data, &u); err != nil {
+		return "", fmt.Errorf("invalid URL: %v", err)
+	}
+	pathVars := make(map[string]string, len(u.Variable))
+	for _, v := range u.Variable {
+		if value := v.value(); !v.Disabled && value != "" {
+			pathVars[v.Key] = value
+		}
+	}
+	if u.Raw != "" {
+		return substitutePathVariables(u.Raw, pathVars), nil
+	}
+
+	var sb strings.Builder
+	if u.Protocol != "" {
+		sb.WriteString(u.Protocol + "://")
+	}
+	sb.WriteString(joinPostmanParts(u.Host, "."))
+	if u.Port != "" {
+		sb.WriteString(":" + u.Port)
+	}
+	if path := joinPostmanParts(u.Path, "/"); path != "" {
+		sb.WriteString("/" + strings.TrimPrefix(path, "/"))
+	}
+	query := make([]string, 0, len(u.Query))
+	for _, q := range u.Query {
+		if !q.Disabled {
+			query = append(query, q.Key+"="+q.value())
+		}
+	}
+	if len(query) > 0 {
+		sb.WriteString("?" + strings.Join(query, "&"))
+	}
+	return substitutePathVariables(sb.String(), pathVars), nil
+}
+
+// substitutePathVariables replaces the :name path segments of rawURL that have a value, leaving the others as written.
+// Values may hold {{name}} tags, resolved later with the collection variables.
+func substitutePathVariables(rawURL string, pathVars map[string]string) string {
+	if len(pathVars) == 0 {
+		return rawURL
+	}
+	start := 0
+	if _, rest, ok := strings.Cut(rawURL, "://"); ok {
+		start = len(rawURL) - len(rest)
+	}
+	slash := strings.Index(rawURL[start:], "/")
+	if slash < 0 {
+		return rawURL
+	}
+	start += slash
+	end := len(rawURL)
+	if i := strings.IndexAny(rawURL[start:], "?#"); i >= 0 {
+		end = start + i
+	}
+
+	segments := strings.Split(rawURL[start:end], "/")
+	for i, segment := range segments {
+		if value, ok := pathVars[strings.TrimPrefix(segment, ":")]; ok && strings.HasPrefix(segment, ":") {
+			segments[i] = value
+		}
+	}
+	return rawURL[:start] + strings.Join(segments, "/") + rawURL[end:]
+}
+
+// joinPostmanParts joins a host or path given either as a string or as a list of segments
+func joinPostmanParts(data json.RawMessage, sep string) string {
+	if len(data) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		return s
+	}
+	var parts []json.RawMessage
+	if json.Unmarshal(data, &parts) != nil {
+		return ""
+	}
+	segments := make([]string, 0, len(parts))
+	for _, part := range parts {
+		// path segments can also be {"type": "string", "value": "..."} objects
+		var segment struct {
+			Value string `json:"value"`
+		}
+		if json.Unmarshal(part, &segment) == nil {
+			segments = append(segments, segment.Value)
+			continue
+		}
+		segments = append(segments, jsonScalar(part))
+	}
+	return strings.Join(segments, sep)
+}
+
+// applyPostmanBody renders the body in its mode and adds a Content-Type when the request has none.
+// URL-encoded fields are resolved here because escaping would hide their variables.
+func (c *collectionRequest) applyPostmanBody(body *PostmanBody, vars map[string]string) error {
+	if body == nil {
+		return nil
+	}
+
+	contentType := ""
+	switch body.Mode {
+	case "raw":
+		c.Body = body.Raw
+		contentType = contentTypeForLanguage(body.Options.Raw.Language)
+	case "urlencoded":
+		values := make([]string, 0, len(body.URLEncoded))
+		for _, field := range body.URLEncoded {
+			if !field.Disabled {
+				values = append(values, url.QueryEscape(resolveVariables(field.Key, vars))+"="+url.QueryEscape(resolveVariables(field.value(), vars)))
+			}
+		}
+		c.Body = strings.Join(values, "&")
+		contentType = "application/x-www-form-urlencoded"
+	case "formdata":
+		fields := make([]formField, 0, len(body.FormData))
+		for _, field := range body.FormData {
+			if field.Disabled {
+				continue
+			}
+			if field.Type == "file" {
+				filename := jsonScalar(field.Src)
+				fields = append(fields, formField{Name: field.Key, Filename: filename[strings.LastIndexAny(filename, `/\`)+1:], IsFile: true})
+				continue
+			}
+			fields = append(fields, formField{Name: field.Key, Value: field.value()})
+		}
+		formBody, formContentType, err := buildMultipartBody(fields)
+		if err != nil {
+			return fmt.Errorf("failed to build form body: %v", err)
+		}
+		c.Body, contentType = formBody, formContentType
+	case "graphql":
+		if body.GraphQL == nil {
+			return nil
+		}
+		payload := map[string]interface{}{"query": body.GraphQL.Query}
+		if variables := strings.TrimSpace(body.GraphQL.Variables); variables != "" {
+			payload["variables"] = json.RawMessage(variables)
+		}
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("invalid GraphQL variables: %v", err)
+		}
+		c.Body = string(encoded)
+		contentType = "application/json"
+	default:
+		return nil
+	}
+
+	if c.Body != "" && c.Headers.Get("Content-Type") == "" {
+		c.Headers = append(c.Headers, Header{Name: "Content-Type", Value: contentType})
+	}
+	return nil
+}
+
+// applyPostmanAuth adds the headers or query parameters of bearer, basic and API key auth.
+// Basic credentials are resolved here because they are encoded before the request's variables.
+func (c *collectionRequest) applyPostmanAuth(auth *PostmanAuth, vars map[string]string) {
+	if auth == nil || c.Headers.Get("Authorization") != "" {
+		return
+	}
+	param := func(params []PostmanKeyValue, key string) string {
+		for _, p := range params {
+			if p.Key == key {
+				return p.value()
+			}
+		}
+		return ""
+	}
+
+	switch auth.Type {
+	case "bearer":
+		c.Headers = append(c.Headers, Header{Name: "Authorization", Value: "Bearer " + param(auth.Bearer, "token")})
+	case "basic":
+		credentials := resolveVariables(param(auth.Basic, "username")+":"+param(auth.Basic, "password"), vars)
+		c.Headers = append(c.Headers, basicAuthHeader(credentials))
+	case "apikey":
+		key, value := param(auth.APIKey, "key"), param(auth.APIKey, "value")
+		if param(auth.APIKey, "in") == "query" {
+			sep := "?"
+			if strings.Contains(c.URL, "?") {
+				sep = "&"
+			}
+			c.URL += sep + key + "=" + value
+			return
+		}
+		c.Headers = append(c.Headers, Header{Name: key, Value: value})
+	}
+}
+
+// value returns the pair's value as a string, whatever JSON type it was stored as
+func (kv PostmanKeyValue) value() string {
+	if len(kv.Value) == 0 {
+		return ""
+	}
+	return jsonScalar(kv.Value)
+}
@@ -0,0 +1,156 @@
+package requests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// statusLinePattern finds the start of a raw response following a request
+var statusLinePattern = regexp.MustCompile(`(?m)^HTTP/[0-9.]+ [0-9]{3}`)
+
+// rawMessage is a raw HTTP/1.x request or response split into its parts
+type rawMessage struct {
+	StartLine string
+	Headers   HeaderSlice
+	Body      []byte
+}
+
+// parseRawMessage splits raw HTTP text into start line, headers and body.
+// It is lenient on purpose: header order and casing are kept, bare \n line endings are accepted
+// and HTTP/2 start lines as written by proxies are not rejected.
+func parseRawMessage(data []byte) (rawMessage, error) {
+	data = bytes.TrimLeft(data, "\r\n")
+	if len(data) == 0 {
+		return rawMessage{}, fmt.Errorf("empty HTTP message")
+	}
+
+	head, body := data, []byte(nil)
+	if i := bytes.Index(data, []byte("\r\n\r\n")); i >= 0 {
+		head, body = data[:i], data[i+4:]
+	} else if i := bytes.Index(data, []byte("\n\n")); i >= 0 {
+		head, body = data[:i], data[i+2:]
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(head), "\r\n", "\n"), "\n")
+	msg := rawMessage{StartLine: strings.TrimSpace(lines[0]), Body: body}
+	for _, line := range lines[1:] {
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		msg.Headers = append(msg.Headers, Header{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+	}
+
+	if strings.EqualFold(msg.Headers.Get("Transfer-Encoding"), "chunked") {
+		if decoded, err := io.ReadAll(httputil.NewChunkedReader(bytes.NewReader(body))); err == nil {
+			msg.Body = decoded
+		}
+	}
+	return msg, nil
+}
+
+// requestLine returns the method and target of a raw request start line
+func (m rawMessage) requestLine() (string, string, error) {
+	parts := strings.Fields(m.StartLine)
+	if len(parts) < 2 {
+		return "", "", fmt.Errorf("invalid request line %q", m.StartLine)
+	}
+	return parts[0], parts[1], nil
+}
+
+// statusCode returns the status code of a raw response start line
+func (m rawMessage) statusCode() (int, error) {
+	parts := strings.Fields(m.StartLine)
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "HTTP/") {
+		return 0, fmt.Errorf("invalid status line %q", m.StartLine)
+	}
+	return strconv.Atoi(parts[1])
+}
+
+// Get returns the value of the first header with the given name, ignoring case
+func (hs HeaderSlice) Get(name string) string {
+	for _, h := range hs {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// parseRawExchange parses a raw request, optionally followed by its raw response, into a TempMyRequest without hashes.
+// Requests with an origin-form target are resolved against the Host header using scheme.
+func parseRawExchange(data []byte, scheme string) (TempMyRequest, error) {
+	reqMsg, err := parseRawMessage(data)
+	if err != nil {
+		return TempMyRequest{}, err
+	}
+
+	// Separate the request body from a response that follows it
+	var resData []byte
+	if length, err := strconv.Atoi(reqMsg.Headers.Get("Content-Length")); err == nil && length <= len(reqMsg.Body) {
+		reqMsg.Body, resData = reqMsg.Body[:length], reqMsg.Body[length:]
+	} else if loc := statusLinePattern.FindIndex(reqMsg.Body); loc != nil {
+		reqMsg.Body, resData = reqMsg.Body[:loc[0]], reqMsg.Body[loc[0]:]
+	}
+	reqMsg.Body = trimTrailingNewline(reqMsg.Body)
+
+	method, target, err := reqMsg.requestLine()
+	if err != nil {
+		return TempMyRequest{}, err
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return TempMyRequest{}, fmt.Errorf("invalid request target %q: %v", target, err)
+	}
+	if !u.IsAbs() {
+		host := reqMsg.Headers.Get("Host")
+		if host == "" {
+			return TempMyRequest{}, fmt.Errorf("request to %q has no Host header", target)
+		}
+		u, err = url.Parse(scheme + "://" + host + target)
+		if err != nil {
+			return TempMyRequest{}, fmt.Errorf("invalid request URL: %v", err)
+		}
+	}
+
+	my := TempMyRequest{
+		URL:        u.String(),
+		Method:     method,
+		Domain:     u.Hostname(),
+		ReqHeaders: reqMsg.Headers,
+		ReqBody:    string(reqMsg.Body),
+	}
+
+	if len(bytes.TrimSpace(resData)) > 0 {
+		resMsg, err := parseRawMessage(resData)
+		if err != nil {
+			return TempMyRequest{}, err
+		}
+		if my.ResStatus, err = resMsg.statusCode(); err != nil {
+			return TempMyRequest{}, err
+		}
+		if length, err := strconv.Atoi(resMsg.Headers.Get("Content-Length")); err == nil && length <= len(resMsg.Body) {
+			resMsg.Body = resMsg.Body[:length]
+		}
+		my.ResHeaders = resMsg.Headers
+		my.ResBody = string(trimTrailingNewline(resMsg.Body))
+		my.RespSize = len(my.ResBody)
+	}
+
+	return my, nil
+}
+
+// trimTrailingNewline removes the single line break exporters append after a body
+func trimTrailingNewline(body []byte) []byte {
+	if bytes.HasSuffix(body, []byte("\r\n")) {
+		return body[:len(body)-2]
+	}
+	return bytes.TrimSuffix(body, []byte("\n"))
+}
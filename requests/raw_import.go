@@ -0,0 +1,108 @@
+package requests
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// DefaultRawDelimiter separates messages of a raw HTTP file when no delimiter is chosen
+const DefaultRawDelimiter = "###"
+
+// zipMagic starts every zip archive
+var zipMagic = []byte("PK\x03\x04")
+
+func init() {
+	RegisterImporter(ImporterRegistration{
+		Format:     ImportFormatRaw,
+		Label:      "Raw HTTP messages (text file or zip)",
+		JobType:    JobTypeImportRaw,
+		Extensions: []string{".txt", ".http", ".req", ".zip"},
+		Importer:   ImporterFunc(StreamRawHTTP),
+	})
+}
+
+// StreamRawHTTP imports raw HTTP requests, each optionally followed by its raw response.
+// The input is either a text file with messages separated by opts.Delimiter lines,
+// or a zip archive whose files each hold one or more such messages.
+func StreamRawHTTP(r io.Reader, opts ImportOptions, fn func(TempMyRequest) error) error {
+	if opts.Scheme == "" {
+		opts.Scheme = "https"
+	}
+	if opts.Delimiter == "" {
+		opts.Delimiter = DefaultRawDelimiter
+	}
+
+	reader := bufio.NewReader(r)
+	sequence := 0
+	handle := func(message []byte) error {
+		sequence++
+		my, err := parseRawExchange(message, opts.Scheme)
+		if err != nil {
+			return fmt.Errorf("invalid message %d: %v", sequence, err)
+		}
+		my.Sequence = sequence
+		my.ApplyHashes(opts.HashFunc)
+		return fn(my)
+	}
+	isDelimiter := func(line []byte) bool {
+		return string(bytes.TrimSpace(line)) == opts.Delimiter
+	}
+
+	if magic, _ := reader.Peek(len(zipMagic)); !bytes.Equal(magic, zipMagic) {
+		return streamDelimited(reader, isDelimiter, handle)
+	}
+
+	// zip needs random access, so spool the archive to disk first
+	archive, err := spoolToTempFile(reader)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive)
+
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("invalid zip archive: %v", err)
+	}
+	defer zr.Close()
+
+	for _, file := range zr.File {
+		if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") || strings.HasPrefix(path.Base(file.Name), ".") {
+			continue
+		}
+		if err := streamZipFile(file, isDelimiter, handle); err != nil {
+			return fmt.Errorf("%s: %v", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// streamZipFile splits a single archived file into messages
+func streamZipFile(file *zip.File, isDelimiter func([]byte) bool, handle func([]byte) error) error {
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return streamDelimited(rc, isDelimiter, handle)
+}
+
+// spoolToTempFile copies r to a temp file and returns its path
+func spoolToTempFile(r io.Reader) (string, error) {
+	tmp, err := os.CreateTemp("", "requester-raw-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %v", err)
+	}
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to spool archive: %v", err)
+	}
+	return tmp.Name(), nil
+}
@@ -0,0 +1,95 @@
+package requests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ToTempMyRequest decodes the JSON header columns of a stored request, hashes are kept as stored
+func (my MyRequest) ToTempMyRequest() (TempMyRequest, error) {
+	reqHeaders, err := decodeStoredHeaders(my.ReqHeaders)
+	if err != nil {
+		return TempMyRequest{}, fmt.Errorf("invalid request headers: %v", err)
+	}
+	resHeaders, err := decodeStoredHeaders(my.ResHeaders)
+	if err != nil {
+		return TempMyRequest{}, fmt.Errorf("invalid response headers: %v", err)
+	}
+	return TempMyRequest{
+		Sequence:    my.Sequence,
+		URL:         my.URL,
+		Method:      my.Method,
+		Domain:      my.Domain,
+		ReqHeaders:  reqHeaders,
+		ReqBody:     my.ReqBody,
+		ResStatus:   my.ResStatus,
+		ResHeaders:  resHeaders,
+		ResBody:     my.ResBody,
+		RespSize:    my.RespSize,
+		LatencyMs:   my.LatencyMs,
+		RequestTime: my.RequestTime,
+		ReqHash1:    my.ReqHash1,
+		ReqHash:     my.ReqHash,
+		ResHash:     my.ResHash,
+		ResBodyHash: my.ResBodyHash,
+	}, nil
+}
+
+// NewHTTPRequest rebuilds an outgoing request from the method, URL, headers and body.
+// Pseudo headers and framing headers are left to the client, a Host header becomes the request host
+// and Accept-Encoding is dropped so the transport negotiates compression and stores a decoded body.
+func (temp TempMyRequest) NewHTTPRequest(ctx context.Context) (*http.Request, error) {
+	u, err := url.Parse(temp.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid request URL %q", temp.URL)
+	}
+
+	var body io.Reader
+	if temp.ReqBody != "" {
+		body = strings.NewReader(temp.ReqBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, temp.Method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %v", err)
+	}
+
+	for _, h := range temp.ReqHeaders {
+		switch {
+		case strings.HasPrefix(h.Name, ":"):
+		case strings.EqualFold(h.Name, "Content-Length"), strings.EqualFold(h.Name, "Transfer-Encoding"),
+			strings.EqualFold(h.Name, "Accept-Encoding"):
+		case strings.EqualFold(h.Name, "Host"):
+			req.Host = h.Value
+		default:
+			req.Header.Add(h.Name, h.Value)
+		}
+	}
+	return req, nil
+}
+
+// SetResponse records a received response on the request, response hashes still have to be applied
+func (temp *TempMyRequest) SetResponse(res *http.Response, body []byte, started time.Time, latency time.Duration) {
+	names := make([]string, 0, len(res.Header))
+	for name := range res.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	temp.ResHeaders = make(HeaderSlice, 0, len(names))
+	for _, name := range names {
+		for _, value := range res.Header[name] {
+			temp.ResHeaders = append(temp.ResHeaders, Header{Name: name, Value: value})
+		}
+	}
+	temp.ResStatus = res.StatusCode
+	temp.ResBody = string(body)
+	temp.RespSize = len(body)
+	temp.LatencyMs = latency.Milliseconds()
+	temp.RequestTime = started.UTC().Format(time.RFC3339Nano)
+}
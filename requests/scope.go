@@ -0,0 +1,230 @@
+package requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ScopeRule matches requests by scheme, host, port and path prefix.
+// Empty parts match anything.
+type ScopeRule struct {
+	Raw        string
+	Scheme     string
+	Host       string     // exact host, *.example.com for its subdomains or * for any host
+	Network    *net.IPNet // set instead of Host for IP addresses and CIDR ranges
+	Port       int
+	PathPrefix string
+}
+
+// Scope holds the include and exclude rules of a program.
+// A request is in scope when no exclude rule matches and an include rule matches,
+// or when there are no include rules at all.
+type Scope struct {
+	Include []ScopeRule
+	Exclude []ScopeRule
+}
+
+// ParseScope parses the scope text and domain list of a program.
+//
+// The scope has one rule per line or comma separated, lines starting with # are comments
+// and rules starting with - or ! exclude. A rule is a host (example.com, *.example.com),
+// an IP address or CIDR range (10.0.0.0/8), optionally with a port (example.com:8443)
+// and a path prefix (example.com/api), or a URL prefix (https://example.com/api/).
+// Domains are include rules, as a JSON array of strings or as plain text.
+func ParseScope(scope, domains string) (*Scope, error) {
+	s := &Scope{}
+	for _, entry := range splitScopeEntries(scope) {
+		exclude := false
+		if strings.HasPrefix(entry, "-") || strings.HasPrefix(entry, "!") {
+			exclude = true
+			entry = strings.TrimSpace(entry[1:])
+		}
+		rule, err := ParseScopeRule(entry)
+		if err != nil {
+			return nil, err
+		}
+		if exclude {
+			s.Exclude = append(s.Exclude, rule)
+		} else {
+			s.Include = append(s.Include, rule)
+		}
+	}
+
+	var domainList []string
+	if err := json.Unmarshal([]byte(domains), &domainList); err != nil {
+		domainList = splitScopeEntries(domains)
+	}
+	for _, domain := range domainList {
+		if domain = strings.TrimSpace(domain); domain == "" {
+			continue
+		}
+		rule, err := ParseScopeRule(domain)
+		if err != nil {
+			return nil, err
+		}
+		s.Include = append(s.Include, rule)
+	}
+	return s, nil
+}
+
+// splitScopeEntries splits scope text on lines and commas, dropping comments and blanks
+func splitScopeEntries(text string) []string {
+	var entries []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, entry := range strings.Split(line, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+// ParseScopeRule parses a single include or exclude rule without its - or ! prefix
+func ParseScopeRule(entry string) (ScopeRule, error) {
+	rule := ScopeRule{Raw: entry}
+	rest := entry
+
+	if scheme, after, found := strings.Cut(rest, "://"); found {
+		rule.Scheme = strings.ToLower(scheme)
+		if rule.Scheme != "http" && rule.Scheme != "https" {
+			return rule, fmt.Errorf("invalid scope rule %q: scheme must be http or https", entry)
+		}
+		rest = after
+	}
+
+	// A slash after an IP address may be a CIDR mask rather than a path
+	if ip, network, err := net.ParseCIDR(rest); err == nil && rule.Scheme == "" && ip != nil {
+		rule.Network = network
+		return rule, nil
+	}
+
+	hostPort, path, found := strings.Cut(rest, "/")
+	if found {
+		rule.PathPrefix = "/" + path
+	}
+
+	host := hostPort
+	if h, port, err := net.SplitHostPort(hostPort); err == nil {
+		host = h
+		if port != "*" {
+			if rule.Port, err = strconv.Atoi(port); err != nil || rule.Port <= 0 || rule.Port > 65535 {
+				return rule, fmt.Errorf("invalid scope rule %q: bad port %q", entry, port)
+			}
+		}
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if host == "" {
+		return rule, fmt.Errorf("invalid scope rule %q: missing host", entry)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		bits := 8 * len(ip.To16())
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		rule.Network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		return rule, nil
+	}
+	if strings.Contains(strings.TrimPrefix(host, "*."), "*") && host != "*" {
+		return rule, fmt.Errorf("invalid scope rule %q: only a leading *. wildcard is supported", entry)
+	}
+	rule.Host = host
+	return rule, nil
+}
+
+// Matches reports whether the rule matches a request. An empty scheme or a port of 0 means
+// the request's value is unknown, such as for endpoints, and is not checked.
+func (r ScopeRule) Matches(scheme, host string, port int, path string) bool {
+	if r.Scheme != "" && scheme != "" && r.Scheme != scheme {
+		return false
+	}
+	if r.Port != 0 && port != 0 && r.Port != port {
+		return false
+	}
+	if r.PathPrefix != "" && !strings.HasPrefix(path, r.PathPrefix) {
+		return false
+	}
+
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if r.Network != nil {
+		ip := net.ParseIP(host)
+		return ip != nil && r.Network.Contains(ip)
+	}
+	switch {
+	case r.Host == "*":
+		return true
+	case strings.HasPrefix(r.Host, "*."):
+		return strings.HasSuffix(host, r.Host[1:])
+	default:
+		return host == r.Host
+	}
+}
+
+// IsEmpty reports whether the scope has no rules, everything is in scope then
+func (s *Scope) IsEmpty() bool {
+	return s == nil || (len(s.Include) == 0 && len(s.Exclude) == 0)
+}
+
+// Contains reports whether a request with the given parts is in scope
+func (s *Scope) Contains(scheme, host string, port int, path string) bool {
+	if s.IsEmpty() {
+		return true
+	}
+	for _, rule := range s.Exclude {
+		if rule.Matches(scheme, host, port, path) {
+			return false
+		}
+	}
+	if len(s.Include) == 0 {
+		return true
+	}
+	for _, rule := range s.Include {
+		if rule.Matches(scheme, host, port, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsURL reports whether a request URL is in scope, unparsable URLs are out of scope
+func (s *Scope) ContainsURL(rawURL string) bool {
+	if s.IsEmpty() {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	port, _ := strconv.Atoi(u.Port())
+	if port == 0 {
+		switch strings.ToLower(u.Scheme) {
+		case "http":
+			port = 80
+		case "https":
+			port = 443
+		}
+	}
+	return s.Contains(strings.ToLower(u.Scheme), u.Hostname(), port, u.Path)
+}
+
+// ContainsEndpoint reports whether an endpoint is in scope, its scheme and port are unknown
+func (s *Scope) ContainsEndpoint(endpoint Endpoint) bool {
+	host := endpoint.Domain
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return s.Contains("", host, 0, endpoint.URI)
+}
+
+// ParseScope parses the scope rules of the program
+func (p Program) ParseScope() (*Scope, error) {
+	return ParseScope(p.Scope, p.Domains)
+}
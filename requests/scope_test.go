@@ -0,0 +1,162 @@
+package requests
+
+import (
+	"testing"
+)
+
+func TestParseScopeRule(t *testing.T) {
+	tests := []struct {
+		entry   string
+		rule    ScopeRule
+		network string
+	}{
+		{entry: "Example.com", rule: ScopeRule{Host: "example.com"}},
+		{entry: "*.example.com", rule: ScopeRule{Host: "*.example.com"}},
+		{entry: "*", rule: ScopeRule{Host: "*"}},
+		{entry: "example.com:8443", rule: ScopeRule{Host: "example.com", Port: 8443}},
+		{entry: "example.com:*", rule: ScopeRule{Host: "example.com"}},
+		{entry: "example.com/api", rule: ScopeRule{Host: "example.com", PathPrefix: "/api"}},
+		{entry: "HTTPS://example.com:443/api/", rule: ScopeRule{Scheme: "https", Host: "example.com", Port: 443, PathPrefix: "/api/"}},
+		{entry: "10.0.0.0/8", network: "10.0.0.0/8"},
+		{entry: "192.168.1.5", network: "192.168.1.5/32"},
+		{entry: "192.168.1.5:8080/admin", rule: ScopeRule{Port: 8080, PathPrefix: "/admin"}, network: "192.168.1.5/32"},
+		{entry: "[::1]:8080", rule: ScopeRule{Port: 8080}, network: "::1/128"},
+		{entry: "2001:db8::/32", network: "2001:db8::/32"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			rule, err := ParseScopeRule(tt.entry)
+			if err != nil {
+				t.Fatalf("ParseScopeRule: %v", err)
+			}
+			network := ""
+			if rule.Network != nil {
+				network = rule.Network.String()
+			}
+			if network != tt.network {
+				t.Errorf("network = %q, want %q", network, tt.network)
+			}
+			if rule.Raw != tt.entry || rule.Scheme != tt.rule.Scheme || rule.Host != tt.rule.Host ||
+				rule.Port != tt.rule.Port || rule.PathPrefix != tt.rule.PathPrefix {
+				t.Errorf("rule = %+v, want %+v", rule, tt.rule)
+			}
+		})
+	}
+}
+
+func TestParseScopeRuleInvalid(t *testing.T) {
+	for _, entry := range []string{"ftp://example.com", "example.com:0", "example.com:70000", "example.com:abc", "/api", "api.*.example.com", "exa*mple.com"} {
+		if _, err := ParseScopeRule(entry); err == nil {
+			t.Errorf("ParseScopeRule(%q) succeeded, want an error", entry)
+		}
+	}
+}
+
+func TestScopeRuleMatches(t *testing.T) {
+	tests := []struct {
+		rule   string
+		scheme string
+		host   string
+		port   int
+		path   string
+		want   bool
+	}{
+		{rule: "example.com", scheme: "https", host: "EXAMPLE.com", port: 443, path: "/", want: true},
+		{rule: "example.com", scheme: "https", host: "api.example.com", port: 443, path: "/", want: false},
+		{rule: "*.example.com", scheme: "https", host: "api.example.com", port: 443, path: "/", want: true},
+		{rule: "*.example.com", scheme: "https", host: "a.b.example.com", port: 443, path: "/", want: true},
+		{rule: "*.example.com", scheme: "https", host: "example.com", port: 443, path: "/", want: false},
+		{rule: "*.example.com", scheme: "https", host: "badexample.com", port: 443, path: "/", want: false},
+		{rule: "*", scheme: "http", host: "anything.test", port: 80, path: "/", want: true},
+		{rule: "example.com:8443", scheme: "https", host: "example.com", port: 8443, path: "/", want: true},
+		{rule: "example.com:8443", scheme: "https", host: "example.com", port: 443, path: "/", want: false},
+		{rule: "example.com:8443", host: "example.com", path: "/", want: true},
+		{rule: "https://example.com", scheme: "http", host: "example.com", port: 80, path: "/", want: false},
+		{rule: "https://example.com", host: "example.com", path: "/", want: true},
+		{rule: "example.com/api", scheme: "https", host: "example.com", port: 443, path: "/api/users", want: true},
+		{rule: "example.com/api", scheme: "https", host: "example.com", port: 443, path: "/admin", want: false},
+		{rule: "10.0.0.0/8", scheme: "http", host: "10.1.2.3", port: 80, path: "/", want: true},
+		{rule: "10.0.0.0/8", scheme: "http", host: "11.1.2.3", port: 80, path: "/", want: false},
+		{rule: "10.0.0.0/8", scheme: "http", host: "ten.example.com", port: 80, path: "/", want: false},
+		{rule: "192.168.1.5", scheme: "http", host: "192.168.1.5", port: 80, path: "/", want: true},
+		{rule: "192.168.1.5", scheme: "http", host: "192.168.1.6", port: 80, path: "/", want: false},
+		{rule: "2001:db8::/32", scheme: "https", host: "[2001:db8::1]", port: 443, path: "/", want: true},
+	}
+
+	for _, tt := range tests {
+		rule, err := ParseScopeRule(tt.rule)
+		if err != nil {
+			t.Fatalf("ParseScopeRule(%q): %v", tt.rule, err)
+		}
+		if got := rule.Matches(tt.scheme, tt.host, tt.port, tt.path); got != tt.want {
+			t.Errorf("%q.Matches(%q, %q, %d, %q) = %v, want %v", tt.rule, tt.scheme, tt.host, tt.port, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseScope(t *testing.T) {
+	scope, err := ParseScope("# in scope\n*.example.com, example.com\n-admin.example.com\n!*.example.com/internal\n", `["10.0.0.0/8"]`)
+	if err != nil {
+		t.Fatalf("ParseScope: %v", err)
+	}
+	if len(scope.Include) != 3 || len(scope.Exclude) != 2 {
+		t.Fatalf("got %d include and %d exclude rules, want 3 and 2", len(scope.Include), len(scope.Exclude))
+	}
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: "https://example.com/", want: true},
+		{url: "https://api.example.com/v1", want: true},
+		{url: "https://admin.example.com/", want: false},
+		{url: "https://api.example.com/internal/keys", want: false},
+		{url: "http://10.0.0.1:8080/", want: true},
+		{url: "https://other.test/", want: false},
+		{url: "not a url", want: false},
+	}
+	for _, tt := range tests {
+		if got := scope.ContainsURL(tt.url); got != tt.want {
+			t.Errorf("ContainsURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+
+	if _, err := ParseScope("example.com\n-ftp://example.com", ""); err == nil {
+		t.Errorf("ParseScope succeeded with an invalid rule, want an error")
+	}
+}
+
+func TestParseScopePlainDomains(t *testing.T) {
+	scope, err := ParseScope("", "example.com\napi.test:8443")
+	if err != nil {
+		t.Fatalf("ParseScope: %v", err)
+	}
+	if len(scope.Include) != 2 || len(scope.Exclude) != 0 {
+		t.Fatalf("got %d include and %d exclude rules, want 2 and 0", len(scope.Include), len(scope.Exclude))
+	}
+	if !scope.ContainsURL("https://api.test:8443/") || scope.ContainsURL("https://api.test/") {
+		t.Errorf("the port of a plain domain is not checked")
+	}
+}
+
+func TestEmptyScopeContainsEverything(t *testing.T) {
+	scope, err := ParseScope("", "")
+	if err != nil {
+		t.Fatalf("ParseScope: %v", err)
+	}
+	if !scope.IsEmpty() || !scope.ContainsURL("not a url") || !scope.ContainsEndpoint(Endpoint{Domain: "example.com", URI: "/"}) {
+		t.Errorf("an empty scope should contain everything")
+	}
+
+	scope, err = ParseScope("-example.com", "")
+	if err != nil {
+		t.Fatalf("ParseScope: %v", err)
+	}
+	if scope.ContainsURL("https://example.com/") || !scope.ContainsURL("https://other.test/") {
+		t.Errorf("a scope with only exclude rules should contain everything else")
+	}
+	if scope.ContainsEndpoint(Endpoint{Domain: "example.com:8443", URI: "/"}) {
+		t.Errorf("ContainsEndpoint should ignore the port of the endpoint domain")
+	}
+}
@@ -0,0 +1,363 @@
+package requests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SnippetFormat is a language or tool a stored request can be rendered for
+type SnippetFormat string
+
+const (
+	SnippetCurl       SnippetFormat = "curl"
+	SnippetPython     SnippetFormat = "python"
+	SnippetGo         SnippetFormat = "go"
+	SnippetFetch      SnippetFormat = "fetch"
+	SnippetPowerShell SnippetFormat = "powershell"
+	SnippetRaw        SnippetFormat = "raw"
+)
+
+// SnippetFormats lists the supported formats in the order they are offered
+var SnippetFormats = []SnippetFormat{SnippetCurl, SnippetPython, SnippetGo, SnippetFetch, SnippetPowerShell, SnippetRaw}
+
+// Label returns the human readable name of the format
+func (f SnippetFormat) Label() string {
+	switch f {
+	case SnippetCurl:
+		return "cURL"
+	case SnippetPython:
+		return "Python requests"
+	case SnippetGo:
+		return "Go net/http"
+	case SnippetFetch:
+		return "JavaScript fetch"
+	case SnippetPowerShell:
+		return "PowerShell"
+	case SnippetRaw:
+		return "Raw HTTP/1.1"
+	default:
+		return string(f)
+	}
+}
+
+// SnippetOptions controls which parts of a request end up in a snippet
+type SnippetOptions struct {
+	OmitHeaders []string // header names to drop, such as the import job's ignored headers
+}
+
+// snippetRequest is a stored request prepared for rendering
+type snippetRequest struct {
+	Method  string
+	URL     *url.URL
+	Headers HeaderSlice // without pseudo headers and omitted headers
+	Body    string
+}
+
+// RenderSnippet renders req as code that sends the same request
+func RenderSnippet(req MyRequest, format SnippetFormat, opts SnippetOptions) (string, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid request URL: %v", err)
+	}
+	headers, err := decodeStoredHeaders(req.ReqHeaders)
+	if err != nil {
+		return "", fmt.Errorf("invalid request headers: %v", err)
+	}
+
+	sr := snippetRequest{Method: req.Method, URL: u, Body: req.ReqBody}
+	if sr.Method == "" {
+		sr.Method = "GET"
+	}
+	for _, h := range headers {
+		if strings.HasPrefix(h.Name, ":") || containsFold(opts.OmitHeaders, h.Name) {
+			continue
+		}
+		sr.Headers = append(sr.Headers, h)
+	}
+
+	switch format {
+	case SnippetCurl:
+		return sr.curl(), nil
+	case SnippetPython:
+		return sr.python(), nil
+	case SnippetGo:
+		return sr.golang(), nil
+	case SnippetFetch:
+		return sr.fetch(), nil
+	case SnippetPowerShell:
+		return sr.powershell(), nil
+	case SnippetRaw:
+		return sr.raw(), nil
+	default:
+		return "", fmt.Errorf("unsupported snippet format %q", format)
+	}
+}
+
+// clientHeaders returns the headers an HTTP client should be told to send.
+// Content-Length and Connection are managed by the client, Host only matters when it differs from the URL.
+func (sr snippetRequest) clientHeaders() HeaderSlice {
+	var out HeaderSlice
+	for _, h := range sr.Headers {
+		switch {
+		case strings.EqualFold(h.Name, "Content-Length"), strings.EqualFold(h.Name, "Connection"):
+			continue
+		case strings.EqualFold(h.Name, "Host") && strings.EqualFold(h.Value, sr.URL.Host):
+			continue
+		}
+		out = append(out, h)
+	}
+	return out
+}
+
+// mergedHeaders folds repeated headers into one value for clients taking a map of headers
+func (sr snippetRequest) mergedHeaders() HeaderSlice {
+	var out HeaderSlice
+	for _, h := range sr.clientHeaders() {
+		merged := false
+		for i := range out {
+			if strings.EqualFold(out[i].Name, h.Name) {
+				sep := ", "
+				if strings.EqualFold(h.Name, "Cookie") {
+					sep = "; "
+				}
+				out[i].Value += sep + h.Value
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// curl renders a POSIX shell curl command
+func (sr snippetRequest) curl() string {
+	var b strings.Builder
+	b.WriteString("curl")
+	switch {
+	case sr.Method == "GET" && sr.Body == "":
+	case sr.Method == "HEAD":
+		b.WriteString(" --head")
+	default:
+		// --data-raw alone makes curl send a POST, so any other method with a body is set explicitly
+		b.WriteString(" -X " + shellWord(sr.Method))
+	}
+	b.WriteString(" " + shellQuote(sr.URL.String()))
+	for _, h := range sr.clientHeaders() {
+		b.WriteString(" \\\n  -H " + shellQuote(h.Name+": "+h.Value))
+	}
+	if sr.Body != "" {
+		b.WriteString(" \\\n  --data-raw " + shellQuote(sr.Body))
+	}
+	return b.String()
+}
+
+// python renders a script using the requests library
+func (sr snippetRequest) python() string {
+	var b strings.Builder
+	b.WriteString("import requests\n\n")
+	fmt.Fprintf(&b, "url = %s\n", strconv.Quote(sr.URL.String()))
+	args := ""
+	if headers := sr.mergedHeaders(); len(headers) > 0 {
+		b.WriteString("headers = {\n")
+		for _, h := range headers {
+			fmt.Fprintf(&b, "    %s: %s,\n", strconv.Quote(h.Name), strconv.Quote(h.Value))
+		}
+		b.WriteString("}\n")
+		args += ", headers=headers"
+	}
+	if sr.Body != "" {
+		fmt.Fprintf(&b, "data = %s\n", strconv.Quote(sr.Body))
+		args += ", data=data"
+	}
+	fmt.Fprintf(&b, "\nresponse = requests.request(%s, url%s)\n", strconv.Quote(sr.Method), args)
+	b.WriteString("print(response.status_code)\nprint(response.text)\n")
+	return b.String()
+}
+
+// golang renders a program using net/http
+func (sr snippetRequest) golang() string {
+	var b strings.Builder
+	b.WriteString("package main\n\nimport (\n\t\"fmt\"\n\t\"io\"\n\t\"net/http\"\n")
+	if sr.Body != "" {
+		b.WriteString("\t\"strings\"\n")
+	}
+	b.WriteString(")\n\nfunc main() {\n")
+	body := "nil"
+	if sr.Body != "" {
+		fmt.Fprintf(&b, "\tbody := strings.NewReader(%s)\n", goQuote(sr.Body))
+		body = "body"
+	}
+	fmt.Fprintf(&b, "\treq, err := http.NewRequest(%s, %s, %s)\n", strconv.Quote(sr.Method), strconv.Quote(sr.URL.String()), body)
+	b.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	for _, h := range sr.clientHeaders() {
+		if strings.EqualFold(h.Name, "Host") {
+			// net/http ignores a Host header, the request field sets it
+			fmt.Fprintf(&b, "\treq.Host = %s\n", strconv.Quote(h.Value))
+			continue
+		}
+		fmt.Fprintf(&b, "\treq.Header.Add(%s, %s)\n", strconv.Quote(h.Name), strconv.Quote(h.Value))
+	}
+	b.WriteString("\n\tres, err := http.DefaultClient.Do(req)\n\tif err != nil {\n\t\tpanic(err)\n\t}\n\tdefer res.Body.Close()\n\n")
+	b.WriteString("\tresBody, err := io.ReadAll(res.Body)\n\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	b.WriteString("\tfmt.Println(res.Status)\n\tfmt.Println(string(resBody))\n}\n")
+	return b.String()
+}
+
+// fetch renders a JavaScript fetch call
+func (sr snippetRequest) fetch() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "const response = await fetch(%s, {\n", jsQuote(sr.URL.String()))
+	fmt.Fprintf(&b, "  method: %s", jsQuote(sr.Method))
+	if headers := sr.mergedHeaders(); len(headers) > 0 {
+		b.WriteString(",\n  headers: {\n")
+		for i, h := range headers {
+			fmt.Fprintf(&b, "    %s: %s", jsQuote(h.Name), jsQuote(h.Value))
+			if i < len(headers)-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("  }")
+	}
+	if sr.Body != "" {
+		fmt.Fprintf(&b, ",\n  body: %s", jsQuote(sr.Body))
+	}
+	b.WriteString("\n});\n\nconsole.log(response.status);\nconsole.log(await response.text());\n")
+	return b.String()
+}
+
+// powershell renders an Invoke-WebRequest call.
+// Content-Type and User-Agent go through their own parameters because Windows PowerShell rejects them in -Headers.
+func (sr snippetRequest) powershell() string {
+	var b strings.Builder
+	var contentType, userAgent string
+	var headers HeaderSlice
+	for _, h := range sr.mergedHeaders() {
+		switch {
+		case strings.EqualFold(h.Name, "Content-Type"):
+			contentType = h.Value
+		case strings.EqualFold(h.Name, "User-Agent"):
+			userAgent = h.Value
+		default:
+			headers = append(headers, h)
+		}
+	}
+
+	if len(headers) > 0 {
+		b.WriteString("$headers = @{\n")
+		for _, h := range headers {
+			fmt.Fprintf(&b, "    %s = %s\n", psQuote(h.Name), psQuote(h.Value))
+		}
+		b.WriteString("}\n")
+	}
+	if sr.Body != "" {
+		fmt.Fprintf(&b, "$body = %s\n", psQuote(sr.Body))
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "$response = Invoke-WebRequest -UseBasicParsing -Uri %s -Method %s", psQuote(sr.URL.String()), psWord(sr.Method))
+	if len(headers) > 0 {
+		b.WriteString(" -Headers $headers")
+	}
+	if contentType != "" {
+		b.WriteString(" -ContentType " + psQuote(contentType))
+	}
+	if userAgent != "" {
+		b.WriteString(" -UserAgent " + psQuote(userAgent))
+	}
+	if sr.Body != "" {
+		b.WriteString(" -Body $body")
+	}
+	b.WriteString("\n$response.StatusCode\n$response.Content\n")
+	return b.String()
+}
+
+// raw renders the request as HTTP/1.1 text with CRLF line endings
+func (sr snippetRequest) raw() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s HTTP/1.1\r\n", sr.Method, sr.URL.RequestURI())
+	if sr.Headers.Get("Host") == "" {
+		fmt.Fprintf(&b, "Host: %s\r\n", sr.URL.Host)
+	}
+	for _, h := range sr.Headers {
+		fmt.Fprintf(&b, "%s: %s\r\n", h.Name, h.Value)
+	}
+	b.WriteString("\r\n")
+	b.WriteString(sr.Body)
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellWord leaves a plain word such as a method name as it is and quotes anything else for a POSIX shell
+func shellWord(s string) string {
+	if isPlainWord(s) {
+		return s
+	}
+	return shellQuote(s)
+}
+
+// goQuote quotes s as a Go string literal, preferring a raw literal for multi-line text
+func goQuote(s string) string {
+	if strings.Contains(s, "\n") && !strings.ContainsAny(s, "`\r") {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+// jsQuote quotes s as a JavaScript string literal
+func jsQuote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// psQuote quotes s as a PowerShell verbatim string
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// psWord leaves a plain word such as a method name as it is and quotes anything else for PowerShell
+func psWord(s string) string {
+	if isPlainWord(s) {
+		return s
+	}
+	return psQuote(s)
+}
+
+// isPlainWord reports whether s only has letters, digits, '-' and '_', so it needs no quoting in any shell
+func isPlainWord(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold reports whether names contains name, ignoring case
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(strings.TrimSpace(n), name) {
+			return true
+		}
+	}
+	return false
+}
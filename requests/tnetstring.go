@@ -0,0 +1,136 @@
+package requests
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxTNetStringLength guards against reading absurd lengths from a corrupt file: a flow holds a request and a
+// response body of up to maxDecodedBodySize each, plus its headers and metadata
+const maxTNetStringLength = 2*maxDecodedBodySize + 1<<20
+
+// readTNetString reads the next tnetstring value from r.
+// Values decode to []byte, string, int64, float64, bool, nil, []interface{} or map[string]interface{}.
+func readTNetString(r *bufio.Reader) (interface{}, error) {
+	lengthText, err := r.ReadString(':')
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(lengthText[:len(lengthText)-1])
+	if err != nil || length < 0 || length > maxTNetStringLength {
+		return nil, fmt.Errorf("invalid tnetstring length %q", lengthText)
+	}
+
+	// Grow the buffer as data arrives instead of trusting the length, a truncated file then costs only its size
+	var buf bytes.Buffer
+	if n, err := io.CopyN(&buf, r, int64(length)+1); err != nil {
+		return nil, fmt.Errorf("truncated tnetstring: read %d of %d bytes: %v", n, length+1, err)
+	}
+	data := buf.Bytes()
+	return parseTNetStringPayload(data[:length], data[length])
+}
+
+// parseTNetString parses the first tnetstring in data and returns the remaining bytes
+func parseTNetString(data []byte) (interface{}, []byte, error) {
+	colon := -1
+	for i := 0; i < len(data) && i < 12; i++ {
+		if data[i] == ':' {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return nil, nil, fmt.Errorf("invalid tnetstring: missing length")
+	}
+	length, err := strconv.Atoi(string(data[:colon]))
+	if err != nil || length < 0 || colon+1+length >= len(data) {
+		return nil, nil, fmt.Errorf("invalid tnetstring length %q", data[:colon])
+	}
+
+	payload := data[colon+1 : colon+1+length]
+	value, err := parseTNetStringPayload(payload, data[colon+1+length])
+	return value, data[colon+2+length:], err
+}
+
+// parseTNetStringPayload decodes a payload according to its type tag
+func parseTNetStringPayload(payload []byte, tag byte) (interface{}, error) {
+	switch tag {
+	case ',':
+		return payload, nil
+	case ';':
+		return string(payload), nil
+	case '#':
+		return strconv.ParseInt(string(payload), 10, 64)
+	case '^':
+		return strconv.ParseFloat(string(payload), 64)
+	case '!':
+		return string(payload) == "true", nil
+	case '~':
+		return nil, nil
+	case ']':
+		list := []interface{}{}
+		for len(payload) > 0 {
+			value, rest, err := parseTNetString(payload)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+			payload = rest
+		}
+		return list, nil
+	case '}':
+		dict := map[string]interface{}{}
+		for len(payload) > 0 {
+			key, rest, err := parseTNetString(payload)
+			if err != nil {
+				return nil, err
+			}
+			value, rest, err := parseTNetString(rest)
+			if err != nil {
+				return nil, err
+			}
+			dict[tnString(key)] = value
+			payload = rest
+		}
+		return dict, nil
+	default:
+		return nil, fmt.Errorf("unknown tnetstring type %q", tag)
+	}
+}
+
+// tnString converts a decoded bytes or string value to a string
+func tnString(v interface{}) string {
+	switch s := v.(type) {
+	case []byte:
+		return string(s)
+	case string:
+		return s
+	case int64:
+		return strconv.FormatInt(s, 10)
+	default:
+		return ""
+	}
+}
+
+// tnFloat converts a decoded number to a float64
+func tnFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int64:
+		return float64(n)
+	default:
+		return 0
+	}
+}
+
+// tnDict returns a decoded dictionary, or an empty one
+func tnDict(v interface{}) map[string]interface{} {
+	if d, ok := v.(map[string]interface{}); ok {
+		return d
+	}
+	return map[string]interface{}{}
+}
@@ -0,0 +1,71 @@
+package requests
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+func init() {
+	RegisterImporter(ImporterRegistration{
+		Format:     ImportFormatZAP,
+		Label:      "OWASP ZAP messages (\"Export Messages to File\")",
+		JobType:    JobTypeImportZAP,
+		Extensions: []string{".txt", ".zap"},
+		Importer:   ImporterFunc(StreamZAPMessages),
+	})
+}
+
+// zapSeparatorPattern matches the "==== 12 ==========" line ZAP writes before each message
+var zapSeparatorPattern = regexp.MustCompile(`^==== [0-9]+ ==========\r?\n?$`)
+
+// StreamZAPMessages walks an OWASP ZAP message export one message at a time,
+// calling fn for each converted request
+func StreamZAPMessages(r io.Reader, opts ImportOptions, fn func(TempMyRequest) error) error {
+	sequence := 0
+	return streamDelimited(r, zapSeparatorPattern.Match, func(message []byte) error {
+		sequence++
+		my, err := parseRawExchange(message, "http")
+		if err != nil {
+			return fmt.Errorf("invalid message %d: %v", sequence, err)
+		}
+		my.Sequence = sequence
+		my.ApplyHashes(opts.HashFunc)
+		return fn(my)
+	})
+}
+
+// streamDelimited splits r into messages on separator lines, calling fn for every non-blank message
+func streamDelimited(r io.Reader, isSeparator func(line []byte) bool, fn func(message []byte) error) error {
+	reader := bufio.NewReader(r)
+	var message bytes.Buffer
+
+	flush := func() error {
+		defer message.Reset()
+		if len(bytes.TrimSpace(message.Bytes())) == 0 {
+			return nil
+		}
+		return fn(message.Bytes())
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if isSeparator(line) {
+				if ferr := flush(); ferr != nil {
+					return ferr
+				}
+			} else {
+				message.Write(line)
+			}
+		}
+		if err == io.EOF {
+			return flush()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
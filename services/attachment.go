@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"linn221/Requester/requests"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxAttachmentSize is the largest file accepted as an attachment
+const MaxAttachmentSize = 64 << 20
+
+// AttachmentService stores the uploaded files of programs, endpoints and requests in a directory,
+// keeping their metadata in the database
+type AttachmentService struct {
+	db  Database
+	dir string
+}
+
+// NewAttachmentService creates a new AttachmentService storing files under dir
+func NewAttachmentService(db Database, dir string) *AttachmentService {
+	return &AttachmentService{db: db, dir: dir}
+}
+
+// AttachmentUpload is an uploaded file to attach to a program, endpoint or request
+type AttachmentUpload struct {
+	ReferenceType requests.ReferenceType
+	ReferenceID   uint
+	Filename      string
+	File          File
+}
+
+// CreateAttachment stores the uploaded file under a random name and records it
+func (s *AttachmentService) CreateAttachment(ctx context.Context, upload AttachmentUpload) (*requests.Attachment, error) {
+	if err := checkReference(ctx, s.db, upload.ReferenceType, upload.ReferenceID); err != nil {
+		return nil, err
+	}
+	filename := strings.TrimSpace(filepath.Base(filepath.Clean("/" + upload.Filename)))
+	if filename == "" || filename == "/" || filename == "." {
+		filename = "attachment"
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create attachment directory: %v", err)
+	}
+	storedName, err := randomStoredName()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(s.dir, storedName)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attachment file: %v", err)
+	}
+	size, err := io.Copy(file, io.LimitReader(upload.File, MaxAttachmentSize+1))
+	file.Close()
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to store attachment: %v", err)
+	}
+	if size > MaxAttachmentSize {
+		os.Remove(path)
+		return nil, fmt.Errorf("%w: attachments are limited to %d MB", ErrInvalid, MaxAttachmentSize>>20)
+	}
+
+	attachment := &requests.Attachment{
+		ReferenceType: upload.ReferenceType,
+		ReferenceID:   upload.ReferenceID,
+		Filename:      filename,
+		StoredName:    storedName,
+		Size:          size,
+	}
+	if err := s.db.WithContext(ctx).Create(attachment).Error(); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to create attachment: %v", err)
+	}
+	return attachment, nil
+}
+
+// GetAttachmentByID fetches a single attachment by ID
+func (s *AttachmentService) GetAttachmentByID(ctx context.Context, id uint) (*requests.Attachment, error) {
+	var attachment requests.Attachment
+	if err := s.db.WithContext(ctx).First(&attachment, id).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch attachment %d: %w", id, err)
+	}
+	return &attachment, nil
+}
+
+// GetAttachments fetches the attachments of a program, endpoint or request, oldest first
+func (s *AttachmentService) GetAttachments(ctx context.Context, referenceType requests.ReferenceType, referenceID uint) ([]requests.Attachment, error) {
+	var attachments []requests.Attachment
+	if err := s.db.WithContext(ctx).Where("reference_type = ? AND reference_id = ?", referenceType, referenceID).Order("created_at ASC, id ASC").Find(&attachments).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch attachments of %s %d: %v", referenceType, referenceID, err)
+	}
+	return attachments, nil
+}
+
+// OpenAttachment opens the stored file of an attachment, the caller closes it
+func (s *AttachmentService) OpenAttachment(ctx context.Context, id uint) (*requests.Attachment, *os.File, error) {
+	attachment, err := s.GetAttachmentByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(filepath.Join(s.dir, attachment.StoredName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open attachment %d: %v", id, err)
+	}
+	return attachment, file, nil
+}
+
+// DeleteAttachment deletes an attachment and its stored file
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, id uint) error {
+	attachment, err := s.GetAttachmentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.deleteAttachments(ctx, []requests.Attachment{*attachment})
+}
+
+// DeleteAttachmentsOf deletes the attachments of a program, endpoint or request being deleted
+func (s *AttachmentService) DeleteAttachmentsOf(ctx context.Context, referenceType requests.ReferenceType, referenceID uint) error {
+	attachments, err := s.GetAttachments(ctx, referenceType, referenceID)
+	if err != nil {
+		return err
+	}
+	return s.deleteAttachments(ctx, attachments)
+}
+
+// deleteAttachments deletes the records of attachments, then their stored files
+func (s *AttachmentService) deleteAttachments(ctx context.Context, attachments []requests.Attachment) error {
+	for _, attachment := range attachments {
+		if err := s.db.WithContext(ctx).Model(&requests.Attachment{}).Delete(&requests.Attachment{}, attachment.ID).Error(); err != nil {
+			return fmt.Errorf("failed to delete attachment %d: %v", attachment.ID, err)
+		}
+		if err := os.Remove(filepath.Join(s.dir, attachment.StoredName)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove file of attachment %d: %v", attachment.ID, err)
+		}
+	}
+	return nil
+}
+
+// removeFiles removes the stored files of attachments whose records were deleted, a file that fails to go is logged and left behind
+func (s *AttachmentService) removeFiles(attachments []requests.Attachment) {
+	for _, attachment := range attachments {
+		if err := os.Remove(filepath.Join(s.dir, attachment.StoredName)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("[ATTACHMENT] failed to remove file of attachment %d: %v", attachment.ID, err)
+		}
+	}
+}
+
+// randomStoredName returns a random file name for a stored attachment
+func randomStoredName() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate attachment name: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
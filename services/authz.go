@@ -0,0 +1,407 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"linn221/Requester/requests"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAuthzConcurrency is the number of variants in flight when the form leaves it empty
+	DefaultAuthzConcurrency = 5
+	// MaxAuthzVariants caps the requests sent by a single authorization run
+	MaxAuthzVariants = 5000
+)
+
+// AuthzService resends captured requests as other identities of their program
+type AuthzService struct {
+	db     Database
+	client *http.Client
+}
+
+// NewAuthzService creates a new AuthzService sending requests with client
+func NewAuthzService(db Database, client *http.Client) *AuthzService {
+	return &AuthzService{
+		db:     db,
+		client: client,
+	}
+}
+
+// AuthzRequest describes an authorization run over an import job or a single endpoint.
+// Every captured request of the source is resent once per identity.
+type AuthzRequest struct {
+	ImportJobID uint // set one of ImportJobID and EndpointID
+	EndpointID  uint
+	IdentityIDs []uint
+	// BasePrivilege is the privilege of the session in the captured requests.
+	// Identities below it are flagged when they get the original response, nil ranks it above every identity.
+	BasePrivilege *int
+	Concurrency   int
+}
+
+// authzTask is one captured request sent as one identity
+type authzTask struct {
+	original requests.MyRequest
+	identity requests.Identity
+	flag     bool // the identity has less privilege than the captured session
+}
+
+// Start validates an authorization run, records it as a job and sends its variants in the background
+func (s *AuthzService) Start(ctx context.Context, req AuthzRequest) (*requests.ImportJob, error) {
+	if len(req.IdentityIDs) == 0 {
+		return nil, fmt.Errorf("select at least one identity")
+	}
+	if req.Concurrency <= 0 {
+		req.Concurrency = DefaultAuthzConcurrency
+	}
+	if req.Concurrency > MaxFuzzConcurrency {
+		req.Concurrency = MaxFuzzConcurrency
+	}
+
+	programID, title, originals, err := s.findOriginals(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(originals) == 0 {
+		return nil, fmt.Errorf("no captured requests to test")
+	}
+
+	var identities []requests.Identity
+	if err := s.db.WithContext(ctx).Where("id IN ? AND program_id = ?", req.IdentityIDs, programID).Order("privilege DESC, name ASC").Find(&identities).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch identities: %v", err)
+	}
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("no identities of the program selected")
+	}
+	if total := len(originals) * len(identities); total > MaxAuthzVariants {
+		return nil, fmt.Errorf("%d requests exceed the limit of %d", total, MaxAuthzVariants)
+	}
+
+	job := requests.ImportJob{
+		ProgramID: &programID,
+		Title:     title,
+		JobType:   requests.JobTypeAuthz,
+		Status:    requests.ImportJobStatusRunning,
+		StartedAt: time.Now().Unix(),
+	}
+	if err := s.db.WithContext(ctx).Create(&job).Error(); err != nil {
+		return nil, fmt.Errorf("failed to create authorization job: %v", err)
+	}
+
+	var tasks []authzTask
+	for _, original := range originals {
+		for _, identity := range identities {
+			flag := req.BasePrivilege == nil || identity.Privilege < *req.BasePrivilege
+			tasks = append(tasks, authzTask{original: original, identity: identity, flag: flag})
+		}
+	}
+
+	go s.run(context.Background(), job.ID, programID, tasks, req.Concurrency)
+	return &job, nil
+}
+
+// findOriginals fetches the captured requests of the run's source and the program they belong to.
+// Replays and requests sent by fuzz or authorization runs are left out.
+func (s *AuthzService) findOriginals(ctx context.Context, req AuthzRequest) (uint, string, []requests.MyRequest, error) {
+	var programID *uint
+	var title string
+	query := s.db.WithContext(ctx).Where("replay_of_id IS NULL")
+
+	switch {
+	case req.ImportJobID != 0:
+		var job requests.ImportJob
+		if err := s.db.WithContext(ctx).First(&job, req.ImportJobID).Error(); err != nil {
+			return 0, "", nil, fmt.Errorf("failed to fetch import job %d: %v", req.ImportJobID, err)
+		}
+		if slices.Contains(generatedJobTypes, job.JobType) {
+			return 0, "", nil, fmt.Errorf("job %d did not import captured requests", job.ID)
+		}
+		programID = job.ProgramID
+		title = "Authorization: " + job.Title
+		query = query.Where("import_job_id = ?", job.ID)
+	case req.EndpointID != 0:
+		var endpoint requests.Endpoint
+		if err := s.db.WithContext(ctx).First(&endpoint, req.EndpointID).Error(); err != nil {
+			return 0, "", nil, fmt.Errorf("failed to fetch endpoint %d: %v", req.EndpointID, err)
+		}
+		programID = endpoint.ProgramID
+		title = fmt.Sprintf("Authorization: %s %s%s", endpoint.Method, endpoint.Domain, endpoint.URI)
+		query = query.Where("endpoint_id = ? AND import_job_id IN (SELECT id FROM import_jobs WHERE job_type NOT IN ?)",
+			endpoint.ID, generatedJobTypes)
+	default:
+		return 0, "", nil, fmt.Errorf("choose an import job or an endpoint to test")
+	}
+
+	if programID == nil {
+		return 0, "", nil, fmt.Errorf("the requests belong to no program, identities are defined per program")
+	}
+
+	var originals []requests.MyRequest
+	if err := query.Order("sequence ASC").Find(&originals).Error(); err != nil {
+		return 0, "", nil, fmt.Errorf("failed to fetch captured requests: %v", err)
+	}
+	return *programID, title, originals, nil
+}
+
+// run sends every variant of an authorization run with a pool of workers
+func (s *AuthzService) run(ctx context.Context, jobID, programID uint, tasks []authzTask, concurrency int) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[AUTHZ PANIC] job %d: %v", jobID, r)
+			s.finishJob(ctx, jobID, fmt.Errorf("authorization run crashed: %v", r), "", 0)
+		}
+	}()
+
+	queue := make(chan authzTask)
+	go func() {
+		defer close(queue)
+		for _, task := range tasks {
+			queue <- task
+		}
+	}()
+
+	hashFunc := newHashFunc(nil, nil)
+	var mu sync.Mutex
+	done, flagged, lastProgress := 0, 0, 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range queue {
+				attempt, err := s.sendVariant(ctx, jobID, programID, task, hashFunc)
+				if err != nil {
+					log.Printf("[AUTHZ] job %d request %d as %q: %v", jobID, task.original.ID, task.identity.Name, err)
+				}
+
+				mu.Lock()
+				done++
+				if attempt.Flagged {
+					flagged++
+				}
+				if progress := done * 100 / len(tasks); progress >= lastProgress+5 && progress < 100 {
+					lastProgress = progress
+					s.db.WithContext(ctx).Model(&requests.ImportJob{}).Where("id = ?", jobID).
+						Updates(map[string]interface{}{"progress": progress, "request_count": done})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	summary := fmt.Sprintf("%d requests sent, %d flagged", done, flagged)
+	s.finishJob(ctx, jobID, nil, summary, done)
+}
+
+// sendVariant sends a captured request as an identity and stores it with the verdict on its response
+func (s *AuthzService) sendVariant(ctx context.Context, jobID, programID uint, task authzTask, hashFunc func(*requests.TempMyRequest) (string, string)) (requests.AuthzAttempt, error) {
+	attempt := requests.AuthzAttempt{ImportJobID: jobID, OriginalID: task.original.ID, IdentityID: task.identity.ID, Verdict: requests.AuthzFailed}
+
+	temp, err := task.original.ToTempMyRequest()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt, s.db.WithContext(ctx).Create(&attempt).Error()
+	}
+	// Only the request half of the captured record is reused
+	temp = requests.TempMyRequest{Sequence: temp.Sequence, URL: temp.URL, Method: temp.Method, Domain: temp.Domain, ReqHeaders: temp.ReqHeaders, ReqBody: temp.ReqBody}
+	sent, err := task.identity.Apply(temp)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt, s.db.WithContext(ctx).Create(&attempt).Error()
+	}
+
+	if httpReq, err := sent.NewHTTPRequest(ctx); err != nil {
+		attempt.Error = err.Error()
+	} else {
+		started := time.Now()
+		res, err := s.client.Do(httpReq)
+		if err != nil {
+			attempt.Error = fmt.Sprintf("failed to send request: %v", err)
+		} else {
+			body, err := requests.ReadResponseBody(res.Body)
+			res.Body.Close()
+			if err != nil {
+				attempt.Error = fmt.Sprintf("failed to read response: %v", err)
+			}
+			sent.SetResponse(res, body, started, time.Since(started))
+		}
+	}
+	sent.ApplyHashes(hashFunc)
+
+	record, err := sent.ToMyRequest(programID, jobID, task.original.EndpointID)
+	if err != nil {
+		return attempt, err
+	}
+	if err := s.db.WithContext(ctx).Create(record).Error(); err != nil {
+		return attempt, fmt.Errorf("failed to store variant: %v", err)
+	}
+
+	attempt.RequestID = record.ID
+	attempt.Verdict = requests.CompareAuthz(task.original, *record)
+	attempt.Flagged = task.flag && requests.IsAuthzFinding(task.original, attempt.Verdict)
+	return attempt, s.db.WithContext(ctx).Create(&attempt).Error()
+}
+
+// finishJob records the end of an authorization run
+func (s *AuthzService) finishJob(ctx context.Context, jobID uint, runErr error, summary string, count int) {
+	updates := map[string]interface{}{
+		"status":        requests.ImportJobStatusDone,
+		"progress":      100,
+		"summary":       summary,
+		"request_count": count,
+		"finished_at":   time.Now().Unix(),
+	}
+	if runErr != nil {
+		updates["status"] = requests.ImportJobStatusFailed
+		updates["error"] = runErr.Error()
+	}
+	s.db.WithContext(ctx).Model(&requests.ImportJob{}).Where("id = ?", jobID).Updates(updates)
+}
+
+// AuthzCell summarizes the variants of one endpoint sent as one identity
+type AuthzCell struct {
+	Variants  int
+	Flagged   int
+	Verdict   requests.AuthzVerdict // most suspicious verdict among the variants
+	Statuses  []int                 // distinct response statuses, 0 for no response
+	RequestID uint                  // variant to open, the first flagged one when any
+}
+
+// AuthzMatrixRow is an endpoint with one cell per identity of the run
+type AuthzMatrixRow struct {
+	Endpoint         requests.Endpoint
+	Originals        int
+	OriginalStatuses []int
+	Cells            []AuthzCell // in the order of AuthzMatrix.Identities
+}
+
+// AuthzMatrix is the endpoint by identity matrix of an authorization run
+type AuthzMatrix struct {
+	Job        requests.ImportJob
+	Identities []requests.Identity
+	Rows       []AuthzMatrixRow
+	Attempts   int
+	Flagged    int
+}
+
+// GetMatrix fetches the variants of an authorization run grouped by endpoint and identity
+func (s *AuthzService) GetMatrix(ctx context.Context, jobID uint) (*AuthzMatrix, error) {
+	var job requests.ImportJob
+	if err := s.db.WithContext(ctx).First(&job, jobID).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch authorization job %d: %v", jobID, err)
+	}
+	if job.JobType != requests.JobTypeAuthz {
+		return nil, fmt.Errorf("job %d is not an authorization run", jobID)
+	}
+
+	var attempts []requests.AuthzAttempt
+	if err := s.db.WithContext(ctx).Where("import_job_id = ?", jobID).Order("id ASC").Find(&attempts).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch attempts of job %d: %v", jobID, err)
+	}
+	matrix := &AuthzMatrix{Job: job, Attempts: len(attempts)}
+	if len(attempts) == 0 {
+		return matrix, nil
+	}
+
+	var originalIDs, identityIDs, variantIDs []uint
+	for _, attempt := range attempts {
+		originalIDs = append(originalIDs, attempt.OriginalID)
+		identityIDs = append(identityIDs, attempt.IdentityID)
+		variantIDs = append(variantIDs, attempt.RequestID)
+	}
+
+	var originals, variants []requests.MyRequest
+	if err := s.db.WithContext(ctx).Where("id IN ?", originalIDs).Find(&originals).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch captured requests: %v", err)
+	}
+	if err := s.db.WithContext(ctx).Where("id IN ?", variantIDs).Find(&variants).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch requests of job %d: %v", jobID, err)
+	}
+	if err := s.db.WithContext(ctx).Where("id IN ?", identityIDs).Order("privilege DESC, name ASC").Find(&matrix.Identities).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch identities: %v", err)
+	}
+	originalByID := make(map[uint]requests.MyRequest, len(originals))
+	var endpointIDs []uint
+	for _, original := range originals {
+		originalByID[original.ID] = original
+		endpointIDs = append(endpointIDs, original.EndpointID)
+	}
+	variantByID := make(map[uint]requests.MyRequest, len(variants))
+	for _, variant := range variants {
+		variantByID[variant.ID] = variant
+	}
+
+	// Identities deleted since the run still get a column
+	column := make(map[uint]int)
+	for i, identity := range matrix.Identities {
+		column[identity.ID] = i
+	}
+	for _, attempt := range attempts {
+		if _, ok := column[attempt.IdentityID]; !ok {
+			column[attempt.IdentityID] = len(matrix.Identities)
+			matrix.Identities = append(matrix.Identities, requests.Identity{ID: attempt.IdentityID, Name: fmt.Sprintf("Deleted #%d", attempt.IdentityID)})
+		}
+	}
+
+	var endpoints []requests.Endpoint
+	if err := s.db.WithContext(ctx).Where("id IN ?", endpointIDs).Order("domain ASC, uri ASC, method ASC").Find(&endpoints).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch endpoints: %v", err)
+	}
+	rowByEndpoint := make(map[uint]int, len(endpoints))
+	for i, endpoint := range endpoints {
+		rowByEndpoint[endpoint.ID] = i
+		matrix.Rows = append(matrix.Rows, AuthzMatrixRow{Endpoint: endpoint, Cells: make([]AuthzCell, len(matrix.Identities))})
+	}
+
+	counted := make(map[uint]bool)
+	for _, attempt := range attempts {
+		original := originalByID[attempt.OriginalID]
+		index, ok := rowByEndpoint[original.EndpointID]
+		if !ok {
+			continue
+		}
+		row := &matrix.Rows[index]
+		if !counted[original.ID] {
+			counted[original.ID] = true
+			row.Originals++
+			row.OriginalStatuses = appendDistinct(row.OriginalStatuses, original.ResStatus)
+		}
+
+		cell := &row.Cells[column[attempt.IdentityID]]
+		cell.Variants++
+		cell.Statuses = appendDistinct(cell.Statuses, variantByID[attempt.RequestID].ResStatus)
+		if attempt.Verdict.Rank() > cell.Verdict.Rank() || cell.Verdict == "" {
+			cell.Verdict = attempt.Verdict
+		}
+		if attempt.Flagged {
+			if cell.Flagged == 0 {
+				cell.RequestID = attempt.RequestID
+			}
+			cell.Flagged++
+			matrix.Flagged++
+		} else if cell.RequestID == 0 {
+			cell.RequestID = attempt.RequestID
+		}
+	}
+	return matrix, nil
+}
+
+// appendDistinct adds a status to a sorted list when it is not there yet
+func appendDistinct(statuses []int, status int) []int {
+	for _, s := range statuses {
+		if s == status {
+			return statuses
+		}
+	}
+	statuses = append(statuses, status)
+	sort.Ints(statuses)
+	return statuses
+}
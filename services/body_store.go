@@ -0,0 +1,21 @@
+package services
+
+import (
+	"fmt"
+	"linn221/Requester/requests"
+)
+
+// bodyLookupSize is the number of body blobs fetched or deleted per query
+const bodyLookupSize = 500
+
+// pruneBodies deletes the blobs of refs no request refers to anymore.
+// It runs in the transaction deleting the requests that used them, a blob still referenced by another request is kept.
+func pruneBodies(tx Tx, refs []string) error {
+	for start := 0; start < len(refs); start += bodyLookupSize {
+		end := min(start+bodyLookupSize, len(refs))
+		if err := tx.Delete(&requests.BodyBlob{}, "hash IN ? AND NOT EXISTS (SELECT 1 FROM my_requests WHERE my_requests.res_body_ref = body_blobs.hash)", refs[start:end]).Error(); err != nil {
+			return fmt.Errorf("failed to delete unused response bodies: %v", err)
+		}
+	}
+	return nil
+}
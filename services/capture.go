@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"linn221/Requester/requests"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CaptureService records live traffic, such as the requests seen by the proxy, into import jobs
+type CaptureService struct {
+	db              Database
+	endpointService *EndpointService
+}
+
+// NewCaptureService creates a new CaptureService
+func NewCaptureService(db Database, endpointService *EndpointService) *CaptureService {
+	return &CaptureService{
+		db:              db,
+		endpointService: endpointService,
+	}
+}
+
+// CaptureSession stores the exchanges of one capture into its running import job.
+// Record is safe for concurrent use, exchanges are stored one at a time.
+type CaptureSession struct {
+	service   *CaptureService
+	programID uint
+	job       requests.ImportJob
+	hashFunc  func(*requests.TempMyRequest) (string, string)
+	rules     *programRules
+	dropOut   bool // skip out of scope exchanges instead of storing them flagged
+
+	mu       sync.Mutex
+	stats    *ImportStats
+	sequence int
+	skipped  atomic.Int64 // exchanges passed through without being handed to Record, counted without the lock
+}
+
+// CaptureOptions configures a capture session
+type CaptureOptions struct {
+	Title          string // defaults to the start time
+	IgnoredHeaders []string
+	IgnoredFields  []string // JSON body fields left out of the hashes
+	DropOutOfScope bool     // skip exchanges outside the program scope instead of storing them flagged
+}
+
+// Start creates the running import job a capture records into
+func (s *CaptureService) Start(ctx context.Context, programID uint, opts CaptureOptions) (*CaptureSession, error) {
+	rules, err := loadProgramRules(ctx, s.db, programID)
+	if err != nil {
+		return nil, err
+	}
+	title := opts.Title
+	if title == "" {
+		title = "Proxy capture " + time.Now().Format("2006-01-02 15:04")
+	}
+
+	job := requests.ImportJob{
+		ProgramID:      &programID,
+		Title:          title,
+		IgnoredHeaders: strings.Join(opts.IgnoredHeaders, ","),
+		IgnoredFields:  strings.Join(opts.IgnoredFields, ","),
+		JobType:        requests.JobTypeCaptureProxy,
+		Status:         requests.ImportJobStatusRunning,
+		StartedAt:      time.Now().Unix(),
+	}
+	if err := s.db.WithContext(ctx).Create(&job).Error(); err != nil {
+		return nil, fmt.Errorf("failed to create capture job: %v", err)
+	}
+
+	return &CaptureSession{
+		service:   s,
+		programID: programID,
+		job:       job,
+		hashFunc:  newHashFunc(opts.IgnoredHeaders, opts.IgnoredFields),
+		rules:     rules,
+		dropOut:   opts.DropOutOfScope,
+		stats:     NewImportStats(),
+	}, nil
+}
+
+// Job returns the import job the session records into
+func (c *CaptureSession) Job() requests.ImportJob {
+	return c.job
+}
+
+// Record hashes an exchange and stores it on its endpoint, the same way imported requests are stored
+func (c *CaptureSession) Record(ctx context.Context, temp requests.TempMyRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.record(ctx, temp); err != nil {
+		c.stats.NotRecorded++
+		return err
+	}
+	return nil
+}
+
+// Skip counts an exchange that was not recorded, such as one over the body size cap
+func (c *CaptureSession) Skip() {
+	c.skipped.Add(1)
+}
+
+// record stores one exchange, the caller holds the lock
+func (c *CaptureSession) record(ctx context.Context, temp requests.TempMyRequest) error {
+	outOfScope := !c.rules.scope.ContainsURL(temp.URL)
+	if outOfScope && c.dropOut {
+		c.stats.Dropped++
+		return nil
+	}
+
+	c.sequence++
+	temp.Sequence = c.sequence
+	temp.ApplyHashes(c.hashFunc)
+
+	uri := c.rules.endpointURI(temp.URL)
+	endpoint, err := c.service.endpointService.FindOrCreateEndpoint(ctx, c.programID, requests.EndpointSourceCapture, temp.Method, temp.Domain, uri)
+	if err != nil {
+		return fmt.Errorf("failed to find or create endpoint: %v", err)
+	}
+
+	dbReq, err := temp.ToMyRequest(c.programID, c.job.ID, endpoint.ID)
+	if err != nil {
+		return fmt.Errorf("failed to convert request to database format: %v", err)
+	}
+	dbReq.OutOfScope = outOfScope
+	if err := c.service.db.WithContext(ctx).Create(dbReq).Error(); err != nil {
+		return fmt.Errorf("failed to save request: %v", err)
+	}
+	c.stats.Add(temp, endpoint.ID)
+	if outOfScope {
+		c.stats.OutOfScope++
+	}
+
+	// Keep the counts of the job list current while the capture runs
+	c.service.db.WithContext(ctx).Model(&requests.ImportJob{ID: c.job.ID}).Updates(map[string]interface{}{
+		"request_count":  c.stats.Total,
+		"endpoint_count": len(c.stats.Endpoints),
+		"domain_count":   len(c.stats.Domains),
+	})
+	return nil
+}
+
+// Close marks the capture job as done with its summary
+func (c *CaptureSession) Close(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.NotRecorded += int(c.skipped.Swap(0))
+	err := c.service.db.WithContext(ctx).Model(&requests.ImportJob{ID: c.job.ID}).Updates(map[string]interface{}{
+		"status":         requests.ImportJobStatusDone,
+		"progress":       100,
+		"summary":        c.stats.Summary(c.job.Title),
+		"request_count":  c.stats.Total,
+		"endpoint_count": len(c.stats.Endpoints),
+		"domain_count":   len(c.stats.Domains),
+		"finished_at":    time.Now().Unix(),
+	}).Error()
+	if err != nil {
+		return fmt.Errorf("failed to finish capture job: %v", err)
+	}
+	return nil
+}
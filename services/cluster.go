@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"linn221/Requester/requests"
+)
+
+// MaxHashClusters caps the clusters listed at once, the largest ones come first
+const MaxHashClusters = 500
+
+// ClusterService groups stored requests by their hashes to collapse duplicate traffic
+type ClusterService struct {
+	db Database
+}
+
+// NewClusterService creates a new ClusterService
+func NewClusterService(db Database) *ClusterService {
+	return &ClusterService{db: db}
+}
+
+// ClusterScope selects the requests to cluster. Set one of the IDs.
+type ClusterScope struct {
+	ImportJobID uint
+	EndpointID  uint
+	ProgramID   uint
+}
+
+// Filter returns the request filter of the scope, narrowed to one hash when hash is set
+func (c ClusterScope) Filter(kind requests.HashKind, hash string) RequestFilter {
+	filter := RequestFilter{
+		ImportJobID: c.ImportJobID,
+		ProgramID:   c.ProgramID,
+		HashKind:    kind,
+		Hash:        hash,
+	}
+	if c.EndpointID != 0 {
+		filter.EndpointIDs = []uint{c.EndpointID}
+	}
+	return filter
+}
+
+// HashCluster is a group of requests sharing a hash, with the first of them as a sample
+type HashCluster struct {
+	Hash     string
+	Count    int64
+	SampleID uint
+	Sample   requests.MyRequest `gorm:"-"` // only the ID, method, URL, status and response size are loaded
+}
+
+// HashClusters is the result of clustering a scope by one hash kind
+type HashClusters struct {
+	Kind          requests.HashKind
+	Clusters      []HashCluster // largest first, at most MaxHashClusters
+	ClusterCount  int           // before the cap
+	RequestCount  int64
+	UniqueCount   int // clusters of a single request
+	LargestCount  int64
+	DuplicateRate int // percent of requests that repeat an earlier hash
+}
+
+// GetHashClusters groups the requests of scope by their hash of kind, largest clusters first
+func (s *ClusterService) GetHashClusters(ctx context.Context, scope ClusterScope, kind requests.HashKind) (*HashClusters, error) {
+	if scope.ImportJobID == 0 && scope.EndpointID == 0 && scope.ProgramID == 0 {
+		return nil, fmt.Errorf("an import job, endpoint or program is required")
+	}
+
+	column := kind.Column()
+	query := s.db.WithContext(ctx).Model(&requests.MyRequest{}).Where(column+" <> ?", "")
+	if scope.ImportJobID != 0 {
+		query = query.Where("import_job_id = ?", scope.ImportJobID)
+	}
+	if scope.EndpointID != 0 {
+		query = query.Where("endpoint_id = ?", scope.EndpointID)
+	}
+	if scope.ProgramID != 0 {
+		query = query.Where("program_id = ?", scope.ProgramID)
+	}
+
+	var clusters []HashCluster
+	if err := query.Select(column + " as hash, COUNT(*) as count, MIN(id) as sample_id").
+		Group(column).
+		Order("count DESC, sample_id ASC").
+		Scan(&clusters).Error(); err != nil {
+		return nil, fmt.Errorf("failed to cluster requests by %s hash: %v", kind, err)
+	}
+
+	result := &HashClusters{Kind: kind, ClusterCount: len(clusters)}
+	for _, cluster := range clusters {
+		result.RequestCount += cluster.Count
+		if cluster.Count == 1 {
+			result.UniqueCount++
+		}
+		if cluster.Count > result.LargestCount {
+			result.LargestCount = cluster.Count
+		}
+	}
+	if result.RequestCount > 0 {
+		result.DuplicateRate = int((result.RequestCount - int64(len(clusters))) * 100 / result.RequestCount)
+	}
+	if len(clusters) > MaxHashClusters {
+		clusters = clusters[:MaxHashClusters]
+	}
+
+	sampleIDs := make([]uint, 0, len(clusters))
+	for _, cluster := range clusters {
+		sampleIDs = append(sampleIDs, cluster.SampleID)
+	}
+	if len(sampleIDs) > 0 {
+		// Only the columns shown in the cluster list, so no headers or bodies are loaded
+		var samples []requests.MyRequest
+		if err := s.db.WithContext(ctx).Where("id IN ?", sampleIDs).Select("id", "method", "url", "res_status", "resp_size").
+			Find(&samples).Error(); err != nil {
+			return nil, fmt.Errorf("failed to fetch cluster samples: %v", err)
+		}
+		byID := make(map[uint]requests.MyRequest, len(samples))
+		for _, sample := range samples {
+			byID[sample.ID] = sample
+		}
+		for i := range clusters {
+			clusters[i].Sample = byID[clusters[i].SampleID]
+		}
+	}
+	result.Clusters = clusters
+	return result, nil
+}
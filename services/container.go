@@ -1,31 +1,68 @@
 package services
 
 import (
+	"context"
+	"linn221/Requester/utils"
+	"os"
+	"path/filepath"
+
 	"gorm.io/gorm"
 )
 
 // ServiceContainer holds all services
 type ServiceContainer struct {
-	ImportService    *ImportService
-	RequestService   *RequestService
-	ImportJobService *ImportJobService
-	EndpointService  *EndpointService
-	ProgramService   *ProgramService
-	FormParser       *FormParser
+	ImportService     *ImportService
+	RequestService    *RequestService
+	ImportJobService  *ImportJobService
+	EndpointService   *EndpointService
+	ProgramService    *ProgramService
+	ReplayService     *ReplayService
+	DiffService       *DiffService
+	FuzzService       *FuzzService
+	IdentityService   *IdentityService
+	AuthzService      *AuthzService
+	RegroupService    *RegroupService
+	RehashService     *RehashService
+	ClusterService    *ClusterService
+	NoteService       *NoteService
+	AttachmentService *AttachmentService
+	FormParser        *FormParser
 }
 
 // NewServiceContainer creates a new service container with all services
 func NewServiceContainer(db *gorm.DB) *ServiceContainer {
 	database := NewGormDatabaseAdapter(db)
 	endpointService := NewEndpointService(database)
-	programService := NewProgramService(database)
+	attachmentService := NewAttachmentService(database, attachmentDir())
+	programService := NewProgramService(database, attachmentService)
+	importService := NewImportService(database, endpointService)
+	importService.StartWorkers(context.Background(), DefaultImportWorkers)
+	replayClient := NewReplayClient()
 
 	return &ServiceContainer{
-		ImportService:    NewImportService(database, endpointService),
-		RequestService:   NewRequestService(database),
-		ImportJobService: NewImportJobService(database),
-		EndpointService:  endpointService,
-		ProgramService:   programService,
-		FormParser:       NewFormParser(),
+		ImportService:     importService,
+		RequestService:    NewRequestService(database),
+		ImportJobService:  NewImportJobService(database),
+		EndpointService:   endpointService,
+		ProgramService:    programService,
+		ReplayService:     NewReplayService(database, endpointService, replayClient),
+		DiffService:       NewDiffService(database),
+		FuzzService:       NewFuzzService(database, replayClient),
+		IdentityService:   NewIdentityService(database),
+		AuthzService:      NewAuthzService(database, replayClient),
+		RegroupService:    NewRegroupService(database, endpointService),
+		RehashService:     NewRehashService(database),
+		ClusterService:    NewClusterService(database),
+		NoteService:       NewNoteService(database),
+		AttachmentService: attachmentService,
+		FormParser:        NewFormParser(),
+	}
+}
+
+// attachmentDir returns the directory of uploaded attachments, ATTACHMENTS_DIR or attachments next to the executable
+func attachmentDir() string {
+	if dir := os.Getenv("ATTACHMENTS_DIR"); dir != "" {
+		return dir
 	}
+	return filepath.Join(utils.GetBaseDir(), "attachments")
 }
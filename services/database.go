@@ -111,11 +111,28 @@ func (g *GormQueryAdapter) Order(value interface{}) Query {
 	return &GormQueryAdapter{db: g.db.Order(value)}
 }
 
+// Limit limits the number of records retrieved
+func (g *GormQueryAdapter) Limit(limit int) Query {
+	return &GormQueryAdapter{db: g.db.Limit(limit)}
+}
+
+// Offset skips the given number of records
+func (g *GormQueryAdapter) Offset(offset int) Query {
+	return &GormQueryAdapter{db: g.db.Offset(offset)}
+}
+
 // Find finds records
 func (g *GormQueryAdapter) Find(dest interface{}) Query {
 	return &GormQueryAdapter{db: g.db.Find(dest)}
 }
 
+// FindInBatches finds records batchSize at a time in primary key order, calling fc after each batch is loaded into dest
+func (g *GormQueryAdapter) FindInBatches(dest interface{}, batchSize int, fc func(batch int) error) Query {
+	return &GormQueryAdapter{db: g.db.FindInBatches(dest, batchSize, func(tx *gorm.DB, batch int) error {
+		return fc(batch)
+	})}
+}
+
 // Count counts records
 func (g *GormQueryAdapter) Count(count *int64) Query {
 	return &GormQueryAdapter{db: g.db.Count(count)}
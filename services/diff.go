@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"linn221/Requester/requests"
+	"sort"
+	"strings"
+)
+
+// DiffService compares two stored requests
+type DiffService struct {
+	db Database
+}
+
+// NewDiffService creates a new DiffService
+func NewDiffService(db Database) *DiffService {
+	return &DiffService{db: db}
+}
+
+// RequestDiff holds the differences between two stored requests and their responses
+type RequestDiff struct {
+	A, B           requests.MyRequest
+	IgnoredHeaders []string // left out of the header diffs, taken from both requests' import jobs
+	ReqHeaders     []requests.DiffLine
+	ResHeaders     []requests.DiffLine
+	ReqBody        requests.BodyDiff
+	ResBody        requests.BodyDiff
+}
+
+// Compare diffs request a against request b.
+// Headers are compared sorted by name so a different header order is not reported as a change.
+func (s *DiffService) Compare(ctx context.Context, aID, bID uint) (*RequestDiff, error) {
+	a, aTemp, ignoredA, err := s.loadForDiff(ctx, aID)
+	if err != nil {
+		return nil, err
+	}
+	b, bTemp, ignoredB, err := s.loadForDiff(ctx, bID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ignored []string
+	seen := make(map[string]bool)
+	for _, name := range append(ignoredA, ignoredB...) {
+		if !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			ignored = append(ignored, name)
+		}
+	}
+	return &RequestDiff{
+		A:              a,
+		B:              b,
+		IgnoredHeaders: ignored,
+		ReqHeaders:     requests.DiffLines(sortedHeaderText(aTemp.ReqHeaders, ignored), sortedHeaderText(bTemp.ReqHeaders, ignored)),
+		ResHeaders:     requests.DiffLines(sortedHeaderText(aTemp.ResHeaders, ignored), sortedHeaderText(bTemp.ResHeaders, ignored)),
+		ReqBody:        requests.DiffBodies(a.ReqBody, b.ReqBody),
+		ResBody:        requests.DiffBodies(a.ResBody, b.ResBody),
+	}, nil
+}
+
+// loadForDiff fetches a request with its decoded headers and the ignored headers of its import job
+func (s *DiffService) loadForDiff(ctx context.Context, id uint) (requests.MyRequest, requests.TempMyRequest, []string, error) {
+	var request requests.MyRequest
+	if err := s.db.WithContext(ctx).First(&request, id).Error(); err != nil {
+		return request, requests.TempMyRequest{}, nil, fmt.Errorf("failed to fetch request with ID %d: %v", id, err)
+	}
+	temp, err := request.ToTempMyRequest()
+	if err != nil {
+		return request, temp, nil, fmt.Errorf("failed to decode request %d: %v", id, err)
+	}
+
+	var importJob requests.ImportJob
+	if err := s.db.WithContext(ctx).First(&importJob, request.ImportJobID).Error(); err != nil {
+		return request, temp, nil, fmt.Errorf("failed to fetch import job %d: %v", request.ImportJobID, err)
+	}
+	return request, temp, importJob.IgnoredHeaderNames(), nil
+}
+
+// sortedHeaderText renders headers one per line sorted by name, without the ignored ones
+func sortedHeaderText(headers requests.HeaderSlice, ignored []string) string {
+	lines := strings.Split(strings.TrimSuffix(headers.EchoFilter(ignored...), "\n"), "\n")
+	sort.SliceStable(lines, func(i, j int) bool {
+		return strings.ToLower(lines[i]) < strings.ToLower(lines[j])
+	})
+	return strings.Join(lines, "\n")
+}
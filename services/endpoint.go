@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"linn221/Requester/requests"
+	"strings"
 )
 
 // EndpointService handles endpoint-related operations
@@ -16,8 +17,9 @@ func NewEndpointService(db Database) *EndpointService {
 	return &EndpointService{db: db}
 }
 
-// FindOrCreateEndpoint finds an existing endpoint or creates a new one
-func (s *EndpointService) FindOrCreateEndpoint(ctx context.Context, programID uint, method, domain, uri string) (*requests.Endpoint, error) {
+// FindOrCreateEndpoint finds an existing endpoint or creates a new one.
+// source is only recorded when the endpoint is created.
+func (s *EndpointService) FindOrCreateEndpoint(ctx context.Context, programID uint, source requests.EndpointSource, method, domain, uri string) (*requests.Endpoint, error) {
 	// First, try to find existing endpoint
 	var endpoint requests.Endpoint
 	err := s.db.WithContext(ctx).Where("program_id = ? AND method = ? AND domain = ? AND uri = ?", programID, method, domain, uri).First(&endpoint).Error()
@@ -35,6 +37,7 @@ func (s *EndpointService) FindOrCreateEndpoint(ctx context.Context, programID ui
 		Domain:       domain,
 		URI:          uri,
 		EndpointType: endpointType,
+		Source:       source,
 		Notes:        "",
 	}
 
@@ -45,6 +48,30 @@ func (s *EndpointService) FindOrCreateEndpoint(ctx context.Context, programID ui
 	return newEndpoint, nil
 }
 
+// AddEndpointNote appends a line to the endpoint's notes unless the notes already contain it
+func (s *EndpointService) AddEndpointNote(ctx context.Context, endpoint *requests.Endpoint, note string) error {
+	notes := appendNote(endpoint.Notes, note)
+	if notes == endpoint.Notes {
+		return nil
+	}
+	if err := s.db.WithContext(ctx).Model(endpoint).Updates(map[string]interface{}{"notes": notes}).Error(); err != nil {
+		return fmt.Errorf("failed to update endpoint notes: %v", err)
+	}
+	endpoint.Notes = notes
+	return nil
+}
+
+// appendNote adds a line to notes unless it is empty or the notes already contain it
+func appendNote(notes, note string) string {
+	if note == "" || strings.Contains(notes, note) {
+		return notes
+	}
+	if notes == "" {
+		return note
+	}
+	return notes + "\n" + note
+}
+
 // GetAllEndpoints fetches all endpoints
 func (s *EndpointService) GetAllEndpoints(ctx context.Context) ([]requests.Endpoint, error) {
 	var endpoints []requests.Endpoint
@@ -54,15 +81,235 @@ func (s *EndpointService) GetAllEndpoints(ctx context.Context) ([]requests.Endpo
 	return endpoints, nil
 }
 
+// EndpointTraffic counts the stored requests of an endpoint and how many of them have a response
+type EndpointTraffic struct {
+	EndpointID    uint
+	RequestCount  int64
+	ResponseCount int64
+}
+
+// GetEndpointTraffic returns request and response counts keyed by endpoint ID.
+// Request-only records, such as imported collection entries, don't count as responses.
+func (s *EndpointService) GetEndpointTraffic(ctx context.Context) (map[uint]EndpointTraffic, error) {
+	return s.endpointTraffic(s.db.WithContext(ctx).Model(&requests.MyRequest{}))
+}
+
+// GetProgramEndpointTraffic returns the request and response counts of the endpoints of one program
+func (s *EndpointService) GetProgramEndpointTraffic(ctx context.Context, programID uint) (map[uint]EndpointTraffic, error) {
+	return s.endpointTraffic(s.db.WithContext(ctx).Model(&requests.MyRequest{}).Where("endpoint_id IN (SELECT id FROM endpoints WHERE program_id = ?)", programID))
+}
+
+// generatedJobTypes are the jobs whose requests are variants of a stored request sent by the tool,
+// they are kept on the original's endpoint but are not traffic of the target
+var generatedJobTypes = []requests.JobType{requests.JobTypeFuzz, requests.JobTypeAuthz, requests.JobTypeReplay}
+
+// endpointTraffic counts the requests selected by query per endpoint, leaving out fuzz and authorization attempts and replays
+func (s *EndpointService) endpointTraffic(query Query) (map[uint]EndpointTraffic, error) {
+	var rows []EndpointTraffic
+	if err := query.
+		Where("import_job_id NOT IN (SELECT id FROM import_jobs WHERE job_type IN ?)", generatedJobTypes).
+		Select("endpoint_id, COUNT(*) as request_count, SUM(CASE WHEN res_status > 0 THEN 1 ELSE 0 END) as response_count").
+		Group("endpoint_id").
+		Scan(&rows).Error(); err != nil {
+		return nil, fmt.Errorf("failed to count endpoint traffic: %v", err)
+	}
+
+	traffic := make(map[uint]EndpointTraffic, len(rows))
+	for _, row := range rows {
+		traffic[row.EndpointID] = row
+	}
+	return traffic, nil
+}
+
+// GetEndpointsByProgram fetches the endpoints of a program
+func (s *EndpointService) GetEndpointsByProgram(ctx context.Context, programID uint) ([]requests.Endpoint, error) {
+	var endpoints []requests.Endpoint
+	if err := s.db.WithContext(ctx).Where("program_id = ?", programID).Order("domain ASC, uri ASC, method ASC").Find(&endpoints).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch endpoints of program %d: %v", programID, err)
+	}
+	return endpoints, nil
+}
+
+// SeedOpenAPIEndpoints creates an endpoint for every documented operation, keeping its path template,
+// and notes the operation ID, summary and tags. It returns the number of operations seeded.
+func (s *EndpointService) SeedOpenAPIEndpoints(ctx context.Context, programID uint, operations []requests.OpenAPIOperation) (int, error) {
+	for _, op := range operations {
+		endpoint, err := s.FindOrCreateEndpoint(ctx, programID, requests.EndpointSourceOpenAPI, op.Method, op.Domain, op.URI)
+		if err != nil {
+			return 0, err
+		}
+
+		var parts []string
+		if op.OperationID != "" {
+			parts = append(parts, op.OperationID)
+		}
+		if op.Summary != "" {
+			parts = append(parts, op.Summary)
+		}
+		if len(op.Tags) > 0 {
+			parts = append(parts, "tags: "+strings.Join(op.Tags, ", "))
+		}
+		if len(parts) > 0 {
+			if err := s.AddEndpointNote(ctx, endpoint, "OpenAPI: "+strings.Join(parts, " - ")); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(operations), nil
+}
+
+// OperationCoverage tells how much captured traffic a documented endpoint received
+type OperationCoverage struct {
+	Endpoint         requests.Endpoint
+	ResponseCount    int64 // captured responses whose path matches the documented template
+	MatchedEndpoints int   // distinct captured paths with responses, e.g. /users/1 and /users/2 for /users/{id}
+}
+
+// CoverageReport compares a program's documented endpoints with its captured traffic
+type CoverageReport struct {
+	Operations   []OperationCoverage
+	Covered      int
+	Undocumented []requests.Endpoint // captured endpoints with responses that match no documented operation
+}
+
+// GetDocumentationCoverage matches the captured responses of a program against the path templates of its
+// documented endpoints, from OpenAPI documents or collections, by method, domain and path.
+func (s *EndpointService) GetDocumentationCoverage(ctx context.Context, programID uint) (*CoverageReport, error) {
+	endpoints, err := s.GetEndpointsByProgram(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+	traffic, err := s.GetProgramEndpointTraffic(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only endpoints with responses can cover an operation, grouped by method and domain so each operation is
+	// matched against its own candidates only
+	candidates := make(map[string][]requests.Endpoint)
+	for _, captured := range endpoints {
+		if traffic[captured.ID].ResponseCount > 0 {
+			key := coverageKey(captured)
+			candidates[key] = append(candidates[key], captured)
+		}
+	}
+
+	report := &CoverageReport{}
+	documented := make(map[uint]bool)
+	for _, doc := range endpoints {
+		if !doc.Source.IsDocumented() {
+			continue
+		}
+		pattern := requests.PathTemplatePattern(doc.URI)
+		coverage := OperationCoverage{Endpoint: doc}
+		for _, captured := range candidates[coverageKey(doc)] {
+			hits := traffic[captured.ID].ResponseCount
+			if captured.ID == doc.ID || pattern.MatchString(captured.URI) {
+				coverage.ResponseCount += hits
+				coverage.MatchedEndpoints++
+				documented[captured.ID] = true
+			}
+		}
+		if coverage.ResponseCount > 0 {
+			report.Covered++
+		}
+		report.Operations = append(report.Operations, coverage)
+	}
+
+	for _, captured := range endpoints {
+		if !captured.Source.IsDocumented() && traffic[captured.ID].ResponseCount > 0 && !documented[captured.ID] {
+			report.Undocumented = append(report.Undocumented, captured)
+		}
+	}
+	return report, nil
+}
+
+// coverageKey groups endpoints by method and domain, ignoring case
+func coverageKey(endpoint requests.Endpoint) string {
+	return strings.ToUpper(endpoint.Method) + " " + strings.ToLower(endpoint.Domain)
+}
+
 // GetEndpointByID fetches a single endpoint by ID
 func (s *EndpointService) GetEndpointByID(ctx context.Context, id uint) (*requests.Endpoint, error) {
 	var endpoint requests.Endpoint
 	if err := s.db.WithContext(ctx).First(&endpoint, id).Error(); err != nil {
-		return nil, fmt.Errorf("failed to fetch endpoint with ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to fetch endpoint with ID %d: %w", id, err)
 	}
 	return &endpoint, nil
 }
 
+// CreateEndpoint stores an endpoint added by hand, rejecting a duplicate of an existing one
+func (s *EndpointService) CreateEndpoint(ctx context.Context, endpoint *requests.Endpoint) error {
+	if err := s.validateEndpoint(ctx, endpoint); err != nil {
+		return err
+	}
+	endpoint.Source = requests.EndpointSourceManual
+	if err := s.db.WithContext(ctx).Create(endpoint).Error(); err != nil {
+		return fmt.Errorf("failed to create endpoint: %v", err)
+	}
+	return nil
+}
+
+// UpdateEndpoint updates the program, method, domain, URI, type and notes of an endpoint
+func (s *EndpointService) UpdateEndpoint(ctx context.Context, endpoint *requests.Endpoint) error {
+	if err := s.validateEndpoint(ctx, endpoint); err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Model(endpoint).Select("program_id", "method", "domain", "uri", "endpoint_type", "notes").Updates(endpoint).Error(); err != nil {
+		return fmt.Errorf("failed to update endpoint %d: %v", endpoint.ID, err)
+	}
+	return nil
+}
+
+// DeleteEndpoint deletes an endpoint that no stored request belongs to
+func (s *EndpointService) DeleteEndpoint(ctx context.Context, id uint) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&requests.MyRequest{}).Where("endpoint_id = ?", id).Count(&count).Error(); err != nil {
+		return fmt.Errorf("failed to count requests of endpoint %d: %v", id, err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: endpoint %d still has %d requests", ErrConflict, id, count)
+	}
+	if err := s.db.WithContext(ctx).Model(&requests.Endpoint{}).Delete(&requests.Endpoint{}, id).Error(); err != nil {
+		return fmt.Errorf("failed to delete endpoint %d: %v", id, err)
+	}
+	return nil
+}
+
+// validateEndpoint normalizes the method of an endpoint and checks its program exists and no other endpoint has its path
+func (s *EndpointService) validateEndpoint(ctx context.Context, endpoint *requests.Endpoint) error {
+	endpoint.Method = strings.ToUpper(strings.TrimSpace(endpoint.Method))
+	switch {
+	case endpoint.ProgramID == nil:
+		return fmt.Errorf("%w: program_id is required", ErrInvalid)
+	case endpoint.Method == "":
+		return fmt.Errorf("%w: method is required", ErrInvalid)
+	case endpoint.Domain == "":
+		return fmt.Errorf("%w: domain is required", ErrInvalid)
+	case !strings.HasPrefix(endpoint.URI, "/"):
+		return fmt.Errorf("%w: URI must start with /", ErrInvalid)
+	}
+	if endpoint.EndpointType == "" {
+		endpoint.EndpointType = requests.DetermineEndpointType(endpoint.URI, endpoint.Method)
+	}
+
+	var program requests.Program
+	if err := s.db.WithContext(ctx).First(&program, *endpoint.ProgramID).Error(); err != nil {
+		return fmt.Errorf("%w: program %d does not exist", ErrInvalid, *endpoint.ProgramID)
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&requests.Endpoint{}).
+		Where("program_id = ? AND method = ? AND domain = ? AND uri = ? AND id <> ?", *endpoint.ProgramID, endpoint.Method, endpoint.Domain, endpoint.URI, endpoint.ID).
+		Count(&count).Error(); err != nil {
+		return fmt.Errorf("failed to check for duplicate endpoints: %v", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s %s%s already exists", ErrConflict, endpoint.Method, endpoint.Domain, endpoint.URI)
+	}
+	return nil
+}
+
 // GetEndpointStats fetches statistics for an endpoint
 func (s *EndpointService) GetEndpointStats(ctx context.Context, endpointID uint) (map[string]interface{}, error) {
 	var count int64
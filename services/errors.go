@@ -0,0 +1,19 @@
+package services
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalid is wrapped by errors caused by invalid input, such as a scope rule that fails to parse
+	ErrInvalid = errors.New("invalid input")
+	// ErrConflict is wrapped by errors caused by existing records, such as a duplicate endpoint
+	ErrConflict = errors.New("conflict")
+)
+
+// IsNotFound reports whether err comes from a lookup that found no record
+func IsNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"linn221/Requester/requests"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultFuzzConcurrency is the number of attempts in flight when the form leaves it empty
+	DefaultFuzzConcurrency = 5
+	// MaxFuzzConcurrency caps the attempts in flight of a single run
+	MaxFuzzConcurrency = 50
+	// fuzzOutlierRatio flags clusters holding at most 1/ratio of the attempts
+	fuzzOutlierRatio = 5
+)
+
+// FuzzService sends payload variations of a stored request and records every attempt
+type FuzzService struct {
+	db     Database
+	client *http.Client
+}
+
+// NewFuzzService creates a new FuzzService sending requests with client
+func NewFuzzService(db Database, client *http.Client) *FuzzService {
+	return &FuzzService{
+		db:     db,
+		client: client,
+	}
+}
+
+// FuzzRequest describes a fuzz run against a stored request.
+// Each payload is tried at each insertion point on its own, the other points keep their original values.
+type FuzzRequest struct {
+	BaseRequestID  uint
+	Points         []requests.InsertionPoint
+	Payloads       []string
+	IgnoredHeaders []string // left out of the hashes used to cluster responses
+	Concurrency    int
+	RatePerSecond  float64 // 0 sends as fast as the workers allow
+}
+
+// fuzzTask is one payload at one insertion point
+type fuzzTask struct {
+	sequence int
+	point    requests.InsertionPoint
+	payload  string
+}
+
+// Start validates a fuzz run, records it as a job and sends its attempts in the background
+func (s *FuzzService) Start(ctx context.Context, req FuzzRequest) (*requests.ImportJob, error) {
+	if len(req.Points) == 0 {
+		return nil, fmt.Errorf("select at least one insertion point")
+	}
+	if len(req.Payloads) == 0 {
+		return nil, fmt.Errorf("no payloads to send")
+	}
+	if total := len(req.Points) * len(req.Payloads); total > requests.MaxFuzzPayloads {
+		return nil, fmt.Errorf("%d attempts exceed the limit of %d", total, requests.MaxFuzzPayloads)
+	}
+	if req.Concurrency <= 0 {
+		req.Concurrency = DefaultFuzzConcurrency
+	}
+	if req.Concurrency > MaxFuzzConcurrency {
+		req.Concurrency = MaxFuzzConcurrency
+	}
+
+	var base requests.MyRequest
+	if err := s.db.WithContext(ctx).First(&base, req.BaseRequestID).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch request with ID %d: %v", req.BaseRequestID, err)
+	}
+
+	job := requests.ImportJob{
+		ProgramID:      base.ProgramID,
+		Title:          fmt.Sprintf("Fuzz #%d %s %s", base.ID, base.Method, base.URL),
+		IgnoredHeaders: strings.Join(req.IgnoredHeaders, ","),
+		JobType:        requests.JobTypeFuzz,
+		Status:         requests.ImportJobStatusRunning,
+		StartedAt:      time.Now().Unix(),
+	}
+	if err := s.db.WithContext(ctx).Create(&job).Error(); err != nil {
+		return nil, fmt.Errorf("failed to create fuzz job: %v", err)
+	}
+
+	go s.run(context.Background(), job.ID, base, req)
+	return &job, nil
+}
+
+// run sends every attempt of a fuzz run with a pool of workers sharing one rate limit
+func (s *FuzzService) run(ctx context.Context, jobID uint, base requests.MyRequest, req FuzzRequest) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[FUZZ PANIC] job %d: %v", jobID, r)
+			s.finishJob(ctx, jobID, fmt.Errorf("fuzz run crashed: %v", r), "", 0)
+		}
+	}()
+
+	temp, err := base.ToTempMyRequest()
+	if err != nil {
+		s.finishJob(ctx, jobID, err, "", 0)
+		return
+	}
+	// Only the request half of the base record is reused
+	temp = requests.TempMyRequest{URL: temp.URL, Method: temp.Method, Domain: temp.Domain, ReqHeaders: temp.ReqHeaders, ReqBody: temp.ReqBody}
+
+	tasks := make(chan fuzzTask)
+	go func() {
+		defer close(tasks)
+		sequence := 0
+		for _, point := range req.Points {
+			for _, payload := range req.Payloads {
+				sequence++
+				tasks <- fuzzTask{sequence: sequence, point: point, payload: payload}
+			}
+		}
+	}()
+
+	var limiter <-chan time.Time
+	if req.RatePerSecond > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / req.RatePerSecond))
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+	total := len(req.Points) * len(req.Payloads)
+	hashFunc := newHashFunc(req.IgnoredHeaders, nil)
+	var mu sync.Mutex
+	done, failed, lastProgress := 0, 0, 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < req.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				if limiter != nil {
+					<-limiter
+				}
+				attemptErr, err := s.sendAttempt(ctx, jobID, base, temp, task, hashFunc)
+				if err != nil {
+					log.Printf("[FUZZ] job %d attempt %d: %v", jobID, task.sequence, err)
+				}
+
+				mu.Lock()
+				done++
+				if attemptErr != "" || err != nil {
+					failed++
+				}
+				if progress := done * 100 / total; progress >= lastProgress+5 && progress < 100 {
+					lastProgress = progress
+					s.db.WithContext(ctx).Model(&requests.ImportJob{}).Where("id = ?", jobID).
+						Updates(map[string]interface{}{"progress": progress, "request_count": done})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	summary := fmt.Sprintf("%d attempts at %d insertion points, %d without a response", total, len(req.Points), failed)
+	s.finishJob(ctx, jobID, nil, summary, done)
+}
+
+// sendAttempt sends one payload and stores the request with its response.
+// The returned string explains a missing response, the error reports a failure to store the attempt.
+func (s *FuzzService) sendAttempt(ctx context.Context, jobID uint, base requests.MyRequest, temp requests.TempMyRequest, task fuzzTask, hashFunc func(*requests.TempMyRequest) (string, string)) (string, error) {
+	attempt := requests.FuzzAttempt{ImportJobID: jobID, Sequence: task.sequence, InsertionPoint: task.point.String(), Payload: task.payload}
+
+	sent, err := task.point.Apply(temp, task.payload)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt.Error, s.db.WithContext(ctx).Create(&attempt).Error()
+	}
+	sent.Sequence = task.sequence
+	if u, err := url.Parse(sent.URL); err == nil {
+		sent.Domain = u.Hostname()
+	}
+
+	if httpReq, err := sent.NewHTTPRequest(ctx); err != nil {
+		attempt.Error = err.Error()
+	} else {
+		started := time.Now()
+		res, err := s.client.Do(httpReq)
+		if err != nil {
+			attempt.Error = fmt.Sprintf("failed to send request: %v", err)
+		} else {
+			body, err := requests.ReadResponseBody(res.Body)
+			res.Body.Close()
+			if err != nil {
+				attempt.Error = fmt.Sprintf("failed to read response: %v", err)
+			}
+			sent.SetResponse(res, body, started, time.Since(started))
+		}
+	}
+	sent.ApplyHashes(hashFunc)
+
+	var programID uint
+	if base.ProgramID != nil {
+		programID = *base.ProgramID
+	}
+	// Attempts stay on the base endpoint, fuzzed paths would otherwise flood the endpoint list.
+	// The fuzz job tags them, traffic and coverage counts leave them out.
+	record, err := sent.ToMyRequest(programID, jobID, base.EndpointID)
+	if err != nil {
+		return attempt.Error, err
+	}
+	if err := s.db.WithContext(ctx).Create(record).Error(); err != nil {
+		return attempt.Error, fmt.Errorf("failed to store attempt: %v", err)
+	}
+
+	attempt.RequestID = record.ID
+	return attempt.Error, s.db.WithContext(ctx).Create(&attempt).Error()
+}
+
+// finishJob records the end of a fuzz run
+func (s *FuzzService) finishJob(ctx context.Context, jobID uint, runErr error, summary string, count int) {
+	updates := map[string]interface{}{
+		"status":        requests.ImportJobStatusDone,
+		"progress":      100,
+		"summary":       summary,
+		"request_count": count,
+		"finished_at":   time.Now().Unix(),
+	}
+	if runErr != nil {
+		updates["status"] = requests.ImportJobStatusFailed
+		updates["error"] = runErr.Error()
+	}
+	s.db.WithContext(ctx).Model(&requests.ImportJob{}).Where("id = ?", jobID).Updates(updates)
+}
+
+// FuzzResultRow is one attempt with the size of the response cluster it belongs to
+type FuzzResultRow struct {
+	Attempt     requests.FuzzAttempt
+	Request     requests.MyRequest // zero when the payload could not be applied
+	ClusterSize int
+	Outlier     bool
+}
+
+// FuzzResults lists the attempts of a fuzz run
+type FuzzResults struct {
+	Job      requests.ImportJob
+	Rows     []FuzzResultRow
+	Clusters int
+}
+
+// GetResults fetches the attempts of a fuzz run and clusters them by ResHash.
+// The largest cluster is taken as the normal response, smaller clusters holding
+// at most 1/fuzzOutlierRatio of the attempts are flagged as outliers.
+func (s *FuzzService) GetResults(ctx context.Context, jobID uint) (*FuzzResults, error) {
+	var job requests.ImportJob
+	if err := s.db.WithContext(ctx).First(&job, jobID).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch fuzz job %d: %v", jobID, err)
+	}
+	if job.JobType != requests.JobTypeFuzz {
+		return nil, fmt.Errorf("job %d is not a fuzz run", jobID)
+	}
+
+	var attempts []requests.FuzzAttempt
+	if err := s.db.WithContext(ctx).Where("import_job_id = ?", jobID).Order("sequence ASC").Find(&attempts).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch attempts of job %d: %v", jobID, err)
+	}
+	var records []requests.MyRequest
+	if err := s.db.WithContext(ctx).Where("import_job_id = ?", jobID).Find(&records).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch requests of job %d: %v", jobID, err)
+	}
+	byID := make(map[uint]requests.MyRequest, len(records))
+	clusterSizes := make(map[string]int)
+	for _, record := range records {
+		byID[record.ID] = record
+		clusterSizes[record.ResHash]++
+	}
+
+	largest := 0
+	for _, size := range clusterSizes {
+		if size > largest {
+			largest = size
+		}
+	}
+
+	results := &FuzzResults{Job: job, Clusters: len(clusterSizes), Rows: make([]FuzzResultRow, 0, len(attempts))}
+	for _, attempt := range attempts {
+		row := FuzzResultRow{Attempt: attempt, Request: byID[attempt.RequestID]}
+		if attempt.RequestID != 0 {
+			row.ClusterSize = clusterSizes[row.Request.ResHash]
+			row.Outlier = row.ClusterSize < largest && row.ClusterSize*fuzzOutlierRatio <= len(records)
+		}
+		results.Rows = append(results.Rows, row)
+	}
+	return results, nil
+}
@@ -25,6 +25,12 @@ func (h *HTTPRequestAdapter) FormValue(name string) string {
 	return h.req.FormValue(name)
 }
 
+// FormValues gets every value of a repeated form field
+func (h *HTTPRequestAdapter) FormValues(name string) []string {
+	h.req.FormValue(name) // parses the form when it has not been parsed yet
+	return h.req.Form[name]
+}
+
 // FormFile gets form file
 func (h *HTTPRequestAdapter) FormFile(name string) (File, FileHeader, error) {
 	file, header, err := h.req.FormFile(name)
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"linn221/Requester/requests"
+)
+
+// IdentityService manages the credential sets of programs
+type IdentityService struct {
+	db Database
+}
+
+// NewIdentityService creates a new IdentityService
+func NewIdentityService(db Database) *IdentityService {
+	return &IdentityService{db: db}
+}
+
+// GetIdentities fetches the identities of a program, most privileged first
+func (s *IdentityService) GetIdentities(ctx context.Context, programID uint) ([]requests.Identity, error) {
+	var identities []requests.Identity
+	if err := s.db.WithContext(ctx).Where("program_id = ?", programID).Order("privilege DESC, name ASC").Find(&identities).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch identities of program %d: %v", programID, err)
+	}
+	return identities, nil
+}
+
+// CreateIdentity creates a new identity
+func (s *IdentityService) CreateIdentity(ctx context.Context, identity *requests.Identity) error {
+	if identity.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if err := s.db.WithContext(ctx).Create(identity).Error(); err != nil {
+		return fmt.Errorf("failed to create identity: %v", err)
+	}
+	return nil
+}
+
+// DeleteIdentity deletes an identity of a program
+func (s *IdentityService) DeleteIdentity(ctx context.Context, programID, id uint) error {
+	if err := s.db.WithContext(ctx).Where("id = ? AND program_id = ?", id, programID).Delete(&requests.Identity{}).Error(); err != nil {
+		return fmt.Errorf("failed to delete identity: %v", err)
+	}
+	return nil
+}
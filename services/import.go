@@ -3,14 +3,19 @@ package services
 import (
 	"context"
 	"fmt"
+	"io"
 	"linn221/Requester/requests"
+	"log"
+	"os"
 	"strings"
+	"time"
 )
 
-// ImportService handles HAR file import operations
+// ImportService handles capture file import operations
 type ImportService struct {
 	db              Database
 	endpointService *EndpointService
+	tasks           chan importTask
 }
 
 // NewImportService creates a new ImportService
@@ -18,144 +23,356 @@ func NewImportService(db Database, endpointService *EndpointService) *ImportServ
 	return &ImportService{
 		db:              db,
 		endpointService: endpointService,
+		tasks:           make(chan importTask, importQueueSize),
 	}
 }
 
-// ImportHAR processes a HAR file import
-func (s *ImportService) ImportHAR(ctx context.Context, req ImportRequest) (*ImportResult, error) {
-	// Validate file extension
-	if !strings.HasSuffix(strings.ToLower(req.Filename), ".har") {
-		return nil, fmt.Errorf("file must be a .har file")
+// Import validates a capture file import and queues it for a background worker
+func (s *ImportService) Import(ctx context.Context, req ImportRequest) (*ImportResult, error) {
+	importer, ok := requests.LookupImporter(req.Format)
+	if !ok {
+		os.Remove(req.FilePath)
+		return nil, fmt.Errorf("%w: unsupported import format %q", ErrInvalid, req.Format)
 	}
 
-	// Create transaction with context
-	tx := s.db.WithContext(ctx).Begin()
-	if tx.Error() != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %v", tx.Error())
+	// Validate file extension
+	if !hasAnySuffix(strings.ToLower(req.Filename), importer.Extensions) {
+		os.Remove(req.FilePath)
+		return nil, fmt.Errorf("%w: %s file must have one of the extensions %s", ErrInvalid, req.Format, strings.Join(importer.Extensions, ", "))
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			panic(r)
-		}
-	}()
 
 	// Create ImportJob record
 	importJob := requests.ImportJob{
 		ProgramID:      &req.ProgramID,
 		Title:          req.Title,
 		IgnoredHeaders: strings.Join(req.IgnoredHeaders, ","),
+		IgnoredFields:  strings.Join(req.IgnoredFields, ","),
+		JobType:        importer.JobType,
+		Status:         requests.ImportJobStatusQueued,
 	}
 
 	// Save ImportJob to database
-	if err := tx.Create(&importJob).Error(); err != nil {
+	if err := s.db.WithContext(ctx).Create(&importJob).Error(); err != nil {
+		os.Remove(req.FilePath)
 		return nil, fmt.Errorf("failed to create import job: %v", err)
 	}
 
+	// Hand the job over to the worker pool
+	if err := s.enqueue(importTask{jobID: importJob.ID, req: req}); err != nil {
+		os.Remove(req.FilePath)
+		s.failJob(context.Background(), importJob.ID, err)
+		return nil, err
+	}
+
+	return &ImportResult{
+		ImportJobID: importJob.ID,
+		Status:      importJob.Status,
+	}, nil
+}
+
+// runImport streams the capture file of a queued job and stores its requests in batches
+func (s *ImportService) runImport(ctx context.Context, task importTask) error {
+	req := task.req
+	defer os.Remove(req.FilePath)
+
+	s.updateJob(ctx, task.jobID, map[string]interface{}{
+		"status":     requests.ImportJobStatusRunning,
+		"started_at": time.Now().Unix(),
+	})
+
 	// Create resHashFunc that uses ignored headers
-	resHashFunc := func(my *requests.TempMyRequest) (string, string) {
-		// Request text with filtered headers
-		reqText := my.URL + " " + my.Method + " " + my.ReqBody + " " + my.ReqHeaders.EchoFilter(req.IgnoredHeaders...)
+	resHashFunc := newHashFunc(req.IgnoredHeaders, req.IgnoredFields)
 
-		// Response text with filtered headers
-		respText := fmt.Sprintf("%d %d %s %s",
-			my.ResStatus, my.RespSize, my.ResBody, my.ResHeaders.EchoFilter(req.IgnoredHeaders...),
-		)
-		return reqText, respText
+	rules, err := loadProgramRules(ctx, s.db, req.ProgramID)
+	if err != nil {
+		return err
 	}
 
-	// Parse HAR file
-	tempResults, err := requests.ParseHAR(req.FileContent, resHashFunc)
+	file, err := os.Open(req.FilePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HAR file: %v", err)
+		return fmt.Errorf("failed to open uploaded file: %v", err)
+	}
+	defer file.Close()
+	reader := &countingReader{r: file}
+
+	stats := NewImportStats()
+	batch := make([]requests.MyRequest, 0, importBatchSize)
+	lastProgress := 0
+
+	importer, ok := requests.LookupImporter(req.Format)
+	if !ok {
+		return fmt.Errorf("unsupported import format %q", req.Format)
+	}
+
+	// Stream the capture file, flushing a batch whenever it is full
+	opts := requests.ImportOptions{
+		HashFunc:  resHashFunc,
+		Scheme:    req.Scheme,
+		Delimiter: req.Delimiter,
+		Variables: req.Variables,
+	}
+	source := importer.Source
+	if source == "" {
+		source = requests.EndpointSourceCapture
 	}
+	err = importer.Importer.Stream(reader, opts, func(tempReq requests.TempMyRequest) error {
+		outOfScope := !rules.scope.ContainsURL(tempReq.URL)
+		if outOfScope && req.DropOutOfScope {
+			stats.Dropped++
+			return nil
+		}
 
-	// Convert TempMyRequest to MyRequest and save to database
-	var dbResults []requests.MyRequest
-	for _, tempReq := range tempResults {
-		// Extract URI without query parameters
-		uri := requests.ExtractURIWithoutQuery(tempReq.URL)
+		// Extract URI without query parameters, templating IDs such as /users/{id}
+		uri := rules.endpointURI(tempReq.URL)
 
 		// Find or create endpoint
-		endpoint, err := s.endpointService.FindOrCreateEndpoint(ctx, req.ProgramID, tempReq.Method, tempReq.Domain, uri)
+		endpoint, err := s.endpointService.FindOrCreateEndpoint(ctx, req.ProgramID, source, tempReq.Method, tempReq.Domain, uri)
 		if err != nil {
-			return nil, fmt.Errorf("failed to find or create endpoint: %v", err)
+			return fmt.Errorf("failed to find or create endpoint: %v", err)
+		}
+
+		// Keep the collection folder of documented requests
+		if tempReq.Folder != "" {
+			if err := s.endpointService.AddEndpointNote(ctx, endpoint, "Folder: "+tempReq.Folder); err != nil {
+				return err
+			}
 		}
 
-		dbReq, err := tempReq.ToMyRequest(req.ProgramID, importJob.ID, endpoint.ID)
+		dbReq, err := tempReq.ToMyRequest(req.ProgramID, task.jobID, endpoint.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to convert request to database format: %v", err)
+			return fmt.Errorf("failed to convert request to database format: %v", err)
+		}
+		dbReq.OutOfScope = outOfScope
+		batch = append(batch, *dbReq)
+		stats.Add(tempReq, endpoint.ID)
+		if outOfScope {
+			stats.OutOfScope++
+		}
+
+		if len(batch) < importBatchSize {
+			return nil
 		}
-		dbResults = append(dbResults, *dbReq)
+		if err := s.saveBatch(ctx, batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+
+		// Report progress by bytes read, keeping 100 for the final batch
+		if req.FileSize > 0 {
+			if progress := int(reader.n * 99 / req.FileSize); progress > lastProgress {
+				lastProgress = progress
+				s.updateJob(ctx, task.jobID, map[string]interface{}{"progress": progress})
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		err = s.saveBatch(ctx, batch)
 	}
+	if err != nil {
+		s.deleteJobRequests(ctx, task.jobID)
+		return fmt.Errorf("failed to import %s file: %v", req.Format, err)
+	}
+
+	s.updateJob(ctx, task.jobID, map[string]interface{}{
+		"status":         requests.ImportJobStatusDone,
+		"progress":       100,
+		"summary":        stats.Summary(req.Title),
+		"request_count":  stats.Total,
+		"endpoint_count": len(stats.Endpoints),
+		"domain_count":   len(stats.Domains),
+		"finished_at":    time.Now().Unix(),
+	})
+	return nil
+}
 
-	// Save all requests to database in batch
-	if err := tx.CreateInBatches(dbResults, 100).Error(); err != nil {
-		return nil, fmt.Errorf("failed to save requests to database: %v", err)
+// saveBatch stores a batch of requests in its own transaction
+func (s *ImportService) saveBatch(ctx context.Context, batch []requests.MyRequest) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	// Create transaction with context
+	tx := s.db.WithContext(ctx).Begin()
+	if tx.Error() != nil {
+		return fmt.Errorf("failed to begin transaction: %v", tx.Error())
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := tx.CreateInBatches(batch, importBatchSize).Error(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to save requests to database: %v", err)
 	}
 
-	// Commit transaction
 	if err := tx.Commit().Error(); err != nil {
 		tx.Rollback()
-		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+		return fmt.Errorf("failed to commit transaction: %v", err)
 	}
+	return nil
+}
 
-	// Generate summary
-	summary := GenerateImportSummary(tempResults, req.Title)
+// deleteJobRequests removes the batches already stored by a failed import, along with the bodies only they used
+func (s *ImportService) deleteJobRequests(ctx context.Context, jobID uint) {
+	tx := s.db.WithContext(ctx).Begin()
+	if tx.Error() != nil {
+		log.Printf("[IMPORT] job %d: failed to begin transaction: %v", jobID, tx.Error())
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
 
-	return &ImportResult{
-		ImportJobID:   importJob.ID,
-		RequestCount:  len(tempResults),
-		UniqueDomains: CountUniqueDomains(tempResults),
-		Summary:       summary,
-	}, nil
+	var refs []string
+	if err := tx.Model(&requests.MyRequest{}).Where("import_job_id = ? AND res_body_ref <> ''", jobID).Distinct("res_body_ref").Pluck("res_body_ref", &refs).Error(); err != nil {
+		tx.Rollback()
+		log.Printf("[IMPORT] job %d: failed to list response bodies: %v", jobID, err)
+		return
+	}
+	if err := tx.Delete(&requests.MyRequest{}, "import_job_id = ?", jobID).Error(); err != nil {
+		tx.Rollback()
+		log.Printf("[IMPORT] job %d: failed to delete stored requests: %v", jobID, err)
+		return
+	}
+	if err := pruneBodies(tx, refs); err != nil {
+		tx.Rollback()
+		log.Printf("[IMPORT] job %d: %v", jobID, err)
+		return
+	}
+	if err := tx.Commit().Error(); err != nil {
+		tx.Rollback()
+		log.Printf("[IMPORT] job %d: failed to commit transaction: %v", jobID, err)
+	}
 }
 
-// GenerateImportSummary creates a summary of the import results
-func GenerateImportSummary(results []requests.TempMyRequest, title string) string {
-	var summary strings.Builder
-	summary.WriteString(fmt.Sprintf("HAR Import Summary for: %s\n", title))
-	summary.WriteString(fmt.Sprintf("Total Requests: %d\n", len(results)))
-	summary.WriteString(fmt.Sprintf("Unique Domains: %d\n", CountUniqueDomains(results)))
-	summary.WriteString("\nDomain Breakdown:\n")
+// failJob marks an import job as failed with the given error
+func (s *ImportService) failJob(ctx context.Context, jobID uint, err error) {
+	s.updateJob(ctx, jobID, map[string]interface{}{
+		"status":      requests.ImportJobStatusFailed,
+		"error":       err.Error(),
+		"finished_at": time.Now().Unix(),
+	})
+}
+
+// updateJob writes status fields of an import job outside of any import transaction
+func (s *ImportService) updateJob(ctx context.Context, jobID uint, values map[string]interface{}) {
+	s.db.WithContext(ctx).Model(&requests.ImportJob{ID: jobID}).Updates(values)
+}
 
-	domainCounts := make(map[string]int)
-	for _, req := range results {
-		domainCounts[req.Domain]++
+// ImportStats accumulates statistics of an import while requests are streamed
+type ImportStats struct {
+	Total       int
+	OutOfScope  int // stored but flagged as outside the program scope
+	Dropped     int // skipped as outside the program scope
+	NotRecorded int // captured but failed to store, too large or dropped under load
+	Domains     map[string]int
+	Methods     map[string]int
+	Statuses    map[int]int
+	Endpoints   map[uint]struct{}
+}
+
+// NewImportStats creates an empty ImportStats
+func NewImportStats() *ImportStats {
+	return &ImportStats{
+		Domains:   make(map[string]int),
+		Methods:   make(map[string]int),
+		Statuses:  make(map[int]int),
+		Endpoints: make(map[uint]struct{}),
 	}
+}
 
-	for domain, count := range domainCounts {
-		summary.WriteString(fmt.Sprintf("  %s: %d requests\n", domain, count))
+// Add records a single imported request
+func (st *ImportStats) Add(req requests.TempMyRequest, endpointID uint) {
+	st.Total++
+	st.Domains[req.Domain]++
+	st.Methods[req.Method]++
+	st.Statuses[req.ResStatus]++
+	st.Endpoints[endpointID] = struct{}{}
+}
+
+// Summary creates a summary of the import results
+func (st *ImportStats) Summary(title string) string {
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("Import Summary for: %s\n", title))
+	summary.WriteString(fmt.Sprintf("Total Requests: %d\n", st.Total))
+	summary.WriteString(fmt.Sprintf("Unique Domains: %d\n", len(st.Domains)))
+	if st.OutOfScope > 0 {
+		summary.WriteString(fmt.Sprintf("Out of Scope: %d requests (flagged)\n", st.OutOfScope))
 	}
+	if st.Dropped > 0 {
+		summary.WriteString(fmt.Sprintf("Out of Scope: %d requests (dropped)\n", st.Dropped))
+	}
+	if st.NotRecorded > 0 {
+		summary.WriteString(fmt.Sprintf("Not Recorded: %d requests (failed, too large or dropped under load)\n", st.NotRecorded))
+	}
+	summary.WriteString("\nDomain Breakdown:\n")
 
-	summary.WriteString("\nMethod Breakdown:\n")
-	methodCounts := make(map[string]int)
-	for _, req := range results {
-		methodCounts[req.Method]++
+	for domain, count := range st.Domains {
+		summary.WriteString(fmt.Sprintf("  %s: %d requests\n", domain, count))
 	}
 
-	for method, count := range methodCounts {
+	summary.WriteString("\nMethod Breakdown:\n")
+	for method, count := range st.Methods {
 		summary.WriteString(fmt.Sprintf("  %s: %d requests\n", method, count))
 	}
 
 	summary.WriteString("\nStatus Code Breakdown:\n")
-	statusCounts := make(map[int]int)
-	for _, req := range results {
-		statusCounts[req.ResStatus]++
-	}
-
-	for status, count := range statusCounts {
+	for status, count := range st.Statuses {
 		summary.WriteString(fmt.Sprintf("  %d: %d responses\n", status, count))
 	}
 
 	return summary.String()
 }
 
-// CountUniqueDomains counts unique domains in the results
-func CountUniqueDomains(results []requests.TempMyRequest) int {
-	domains := make(map[string]bool)
-	for _, req := range results {
-		domains[req.Domain] = true
+// hasAnySuffix reports whether s ends with one of the suffixes
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// countingReader counts the bytes read so far, used for progress reporting
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read reads from the underlying reader
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// newHashFunc returns the hash function of imported and replayed requests.
+// ignoredHeaders are left out of both hashes, ignoredFields out of JSON request and response bodies.
+func newHashFunc(ignoredHeaders, ignoredFields []string) func(*requests.TempMyRequest) (string, string) {
+	return func(my *requests.TempMyRequest) (string, string) {
+		reqBody, resBody, resSize := my.ReqBody, my.ResBody, my.RespSize
+		if len(ignoredFields) > 0 {
+			reqBody = requests.StripJSONFields(reqBody, ignoredFields)
+			resBody = requests.StripJSONFields(resBody, ignoredFields)
+			// ignored values may vary in length
+			resSize = len(resBody)
+		}
+
+		// Request text with filtered headers
+		reqText := my.URL + " " + my.Method + " " + reqBody + " " + my.ReqHeaders.EchoFilter(ignoredHeaders...)
+
+		// Response text with filtered headers
+		respText := fmt.Sprintf("%d %d %s %s",
+			my.ResStatus, resSize, resBody, my.ResHeaders.EchoFilter(ignoredHeaders...),
+		)
+		return reqText, respText
 	}
-	return len(domains)
 }
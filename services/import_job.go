@@ -38,7 +38,7 @@ func (s *ImportJobService) GetImportJobByID(ctx context.Context, id uint) (*requ
 	// Fetch import job by ID
 	var importJob requests.ImportJob
 	if err := tx.First(&importJob, id).Error(); err != nil {
-		return nil, fmt.Errorf("failed to fetch import job %d: %v", id, err)
+		return nil, fmt.Errorf("failed to fetch import job %d: %w", id, err)
 	}
 
 	return &importJob, nil
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"linn221/Requester/requests"
+	"log"
+	"time"
+)
+
+const (
+	// DefaultImportWorkers is the number of imports processed concurrently
+	DefaultImportWorkers = 2
+	importQueueSize      = 64
+	importBatchSize      = 100
+)
+
+// importTask is a queued import waiting for a worker
+type importTask struct {
+	jobID uint
+	req   ImportRequest
+}
+
+// StartWorkers starts the background workers that process queued imports.
+// Jobs left queued or running by a previous process are marked as failed,
+// since their uploaded files are no longer available.
+func (s *ImportService) StartWorkers(ctx context.Context, workers int) {
+	s.failInterruptedJobs(ctx)
+
+	for i := 0; i < workers; i++ {
+		go s.worker(ctx)
+	}
+}
+
+// worker processes import tasks until the context is cancelled
+func (s *ImportService) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case task := <-s.tasks:
+			s.process(ctx, task)
+		}
+	}
+}
+
+// process runs a single import task, recording panics and errors on the job
+func (s *ImportService) process(ctx context.Context, task importTask) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[IMPORT PANIC] job %d: %v", task.jobID, r)
+			s.failJob(ctx, task.jobID, fmt.Errorf("import crashed: %v", r))
+		}
+	}()
+
+	if err := s.runImport(ctx, task); err != nil {
+		log.Printf("[IMPORT FAILED] job %d: %v", task.jobID, err)
+		s.failJob(ctx, task.jobID, err)
+	}
+}
+
+// enqueue hands a task to the workers without blocking the caller
+func (s *ImportService) enqueue(task importTask) error {
+	select {
+	case s.tasks <- task:
+		return nil
+	default:
+		return fmt.Errorf("import queue is full, try again later")
+	}
+}
+
+// failInterruptedJobs marks unfinished jobs from a previous run as failed.
+// Capture jobs are left alone, they belong to the proxy process which keeps recording across server restarts.
+func (s *ImportService) failInterruptedJobs(ctx context.Context) {
+	s.db.WithContext(ctx).Model(&requests.ImportJob{}).
+		Where("status IN ?", []requests.ImportJobStatus{requests.ImportJobStatusQueued, requests.ImportJobStatusRunning}).
+		Where("job_type <> ?", requests.JobTypeCaptureProxy).
+		Updates(map[string]interface{}{
+			"status":      requests.ImportJobStatusFailed,
+			"error":       "import was interrupted by a server restart",
+			"finished_at": time.Now().Unix(),
+		})
+}
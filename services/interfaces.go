@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"linn221/Requester/requests"
 )
 
 // Database interface for database operations
@@ -34,7 +35,10 @@ type Tx interface {
 type Query interface {
 	Error() error
 	Order(value interface{}) Query
+	Limit(limit int) Query
+	Offset(offset int) Query
 	Find(dest interface{}) Query
+	FindInBatches(dest interface{}, batchSize int, fc func(batch int) error) Query
 	Count(count *int64) Query
 	Distinct(column string) Query
 	Pluck(column string, dest interface{}) Query
@@ -51,6 +55,7 @@ type Query interface {
 type HTTPRequest interface {
 	ParseMultipartForm(maxMemory int64) error
 	FormValue(name string) string
+	FormValues(name string) []string
 	FormFile(name string) (File, FileHeader, error)
 }
 
@@ -66,18 +71,24 @@ type FileHeader interface {
 }
 
 // ImportRequest represents the data needed for import
+// The uploaded file is spooled to FilePath so it can be streamed by a worker after the HTTP request ends.
 type ImportRequest struct {
 	ProgramID      uint
 	Title          string
+	Format         requests.ImportFormat
+	Scheme         string            // raw HTTP only, used with the Host header to rebuild URLs
+	Delimiter      string            // raw HTTP only, separates messages in a single file
+	Variables      map[string]string // collections only, values from an uploaded environment file
 	IgnoredHeaders []string
-	FileContent    []byte
+	IgnoredFields  []string // JSON body fields left out of the hashes, such as timestamps and CSRF tokens
+	DropOutOfScope bool     // skip requests outside the program scope instead of storing them flagged
+	FilePath       string
+	FileSize       int64
 	Filename       string
 }
 
-// ImportResult represents the result of an import operation
+// ImportResult represents the result of queueing an import operation
 type ImportResult struct {
-	ImportJobID   uint
-	RequestCount  int
-	UniqueDomains int
-	Summary       string
+	ImportJobID uint
+	Status      requests.ImportJobStatus
 }
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"linn221/Requester/requests"
+	"strings"
+)
+
+// MaxNoteResults caps the notes listed by the note search page
+const MaxNoteResults = 500
+
+// NoteService manages the notes of programs, endpoints and requests
+type NoteService struct {
+	db Database
+}
+
+// NewNoteService creates a new NoteService
+func NewNoteService(db Database) *NoteService {
+	return &NoteService{db: db}
+}
+
+// NoteFilter narrows the notes listed by SearchNotes, zero values match every note
+type NoteFilter struct {
+	ReferenceType requests.ReferenceType
+	Search        string
+}
+
+// CreateNote creates a note of an existing program, endpoint or request
+func (s *NoteService) CreateNote(ctx context.Context, note *requests.Note) error {
+	note.Value = strings.TrimSpace(note.Value)
+	if note.Value == "" {
+		return fmt.Errorf("%w: value is required", ErrInvalid)
+	}
+	if err := checkReference(ctx, s.db, note.ReferenceType, note.ReferenceID); err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Create(note).Error(); err != nil {
+		return fmt.Errorf("failed to create note: %v", err)
+	}
+	return nil
+}
+
+// GetNoteByID fetches a single note by ID
+func (s *NoteService) GetNoteByID(ctx context.Context, id uint) (*requests.Note, error) {
+	var note requests.Note
+	if err := s.db.WithContext(ctx).First(&note, id).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch note %d: %w", id, err)
+	}
+	return &note, nil
+}
+
+// UpdateNote replaces the value of a note
+func (s *NoteService) UpdateNote(ctx context.Context, id uint, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fmt.Errorf("%w: value is required", ErrInvalid)
+	}
+	if err := s.db.WithContext(ctx).Model(&requests.Note{}).Where("id = ?", id).Updates(map[string]interface{}{"value": value}).Error(); err != nil {
+		return fmt.Errorf("failed to update note %d: %v", id, err)
+	}
+	return nil
+}
+
+// DeleteNote deletes a note
+func (s *NoteService) DeleteNote(ctx context.Context, id uint) error {
+	if err := s.db.WithContext(ctx).Model(&requests.Note{}).Delete(&requests.Note{}, id).Error(); err != nil {
+		return fmt.Errorf("failed to delete note %d: %v", id, err)
+	}
+	return nil
+}
+
+// DeleteNotesOf deletes the notes of a program, endpoint or request being deleted
+func (s *NoteService) DeleteNotesOf(ctx context.Context, referenceType requests.ReferenceType, referenceID uint) error {
+	if err := s.db.WithContext(ctx).Where("reference_type = ? AND reference_id = ?", referenceType, referenceID).Delete(&requests.Note{}).Error(); err != nil {
+		return fmt.Errorf("failed to delete notes of %s %d: %v", referenceType, referenceID, err)
+	}
+	return nil
+}
+
+// GetNotes fetches the notes of a program, endpoint or request, oldest first
+func (s *NoteService) GetNotes(ctx context.Context, referenceType requests.ReferenceType, referenceID uint) ([]requests.Note, error) {
+	var notes []requests.Note
+	if err := s.db.WithContext(ctx).Where("reference_type = ? AND reference_id = ?", referenceType, referenceID).Order("created_at ASC, id ASC").Find(&notes).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch notes of %s %d: %v", referenceType, referenceID, err)
+	}
+	return notes, nil
+}
+
+// SearchNotes finds the notes matching filter across all programs, endpoints and requests, most recently
+// updated first. It returns the page of at most limit notes after offset, and the number of matching notes.
+func (s *NoteService) SearchNotes(ctx context.Context, filter NoteFilter, offset, limit int) ([]requests.Note, int64, error) {
+	query := s.db.WithContext(ctx).Model(&requests.Note{})
+	if filter.ReferenceType != "" {
+		query = query.Where("reference_type = ?", filter.ReferenceType)
+	}
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		query = query.Where("value LIKE ?", "%"+search+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error(); err != nil {
+		return nil, 0, fmt.Errorf("failed to count notes: %v", err)
+	}
+	var notes []requests.Note
+	if err := query.Order("updated_at DESC, id DESC").Offset(offset).Limit(limit).Find(&notes).Error(); err != nil {
+		return nil, 0, fmt.Errorf("failed to search notes: %v", err)
+	}
+	return notes, total, nil
+}
+
+// checkReference checks that the program, endpoint or request a note or attachment refers to exists
+func checkReference(ctx context.Context, db Database, referenceType requests.ReferenceType, referenceID uint) error {
+	var model interface{}
+	switch referenceType {
+	case requests.ReferencePrograms:
+		model = &requests.Program{}
+	case requests.ReferenceEndpoints:
+		model = &requests.Endpoint{}
+	case requests.ReferenceRequests:
+		model = &requests.MyRequest{}
+	default:
+		return fmt.Errorf("%w: reference_type must be programs, endpoints or requests", ErrInvalid)
+	}
+
+	var count int64
+	if err := db.WithContext(ctx).Model(model).Where("id = ?", referenceID).Count(&count).Error(); err != nil {
+		return fmt.Errorf("failed to check %s %d: %v", referenceType, referenceID, err)
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %s %d does not exist", ErrInvalid, referenceType, referenceID)
+	}
+	return nil
+}
@@ -3,8 +3,12 @@ package services
 import (
 	"fmt"
 	"io"
+	"linn221/Requester/requests"
+	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 // FormParser handles parsing of multipart form data
@@ -38,27 +42,396 @@ func (p *FormParser) ParseImportForm(r HTTPRequest) (*ImportRequest, error) {
 		return nil, fmt.Errorf("title is required")
 	}
 
-	ignoredHeadersText := r.FormValue("ignoredHeaders")
-	ignoredHeaders := strings.Fields(strings.ReplaceAll(ignoredHeadersText, "\n", " "))
+	format := requests.ImportFormat(r.FormValue("format"))
+	if format == "" {
+		format = requests.ImportFormatHAR
+	}
+
+	scheme := r.FormValue("scheme")
+	if scheme != "" && scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("scheme must be http or https")
+	}
+	delimiter := strings.TrimSpace(r.FormValue("delimiter"))
+
+	// The form sends ignored_headers and har_file, scripted uploads still use the original ignoredHeaders and harfile
+	ignoredHeaders := parseNameList(firstFormValue(r, "ignored_headers", "ignoredHeaders"))
+
+	// Optional environment file resolving collection variables
+	variables, err := parseEnvironmentFile(r)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get uploaded file
-	file, header, err := r.FormFile("harfile")
+	file, header, err := r.FormFile("har_file")
+	if err != nil {
+		file, header, err = r.FormFile("harfile")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get uploaded file: %v", err)
 	}
 	defer file.Close()
 
-	// Read file content
-	fileContent, err := io.ReadAll(file)
+	// Spool the upload to a temp file, the import worker streams it later
+	filePath, fileSize, err := spoolUpload(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %v", err)
+		return nil, err
 	}
 
 	return &ImportRequest{
 		ProgramID:      uint(programID),
 		Title:          title,
+		Format:         format,
+		Scheme:         scheme,
+		Delimiter:      delimiter,
+		Variables:      variables,
 		IgnoredHeaders: ignoredHeaders,
-		FileContent:    fileContent,
+		IgnoredFields:  parseNameList(r.FormValue("ignored_fields")),
+		DropOutOfScope: r.FormValue("drop_out_of_scope") == "true",
+		FilePath:       filePath,
+		FileSize:       fileSize,
+		Filename:       header.Filename(),
+	}, nil
+}
+
+// ParseHARUploadForm parses the HAR upload of the JSON API: a file field and a program_id,
+// with optional title, ignored_headers and ignored_fields
+func (p *FormParser) ParseHARUploadForm(r HTTPRequest) (*ImportRequest, error) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse form: %v", ErrInvalid, err)
+	}
+
+	programID, err := parseFormID(r.FormValue("program_id"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid program_id: %v", ErrInvalid, err)
+	}
+	if programID == 0 {
+		return nil, fmt.Errorf("%w: program_id is required", ErrInvalid)
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get uploaded file: %v", ErrInvalid, err)
+	}
+	defer file.Close()
+
+	filePath, fileSize, err := spoolUpload(file)
+	if err != nil {
+		return nil, err
+	}
+
+	title := strings.TrimSpace(r.FormValue("title"))
+	if title == "" {
+		title = header.Filename()
+	}
+	return &ImportRequest{
+		ProgramID:      programID,
+		Title:          title,
+		Format:         requests.ImportFormatHAR,
+		IgnoredHeaders: parseNameList(r.FormValue("ignored_headers")),
+		IgnoredFields:  parseNameList(r.FormValue("ignored_fields")),
+		FilePath:       filePath,
+		FileSize:       fileSize,
 		Filename:       header.Filename(),
 	}, nil
 }
+
+// ParseNoteForm parses a note of a program, endpoint or request
+func (p *FormParser) ParseNoteForm(r HTTPRequest) (*requests.Note, error) {
+	referenceType, referenceID, err := parseReference(r)
+	if err != nil {
+		return nil, err
+	}
+	return &requests.Note{
+		ReferenceType: referenceType,
+		ReferenceID:   referenceID,
+		Value:         r.FormValue("value"),
+	}, nil
+}
+
+// ParseAttachmentForm parses an attachment upload, the caller closes the uploaded file
+func (p *FormParser) ParseAttachmentForm(r HTTPRequest) (*AttachmentUpload, error) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse form: %v", ErrInvalid, err)
+	}
+	referenceType, referenceID, err := parseReference(r)
+	if err != nil {
+		return nil, err
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get uploaded file: %v", ErrInvalid, err)
+	}
+	return &AttachmentUpload{
+		ReferenceType: referenceType,
+		ReferenceID:   referenceID,
+		Filename:      header.Filename(),
+		File:          file,
+	}, nil
+}
+
+// parseReference parses the reference_type and reference_id fields naming the owner of a note or attachment
+func parseReference(r HTTPRequest) (requests.ReferenceType, uint, error) {
+	referenceType, ok := requests.ParseReferenceType(r.FormValue("reference_type"))
+	if !ok {
+		return "", 0, fmt.Errorf("%w: reference_type must be programs, endpoints or requests", ErrInvalid)
+	}
+	referenceID, err := parseFormID(r.FormValue("reference_id"))
+	if err != nil || referenceID == 0 {
+		return "", 0, fmt.Errorf("%w: invalid reference_id %q", ErrInvalid, r.FormValue("reference_id"))
+	}
+	return referenceType, referenceID, nil
+}
+
+// parseEnvironmentFile reads the variables of the optional env_file upload
+func parseEnvironmentFile(r HTTPRequest) (map[string]string, error) {
+	file, _, err := r.FormFile("env_file")
+	if err == http.ErrMissingFile {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get environment file: %v", err)
+	}
+	defer file.Close()
+
+	return requests.ParseEnvironment(file)
+}
+
+// spoolUpload copies an uploaded file to a temp file that outlives the HTTP request
+func spoolUpload(file File) (string, int64, error) {
+	tmp, err := os.CreateTemp("", "requester-import-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create temp file: %v", err)
+	}
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, file)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", 0, fmt.Errorf("failed to read file: %v", err)
+	}
+	return tmp.Name(), size, nil
+}
+
+// ParseReplayForm parses the edited request of the replay form.
+// Headers are entered one "Name: value" per line.
+func (p *FormParser) ParseReplayForm(r HTTPRequest) (*ReplayEdit, error) {
+	method := strings.ToUpper(strings.TrimSpace(r.FormValue("method")))
+	if method == "" {
+		return nil, fmt.Errorf("method is required")
+	}
+	rawURL := strings.TrimSpace(r.FormValue("url"))
+	if rawURL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+
+	headers, err := parseHeaderLines(r.FormValue("headers"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReplayEdit{
+		Method:  method,
+		URL:     rawURL,
+		Headers: headers,
+		Body:    strings.ReplaceAll(r.FormValue("body"), "\r\n", "\n"),
+	}, nil
+}
+
+// ParseFuzzForm parses the fuzz form, expanding the payload list, wordlist, number range and case variants.
+// The base request is taken from the URL and left for the caller to set.
+func (p *FormParser) ParseFuzzForm(r HTTPRequest) (*FuzzRequest, error) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return nil, fmt.Errorf("failed to parse form: %v", err)
+	}
+
+	var points []requests.InsertionPoint
+	for _, value := range r.FormValues("points") {
+		point, err := requests.ParseInsertionPoint(value)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+
+	spec := requests.PayloadSpec{CaseVariants: r.FormValue("case_variants") == "true"}
+	words, err := requests.ReadWordlist(strings.NewReader(r.FormValue("payloads")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payloads: %v", err)
+	}
+	spec.Words = words
+
+	file, _, err := r.FormFile("wordlist")
+	if err != nil && err != http.ErrMissingFile {
+		return nil, fmt.Errorf("failed to get wordlist: %v", err)
+	}
+	if file != nil {
+		defer file.Close()
+		words, err := requests.ReadWordlist(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read wordlist: %v", err)
+		}
+		spec.Words = append(spec.Words, words...)
+	}
+
+	if r.FormValue("range_step") != "" {
+		if spec.RangeFrom, err = strconv.Atoi(r.FormValue("range_from")); err != nil {
+			return nil, fmt.Errorf("invalid range start: %v", err)
+		}
+		if spec.RangeTo, err = strconv.Atoi(r.FormValue("range_to")); err != nil {
+			return nil, fmt.Errorf("invalid range end: %v", err)
+		}
+		if spec.RangeStep, err = strconv.Atoi(r.FormValue("range_step")); err != nil {
+			return nil, fmt.Errorf("invalid range step: %v", err)
+		}
+	}
+
+	payloads, err := spec.Payloads()
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := 0
+	if value := r.FormValue("concurrency"); value != "" {
+		if concurrency, err = strconv.Atoi(value); err != nil || concurrency < 1 {
+			return nil, fmt.Errorf("invalid concurrency %q", value)
+		}
+	}
+	rate := 0.0
+	if value := r.FormValue("rate"); value != "" {
+		if rate, err = strconv.ParseFloat(value, 64); err != nil || rate < 0 {
+			return nil, fmt.Errorf("invalid rate limit %q", value)
+		}
+	}
+
+	return &FuzzRequest{
+		Points:         points,
+		Payloads:       payloads,
+		IgnoredHeaders: parseNameList(r.FormValue("ignored_headers")),
+		Concurrency:    concurrency,
+		RatePerSecond:  rate,
+	}, nil
+}
+
+// parseHeaderLines parses headers entered one "Name: value" per line
+func parseHeaderLines(text string) (requests.HeaderSlice, error) {
+	var headers requests.HeaderSlice
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header line %q, expected Name: value", line)
+		}
+		headers = append(headers, requests.Header{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+	}
+	return headers, nil
+}
+
+// ParseIdentityForm parses the identity form of a program.
+// Headers to set are entered one "Name: value" per line, headers to remove one name per line.
+func (p *FormParser) ParseIdentityForm(r HTTPRequest, programID uint) (*requests.Identity, error) {
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	privilege := 0
+	if value := strings.TrimSpace(r.FormValue("privilege")); value != "" {
+		var err error
+		if privilege, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("invalid privilege %q", value)
+		}
+	}
+	headers, err := parseHeaderLines(r.FormValue("headers"))
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &requests.Identity{
+		ProgramID:     programID,
+		Name:          name,
+		Privilege:     privilege,
+		RemoveHeaders: strings.Join(strings.Fields(strings.ReplaceAll(r.FormValue("remove_headers"), ",", " ")), ","),
+	}
+	if err := identity.SetHeaders(headers); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// ParseAuthzForm parses the authorization run form
+func (p *FormParser) ParseAuthzForm(r HTTPRequest) (*AuthzRequest, error) {
+	req := &AuthzRequest{}
+	var err error
+	if req.ImportJobID, err = parseFormID(r.FormValue("import_job_id")); err != nil {
+		return nil, fmt.Errorf("invalid import job ID: %v", err)
+	}
+	if req.EndpointID, err = parseFormID(r.FormValue("endpoint_id")); err != nil {
+		return nil, fmt.Errorf("invalid endpoint ID: %v", err)
+	}
+	for _, value := range r.FormValues("identities") {
+		id, err := parseFormID(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid identity ID: %v", err)
+		}
+		req.IdentityIDs = append(req.IdentityIDs, id)
+	}
+
+	if value := strings.TrimSpace(r.FormValue("base_privilege")); value != "" {
+		privilege, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid privilege %q", value)
+		}
+		req.BasePrivilege = &privilege
+	}
+	if value := r.FormValue("concurrency"); value != "" {
+		if req.Concurrency, err = strconv.Atoi(value); err != nil || req.Concurrency < 1 {
+			return nil, fmt.Errorf("invalid concurrency %q", value)
+		}
+	}
+	return req, nil
+}
+
+// parseFormID parses an optional ID, empty values give 0
+func parseFormID(value string) (uint, error) {
+	if value = strings.TrimSpace(value); value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	return uint(id), err
+}
+
+// ParseRehashForm parses the rehash form of an import job or program
+func (p *FormParser) ParseRehashForm(r HTTPRequest) (*RehashRequest, error) {
+	req := &RehashRequest{
+		IgnoredHeaders: parseNameList(r.FormValue("ignored_headers")),
+		IgnoredFields:  parseNameList(r.FormValue("ignored_fields")),
+	}
+	var err error
+	if req.ImportJobID, err = parseFormID(r.FormValue("import_job_id")); err != nil {
+		return nil, fmt.Errorf("invalid import job ID: %v", err)
+	}
+	if req.ProgramID, err = parseFormID(r.FormValue("program_id")); err != nil {
+		return nil, fmt.Errorf("invalid program ID: %v", err)
+	}
+	return req, nil
+}
+
+// firstFormValue returns the value of the first of names that is set
+func firstFormValue(r HTTPRequest, names ...string) string {
+	for _, name := range names {
+		if value := r.FormValue(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseNameList splits a list of header or field names separated by lines, spaces or commas
+func parseNameList(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
@@ -4,16 +4,18 @@ import (
 	"context"
 	"fmt"
 	"linn221/Requester/requests"
+	"log"
 )
 
 // ProgramService handles program-related operations
 type ProgramService struct {
-	db Database
+	db                Database
+	attachmentService *AttachmentService
 }
 
-// NewProgramService creates a new ProgramService
-func NewProgramService(db Database) *ProgramService {
-	return &ProgramService{db: db}
+// NewProgramService creates a new ProgramService, removing the files of deleted programs with attachmentService
+func NewProgramService(db Database, attachmentService *AttachmentService) *ProgramService {
+	return &ProgramService{db: db, attachmentService: attachmentService}
 }
 
 // GetAllPrograms fetches all programs
@@ -38,7 +40,7 @@ func (s *ProgramService) GetProgramByID(ctx context.Context, id uint) (*requests
 	// Fetch program by ID
 	var program requests.Program
 	if err := tx.First(&program, id).Error(); err != nil {
-		return nil, fmt.Errorf("failed to fetch program %d: %v", id, err)
+		return nil, fmt.Errorf("failed to fetch program %d: %w", id, err)
 	}
 
 	return &program, nil
@@ -46,6 +48,13 @@ func (s *ProgramService) GetProgramByID(ctx context.Context, id uint) (*requests
 
 // CreateProgram creates a new program
 func (s *ProgramService) CreateProgram(ctx context.Context, program *requests.Program) error {
+	if _, err := program.ParseScope(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if _, err := program.PathNormalizer(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
 	// Create transaction with context
 	tx := s.db.WithContext(ctx).Begin()
 	if tx.Error() != nil {
@@ -73,6 +82,13 @@ func (s *ProgramService) CreateProgram(ctx context.Context, program *requests.Pr
 
 // UpdateProgram updates an existing program
 func (s *ProgramService) UpdateProgram(ctx context.Context, program *requests.Program) error {
+	if _, err := program.ParseScope(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if _, err := program.PathNormalizer(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
 	// Create transaction with context
 	tx := s.db.WithContext(ctx).Begin()
 	if tx.Error() != nil {
@@ -85,8 +101,8 @@ func (s *ProgramService) UpdateProgram(ctx context.Context, program *requests.Pr
 		}
 	}()
 
-	// Update the program
-	if err := tx.Model(program).Updates(program).Error(); err != nil {
+	// Update the program, selecting the fields so they can be cleared too
+	if err := tx.Model(program).Select("name", "url", "notes", "scope", "domains", "path_rules").Updates(program).Error(); err != nil {
 		return fmt.Errorf("failed to update program: %v", err)
 	}
 
@@ -98,7 +114,7 @@ func (s *ProgramService) UpdateProgram(ctx context.Context, program *requests.Pr
 	return nil
 }
 
-// DeleteProgram deletes a program by ID
+// DeleteProgram deletes a program by ID with its notes and attachments, the attachment files are removed after the commit
 func (s *ProgramService) DeleteProgram(ctx context.Context, id uint) error {
 	// Create transaction with context
 	tx := s.db.WithContext(ctx).Begin()
@@ -112,15 +128,84 @@ func (s *ProgramService) DeleteProgram(ctx context.Context, id uint) error {
 		}
 	}()
 
-	// Delete the program
+	var program requests.Program
+	if err := tx.Model(&requests.Program{}).First(&program, id).Error(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to fetch program %d: %w", id, err)
+	}
+	var attachments []requests.Attachment
+	if err := tx.Model(&requests.Attachment{}).Where("reference_type = ? AND reference_id = ?", requests.ReferencePrograms, id).Find(&attachments).Error(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to fetch attachments of program %d: %v", id, err)
+	}
+
+	// Delete the program with its notes and attachments
 	if err := tx.Delete(&requests.Program{}, id).Error(); err != nil {
+		tx.Rollback()
 		return fmt.Errorf("failed to delete program: %v", err)
 	}
+	if err := tx.Delete(&requests.Note{}, "reference_type = ? AND reference_id = ?", requests.ReferencePrograms, id).Error(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete notes of program %d: %v", id, err)
+	}
+	if err := tx.Delete(&requests.Attachment{}, "reference_type = ? AND reference_id = ?", requests.ReferencePrograms, id).Error(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete attachments of program %d: %v", id, err)
+	}
 
 	// Commit the transaction
 	if err := tx.Commit().Error(); err != nil {
 		return fmt.Errorf("failed to commit program deletion: %v", err)
 	}
 
+	// Files can't be restored by a rollback, so they go once the records are gone
+	s.attachmentService.removeFiles(attachments)
 	return nil
 }
+
+// GetProgramScopes parses the scope rules of every program, keyed by program ID
+func (s *ProgramService) GetProgramScopes(ctx context.Context) (map[uint]*requests.Scope, error) {
+	programs, err := s.GetAllPrograms(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make(map[uint]*requests.Scope, len(programs))
+	for _, program := range programs {
+		// Rules are validated when saved, a program that still fails to parse is treated as unscoped
+		if scope, err := program.ParseScope(); err == nil {
+			scopes[program.ID] = scope
+		}
+	}
+	return scopes, nil
+}
+
+// programRules are the parsed rules of a program applied to every request it stores
+type programRules struct {
+	scope      *requests.Scope
+	normalizer *requests.PathNormalizer
+}
+
+// loadProgramRules fetches a program and parses its scope and path rules
+func loadProgramRules(ctx context.Context, db Database, programID uint) (*programRules, error) {
+	var program requests.Program
+	if err := db.WithContext(ctx).First(&program, programID).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch program %d: %v", programID, err)
+	}
+	// Programs saved before scope rules were validated may hold free text, like GetProgramScopes they count as unscoped
+	scope, err := program.ParseScope()
+	if err != nil {
+		log.Printf("[SCOPE] program %d has unparsable scope rules, treating it as unscoped: %v", programID, err)
+		scope = nil
+	}
+	normalizer, err := program.PathNormalizer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse path rules of program %d: %v", programID, err)
+	}
+	return &programRules{scope: scope, normalizer: normalizer}, nil
+}
+
+// endpointURI returns the templated path of the endpoint a request URL belongs to
+func (r *programRules) endpointURI(rawURL string) string {
+	return r.normalizer.Normalize(requests.ExtractURIWithoutQuery(rawURL))
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"linn221/Requester/requests"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RegroupService merges the captured endpoints of a program into templated endpoints such as /users/{id}
+type RegroupService struct {
+	db              Database
+	endpointService *EndpointService
+}
+
+// NewRegroupService creates a new RegroupService
+func NewRegroupService(db Database, endpointService *EndpointService) *RegroupService {
+	return &RegroupService{
+		db:              db,
+		endpointService: endpointService,
+	}
+}
+
+// maintenanceJobTypes rewrite the endpoints or hashes of a program's stored requests, only one of them runs per program at a time
+var maintenanceJobTypes = []requests.JobType{requests.JobTypeRegroup, requests.JobTypeRehash}
+
+// maintenanceMu serializes starting maintenance jobs, so two starts can't both find no running job
+var maintenanceMu sync.Mutex
+
+// createMaintenanceJob creates a running regroup or rehash job, refusing while another one runs for the same program
+func createMaintenanceJob(ctx context.Context, db Database, job *requests.ImportJob) error {
+	maintenanceMu.Lock()
+	defer maintenanceMu.Unlock()
+
+	if job.ProgramID != nil {
+		var running int64
+		if err := db.WithContext(ctx).Model(&requests.ImportJob{}).Where("program_id = ? AND job_type IN ? AND status = ?",
+			*job.ProgramID, maintenanceJobTypes, requests.ImportJobStatusRunning).Count(&running).Error(); err != nil {
+			return fmt.Errorf("failed to check the running jobs of program %d: %v", *job.ProgramID, err)
+		}
+		if running > 0 {
+			return fmt.Errorf("%w: a regroup or rehash of program %d is still running", ErrConflict, *job.ProgramID)
+		}
+	}
+	if err := db.WithContext(ctx).Create(job).Error(); err != nil {
+		return fmt.Errorf("failed to create %s job: %v", job.JobType, err)
+	}
+	return nil
+}
+
+// Start records a regroup of the program's endpoints as a job and runs it in the background.
+// It is refused while a regroup or rehash of the program is running.
+func (s *RegroupService) Start(ctx context.Context, programID uint) (*requests.ImportJob, error) {
+	rules, err := loadProgramRules(ctx, s.db, programID)
+	if err != nil {
+		return nil, err
+	}
+
+	job := requests.ImportJob{
+		ProgramID: &programID,
+		Title:     "Regroup endpoints " + time.Now().Format("2006-01-02 15:04"),
+		JobType:   requests.JobTypeRegroup,
+		Status:    requests.ImportJobStatusRunning,
+		StartedAt: time.Now().Unix(),
+	}
+	if err := createMaintenanceJob(ctx, s.db, &job); err != nil {
+		return nil, err
+	}
+
+	go s.run(context.Background(), job.ID, programID, rules.normalizer)
+	return &job, nil
+}
+
+// run templates the path of every captured endpoint, moving its requests and notes to the
+// templated endpoint and deleting it. Documented endpoints keep their paths.
+func (s *RegroupService) run(ctx context.Context, jobID, programID uint, normalizer *requests.PathNormalizer) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[REGROUP PANIC] job %d: %v", jobID, r)
+			s.finishJob(ctx, jobID, fmt.Errorf("regroup crashed: %v", r), "", 0, 0)
+		}
+	}()
+
+	endpoints, err := s.endpointService.GetEndpointsByProgram(ctx, programID)
+	if err != nil {
+		s.finishJob(ctx, jobID, err, "", 0, 0)
+		return
+	}
+
+	merged := make(map[uint]int) // requests moved into each templated endpoint
+	templates := make(map[uint]string)
+	moved, lastProgress := 0, 0
+	for i, endpoint := range endpoints {
+		uri := normalizer.Normalize(endpoint.URI)
+		if endpoint.Source.IsDocumented() || uri == endpoint.URI {
+			continue
+		}
+
+		target, err := s.endpointService.FindOrCreateEndpoint(ctx, programID, endpoint.Source, endpoint.Method, endpoint.Domain, uri)
+		if err != nil {
+			s.finishJob(ctx, jobID, err, "", moved, len(merged))
+			return
+		}
+		count, err := s.mergeEndpoint(ctx, endpoint, target)
+		if err != nil {
+			s.finishJob(ctx, jobID, err, "", moved, len(merged))
+			return
+		}
+		moved += count
+		merged[target.ID] += count
+		templates[target.ID] = target.Method + " " + target.Domain + target.URI
+
+		if progress := (i + 1) * 99 / len(endpoints); progress > lastProgress {
+			lastProgress = progress
+			s.db.WithContext(ctx).Model(&requests.ImportJob{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+				"progress":       progress,
+				"request_count":  moved,
+				"endpoint_count": len(merged),
+			})
+		}
+	}
+
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("Regrouped %d requests into %d templated endpoints\n", moved, len(merged)))
+	for id, count := range merged {
+		summary.WriteString(fmt.Sprintf("  %s: %d requests\n", templates[id], count))
+	}
+	s.finishJob(ctx, jobID, nil, summary.String(), moved, len(merged))
+}
+
+// mergeEndpoint re-points the requests, notes and attachments of endpoint to target, keeps its inline notes and deletes it,
+// all in one transaction. It returns the number of requests moved.
+func (s *RegroupService) mergeEndpoint(ctx context.Context, endpoint requests.Endpoint, target *requests.Endpoint) (int, error) {
+	tx := s.db.WithContext(ctx).Begin()
+	if tx.Error() != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", tx.Error())
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	var count int64
+	if err := tx.Model(&requests.MyRequest{}).Where("endpoint_id = ?", endpoint.ID).Count(&count).Error(); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to count requests of endpoint %d: %v", endpoint.ID, err)
+	}
+	notes := target.Notes
+	for _, note := range strings.Split(endpoint.Notes, "\n") {
+		notes = appendNote(notes, strings.TrimSpace(note))
+	}
+	if notes != target.Notes {
+		if err := tx.Model(&requests.Endpoint{}).Where("id = ?", target.ID).Updates(map[string]interface{}{"notes": notes}).Error(); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to update endpoint notes: %v", err)
+		}
+	}
+
+	if err := tx.Model(&requests.MyRequest{}).Where("endpoint_id = ?", endpoint.ID).Updates(map[string]interface{}{"endpoint_id": target.ID}).Error(); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to move requests of endpoint %d: %v", endpoint.ID, err)
+	}
+	// The notes and attachments of the merged endpoint now belong to the target
+	for _, model := range []interface{}{&requests.Note{}, &requests.Attachment{}} {
+		if err := tx.Model(model).Where("reference_type = ? AND reference_id = ?", requests.ReferenceEndpoints, endpoint.ID).
+			Updates(map[string]interface{}{"reference_id": target.ID}).Error(); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to move notes and attachments of endpoint %d: %v", endpoint.ID, err)
+		}
+	}
+	if err := tx.Delete(&requests.Endpoint{}, endpoint.ID).Error(); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to delete endpoint %d: %v", endpoint.ID, err)
+	}
+	if err := tx.Commit().Error(); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	target.Notes = notes
+	return int(count), nil
+}
+
+// finishJob records the outcome of a regroup
+func (s *RegroupService) finishJob(ctx context.Context, jobID uint, runErr error, summary string, requestCount, endpointCount int) {
+	updates := map[string]interface{}{
+		"status":         requests.ImportJobStatusDone,
+		"progress":       100,
+		"summary":        summary,
+		"request_count":  requestCount,
+		"endpoint_count": endpointCount,
+		"finished_at":    time.Now().Unix(),
+	}
+	if runErr != nil {
+		updates["status"] = requests.ImportJobStatusFailed
+		updates["error"] = runErr.Error()
+	}
+	s.db.WithContext(ctx).Model(&requests.ImportJob{}).Where("id = ?", jobID).Updates(updates)
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"linn221/Requester/requests"
+	"log"
+	"strings"
+	"time"
+)
+
+// rehashBatchSize is the number of requests read and updated per transaction
+const rehashBatchSize = 500
+
+// RehashService recomputes the hashes of stored requests with a new list of ignored headers and body fields
+type RehashService struct {
+	db Database
+}
+
+// NewRehashService creates a new RehashService
+func NewRehashService(db Database) *RehashService {
+	return &RehashService{db: db}
+}
+
+// RehashRequest selects the requests to rehash and the new ignore lists.
+// Set one of ImportJobID and ProgramID, a program rehashes the requests of all its jobs.
+type RehashRequest struct {
+	ImportJobID    uint
+	ProgramID      uint
+	IgnoredHeaders []string
+	IgnoredFields  []string
+}
+
+// Start records a rehash as a job and runs it in the background, refused while a regroup or rehash of the program is running.
+// The rehashed jobs keep the new ignore lists, so later replays hash the same way.
+func (s *RehashService) Start(ctx context.Context, req RehashRequest) (*requests.ImportJob, error) {
+	var jobs []requests.ImportJob
+	var programID *uint
+	var title string
+	switch {
+	case req.ImportJobID != 0:
+		var job requests.ImportJob
+		if err := s.db.WithContext(ctx).First(&job, req.ImportJobID).Error(); err != nil {
+			return nil, fmt.Errorf("failed to fetch import job %d: %v", req.ImportJobID, err)
+		}
+		if !job.IsFinished() {
+			return nil, fmt.Errorf("import job %d is still running", job.ID)
+		}
+		jobs = []requests.ImportJob{job}
+		programID = job.ProgramID
+		title = "Rehash " + job.Title
+	case req.ProgramID != 0:
+		var program requests.Program
+		if err := s.db.WithContext(ctx).First(&program, req.ProgramID).Error(); err != nil {
+			return nil, fmt.Errorf("failed to fetch program %d: %v", req.ProgramID, err)
+		}
+		// Earlier rehash and regroup jobs store no requests of their own
+		if err := s.db.WithContext(ctx).Where("program_id = ? AND job_type NOT IN ? AND status IN ?", program.ID,
+			[]requests.JobType{requests.JobTypeRehash, requests.JobTypeRegroup},
+			[]requests.ImportJobStatus{requests.ImportJobStatusDone, requests.ImportJobStatusFailed}).
+			Order("id ASC").Find(&jobs).Error(); err != nil {
+			return nil, fmt.Errorf("failed to fetch import jobs of program %d: %v", program.ID, err)
+		}
+		programID = &program.ID
+		title = "Rehash program " + program.Name
+	default:
+		return nil, fmt.Errorf("an import job or program is required")
+	}
+
+	job := requests.ImportJob{
+		ProgramID:      programID,
+		Title:          title,
+		IgnoredHeaders: strings.Join(req.IgnoredHeaders, ","),
+		IgnoredFields:  strings.Join(req.IgnoredFields, ","),
+		JobType:        requests.JobTypeRehash,
+		Status:         requests.ImportJobStatusRunning,
+		StartedAt:      time.Now().Unix(),
+	}
+	if err := createMaintenanceJob(ctx, s.db, &job); err != nil {
+		return nil, err
+	}
+
+	go s.run(context.Background(), job.ID, jobs, req)
+	return &job, nil
+}
+
+// rehashCounts tracks how many requests a rehash went through and how many hashes it changed
+type rehashCounts struct {
+	requests       int
+	requestHashes  int
+	responseHashes int
+	unreadable     int
+}
+
+// run rehashes the requests of every job in batches, storing the new ignore lists on each job once its requests are done
+func (s *RehashService) run(ctx context.Context, jobID uint, jobs []requests.ImportJob, req RehashRequest) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[REHASH PANIC] job %d: %v", jobID, r)
+			s.finishJob(ctx, jobID, fmt.Errorf("rehash crashed: %v", r), "", 0)
+		}
+	}()
+
+	jobIDs := make([]uint, 0, len(jobs))
+	for _, job := range jobs {
+		jobIDs = append(jobIDs, job.ID)
+	}
+	var total int64
+	if len(jobIDs) > 0 {
+		if err := s.db.WithContext(ctx).Model(&requests.MyRequest{}).Where("import_job_id IN ?", jobIDs).Count(&total).Error(); err != nil {
+			s.finishJob(ctx, jobID, fmt.Errorf("failed to count requests: %v", err), "", 0)
+			return
+		}
+	}
+
+	// Jobs are rehashed one after the other and take the new ignore lists as soon as their requests are done,
+	// so a failure leaves every job consistent with its lists except the one it stopped in
+	hashFunc := newHashFunc(req.IgnoredHeaders, req.IgnoredFields)
+	counts := rehashCounts{}
+	lastProgress := 0
+	for done, job := range jobs {
+		var lastID uint
+		for total > 0 {
+			var batch []requests.MyRequest
+			if err := s.db.WithContext(ctx).Where("import_job_id = ? AND id > ?", job.ID, lastID).
+				Order("id ASC").Limit(rehashBatchSize).Find(&batch).Error(); err != nil {
+				s.finishJob(ctx, jobID, partialRehashError(job.ID, done, fmt.Errorf("failed to fetch requests: %v", err)), "", counts.requests)
+				return
+			}
+			if len(batch) == 0 {
+				break
+			}
+			if err := s.rehashBatch(ctx, batch, hashFunc, &counts); err != nil {
+				s.finishJob(ctx, jobID, partialRehashError(job.ID, done, err), "", counts.requests)
+				return
+			}
+			lastID = batch[len(batch)-1].ID
+
+			if progress := int(int64(counts.requests) * 99 / total); progress > lastProgress {
+				lastProgress = progress
+				s.db.WithContext(ctx).Model(&requests.ImportJob{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+					"progress":      progress,
+					"request_count": counts.requests,
+				})
+			}
+		}
+
+		if err := s.db.WithContext(ctx).Model(&requests.ImportJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"ignored_headers": strings.Join(req.IgnoredHeaders, ","),
+			"ignored_fields":  strings.Join(req.IgnoredFields, ","),
+		}).Error(); err != nil {
+			s.finishJob(ctx, jobID, partialRehashError(job.ID, done, fmt.Errorf("failed to update ignore lists: %v", err)), "", counts.requests)
+			return
+		}
+	}
+
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("Rehashed %d requests of %d import jobs\n", counts.requests, len(jobs)))
+	summary.WriteString(fmt.Sprintf("Request hashes changed: %d\n", counts.requestHashes))
+	summary.WriteString(fmt.Sprintf("Response hashes changed: %d\n", counts.responseHashes))
+	if counts.unreadable > 0 {
+		summary.WriteString(fmt.Sprintf("Skipped with unreadable headers: %d\n", counts.unreadable))
+	}
+	summary.WriteString(fmt.Sprintf("Ignored headers: %s\n", strings.Join(req.IgnoredHeaders, ", ")))
+	summary.WriteString(fmt.Sprintf("Ignored body fields: %s\n", strings.Join(req.IgnoredFields, ", ")))
+	s.finishJob(ctx, jobID, nil, summary.String(), counts.requests)
+}
+
+// partialRehashError records how far a failed rehash got: the jobs before the one it stopped in are rehashed
+// with the new ignore lists, that one is partly rehashed and keeps its old lists
+func partialRehashError(stoppedJobID uint, doneJobs int, err error) error {
+	return fmt.Errorf("%v (%d import jobs were rehashed, import job %d is partly rehashed and keeps its old ignore lists)", err, doneJobs, stoppedJobID)
+}
+
+// rehashBatch recomputes the hashes of a batch, updating the rows whose hashes changed in one transaction
+func (s *RehashService) rehashBatch(ctx context.Context, batch []requests.MyRequest, hashFunc func(*requests.TempMyRequest) (string, string), counts *rehashCounts) error {
+	tx := s.db.WithContext(ctx).Begin()
+	if tx.Error() != nil {
+		return fmt.Errorf("failed to begin transaction: %v", tx.Error())
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	for _, stored := range batch {
+		counts.requests++
+		temp, err := stored.ToTempMyRequest()
+		if err != nil {
+			counts.unreadable++
+			continue
+		}
+		temp.ApplyHashes(hashFunc)
+		if temp.ReqHash == stored.ReqHash && temp.ResHash == stored.ResHash {
+			continue
+		}
+		if temp.ReqHash != stored.ReqHash {
+			counts.requestHashes++
+		}
+		if temp.ResHash != stored.ResHash {
+			counts.responseHashes++
+		}
+
+		if err := tx.Model(&requests.MyRequest{}).Where("id = ?", stored.ID).Updates(map[string]interface{}{
+			"req_hash": temp.ReqHash,
+			"res_hash": temp.ResHash,
+		}).Error(); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to update hashes of request %d: %v", stored.ID, err)
+		}
+	}
+
+	if err := tx.Commit().Error(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// finishJob records the outcome of a rehash
+func (s *RehashService) finishJob(ctx context.Context, jobID uint, runErr error, summary string, count int) {
+	updates := map[string]interface{}{
+		"status":        requests.ImportJobStatusDone,
+		"progress":      100,
+		"summary":       summary,
+		"request_count": count,
+		"finished_at":   time.Now().Unix(),
+	}
+	if runErr != nil {
+		updates["status"] = requests.ImportJobStatusFailed
+		updates["error"] = runErr.Error()
+	}
+	s.db.WithContext(ctx).Model(&requests.ImportJob{}).Where("id = ?", jobID).Updates(updates)
+}
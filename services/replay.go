@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"linn221/Requester/requests"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// replayTimeout bounds a single replayed request
+const replayTimeout = 30 * time.Second
+
+// ReplayService resends stored requests and stores each response as a new linked record
+type ReplayService struct {
+	db              Database
+	endpointService *EndpointService
+	client          *http.Client
+
+	mu sync.Mutex // serializes storing replays, so each original gets a single replay job with consecutive sequences
+}
+
+// NewReplayService creates a new ReplayService sending requests with client
+func NewReplayService(db Database, endpointService *EndpointService, client *http.Client) *ReplayService {
+	return &ReplayService{
+		db:              db,
+		endpointService: endpointService,
+		client:          client,
+	}
+}
+
+// NewReplayClient returns the client used for replays.
+// Like an intercepting proxy it does not follow redirects and accepts any certificate, targets often run self-signed ones.
+func NewReplayClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   replayTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// ReplayEdit is the request as edited by the user before it is sent
+type ReplayEdit struct {
+	Method  string
+	URL     string
+	Headers requests.HeaderSlice
+	Body    string
+}
+
+// Replay sends edit in place of the original request and stores the result linked to it.
+// Replays are kept out of the original's import job, each original gets a replay job of its own in the same program.
+// The replay is hashed with the ignored headers and fields of the original's job, so its ResHash and ResBodyHash
+// compare directly with the original's.
+func (s *ReplayService) Replay(ctx context.Context, originalID uint, edit ReplayEdit) (*requests.MyRequest, error) {
+	var original requests.MyRequest
+	if err := s.db.WithContext(ctx).First(&original, originalID).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch request with ID %d: %v", originalID, err)
+	}
+	var importJob requests.ImportJob
+	if err := s.db.WithContext(ctx).First(&importJob, original.ImportJobID).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch import job %d: %v", original.ImportJobID, err)
+	}
+
+	u, err := url.Parse(edit.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %v", edit.URL, err)
+	}
+	temp := requests.TempMyRequest{
+		URL:        edit.URL,
+		Method:     edit.Method,
+		Domain:     u.Hostname(),
+		ReqHeaders: edit.Headers,
+		ReqBody:    edit.Body,
+	}
+
+	httpReq, err := temp.NewHTTPRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	started := time.Now()
+	res, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer res.Body.Close()
+	body, err := requests.ReadResponseBody(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	temp.SetResponse(res, body, started, time.Since(started))
+	temp.ApplyHashes(newHashFunc(importJob.IgnoredHeaderNames(), importJob.IgnoredFieldNames()))
+
+	var programID uint
+	uri := requests.ExtractURIWithoutQuery(temp.URL)
+	if original.ProgramID != nil {
+		programID = *original.ProgramID
+		rules, err := loadProgramRules(ctx, s.db, programID)
+		if err != nil {
+			return nil, err
+		}
+		uri = rules.endpointURI(temp.URL)
+	}
+	endpoint, err := s.endpointService.FindOrCreateEndpoint(ctx, programID, requests.EndpointSourceCapture, temp.Method, temp.Domain, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find or create endpoint: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, err := s.replayJob(ctx, original, importJob)
+	if err != nil {
+		return nil, err
+	}
+	temp.Sequence = job.RequestCount + 1
+	replay, err := temp.ToMyRequest(programID, job.ID, endpoint.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert request to database format: %v", err)
+	}
+	replay.ReplayOfID = &original.ID
+	if err := s.db.WithContext(ctx).Create(replay).Error(); err != nil {
+		return nil, fmt.Errorf("failed to store replay: %v", err)
+	}
+	s.updateJobCounts(ctx, job.ID)
+	return replay, nil
+}
+
+// replayJob returns the job holding the replays of original, creating it on the first replay
+// with the program and hashing settings of the original's import job
+func (s *ReplayService) replayJob(ctx context.Context, original requests.MyRequest, importJob requests.ImportJob) (requests.ImportJob, error) {
+	var jobs []requests.ImportJob
+	if err := s.db.WithContext(ctx).Where("job_type = ? AND id IN (SELECT import_job_id FROM my_requests WHERE replay_of_id = ?)",
+		requests.JobTypeReplay, original.ID).Order("id ASC").Limit(1).Find(&jobs).Error(); err != nil {
+		return requests.ImportJob{}, fmt.Errorf("failed to fetch replay job of request %d: %v", original.ID, err)
+	}
+	if len(jobs) > 0 {
+		return jobs[0], nil
+	}
+
+	now := time.Now().Unix()
+	job := requests.ImportJob{
+		ProgramID:      original.ProgramID,
+		Title:          fmt.Sprintf("Replays of #%d %s %s", original.ID, original.Method, original.URL),
+		IgnoredHeaders: importJob.IgnoredHeaders,
+		IgnoredFields:  importJob.IgnoredFields,
+		JobType:        requests.JobTypeReplay,
+		Status:         requests.ImportJobStatusDone,
+		Progress:       100,
+		StartedAt:      now,
+		FinishedAt:     now,
+	}
+	if err := s.db.WithContext(ctx).Create(&job).Error(); err != nil {
+		return requests.ImportJob{}, fmt.Errorf("failed to create replay job: %v", err)
+	}
+	return job, nil
+}
+
+// updateJobCounts recounts the requests, endpoints and domains of a replay job for the job list
+func (s *ReplayService) updateJobCounts(ctx context.Context, jobID uint) {
+	var requestCount, endpointCount, domainCount int64
+	s.db.WithContext(ctx).Model(&requests.MyRequest{}).Where("import_job_id = ?", jobID).Count(&requestCount)
+	s.db.WithContext(ctx).Model(&requests.MyRequest{}).Where("import_job_id = ?", jobID).Distinct("endpoint_id").Count(&endpointCount)
+	s.db.WithContext(ctx).Model(&requests.MyRequest{}).Where("import_job_id = ?", jobID).Distinct("domain").Count(&domainCount)
+	s.db.WithContext(ctx).Model(&requests.ImportJob{ID: jobID}).Updates(map[string]interface{}{
+		"request_count":  requestCount,
+		"endpoint_count": endpointCount,
+		"domain_count":   domainCount,
+	})
+}
+
+// GetReplays fetches the replays of a request, newest first
+func (s *ReplayService) GetReplays(ctx context.Context, originalID uint) ([]requests.MyRequest, error) {
+	var replays []requests.MyRequest
+	if err := s.db.WithContext(ctx).Where("replay_of_id = ?", originalID).Order("id DESC").Find(&replays).Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch replays of request %d: %v", originalID, err)
+	}
+	return replays, nil
+}
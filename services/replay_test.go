@@ -0,0 +1,171 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"linn221/Requester/internal/testdb"
+	"linn221/Requester/requests"
+	"linn221/Requester/services"
+)
+
+func TestReplayStoresResponseAndDiff(t *testing.T) {
+	var gotAuth, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"id":7,"role":"guest"}`)
+	}))
+	defer server.Close()
+
+	db := testdb.Open(t)
+	ctx := context.Background()
+	program := requests.Program{Name: "test"}
+	if err := db.Create(&program).Error; err != nil {
+		t.Fatal(err)
+	}
+	job := requests.ImportJob{ProgramID: &program.ID, Title: "original", Status: requests.ImportJobStatusDone}
+	if err := db.Create(&job).Error; err != nil {
+		t.Fatal(err)
+	}
+	endpoint := requests.Endpoint{ProgramID: &program.ID, Method: "POST", Domain: "127.0.0.1", URI: "/users/7"}
+	if err := db.Create(&endpoint).Error; err != nil {
+		t.Fatal(err)
+	}
+	original := requests.MyRequest{
+		ProgramID:   &program.ID,
+		ImportJobID: job.ID,
+		EndpointID:  endpoint.ID,
+		Sequence:    3,
+		URL:         server.URL + "/users/7",
+		Method:      "POST",
+		Domain:      "127.0.0.1",
+		ReqHeaders:  `[{"name":"Authorization","value":"Bearer admin"}]`,
+		ReqBody:     `{"name":"x"}`,
+		ResStatus:   http.StatusOK,
+		ResBody:     `{"id":7,"role":"admin"}`,
+	}
+	if err := db.Create(&original).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	database := services.NewGormDatabaseAdapter(db)
+	replayService := services.NewReplayService(database, services.NewEndpointService(database), server.Client())
+	replay, err := replayService.Replay(ctx, original.ID, services.ReplayEdit{
+		Method:  "POST",
+		URL:     server.URL + "/users/7",
+		Headers: requests.HeaderSlice{{Name: "Authorization", Value: "Bearer guest"}},
+		Body:    `{"name":"y"}`,
+	})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if gotAuth != "Bearer guest" || gotBody != `{"name":"y"}` {
+		t.Errorf("server received Authorization %q and body %q, want the edited request", gotAuth, gotBody)
+	}
+
+	var stored requests.MyRequest
+	if err := db.First(&stored, replay.ID).Error; err != nil {
+		t.Fatalf("failed to fetch stored replay: %v", err)
+	}
+	if stored.ReplayOfID == nil || *stored.ReplayOfID != original.ID {
+		t.Errorf("ReplayOfID = %v, want %d", stored.ReplayOfID, original.ID)
+	}
+	if stored.ImportJobID == job.ID || stored.Sequence != 1 || stored.ProgramID == nil || *stored.ProgramID != program.ID {
+		t.Errorf("replay stored with job %d sequence %d, want sequence 1 of a replay job in program %d", stored.ImportJobID, stored.Sequence, program.ID)
+	}
+	var replayJob requests.ImportJob
+	if err := db.First(&replayJob, stored.ImportJobID).Error; err != nil {
+		t.Fatalf("failed to fetch replay job: %v", err)
+	}
+	if replayJob.JobType != requests.JobTypeReplay || replayJob.RequestCount != 1 {
+		t.Errorf("replay job has type %q and %d requests, want a replay job with 1 request", replayJob.JobType, replayJob.RequestCount)
+	}
+	if stored.ResStatus != http.StatusForbidden {
+		t.Errorf("ResStatus = %d, want %d", stored.ResStatus, http.StatusForbidden)
+	}
+	if stored.ResBody != `{"id":7,"role":"guest"}` {
+		t.Errorf("ResBody = %q", stored.ResBody)
+	}
+	if stored.ResHash == "" || stored.ResBodyHash == "" {
+		t.Error("replay stored without response hashes")
+	}
+
+	second, err := replayService.Replay(ctx, original.ID, services.ReplayEdit{Method: "POST", URL: server.URL + "/users/7"})
+	if err != nil {
+		t.Fatalf("second Replay: %v", err)
+	}
+	if second.ImportJobID != stored.ImportJobID || second.Sequence != 2 {
+		t.Errorf("second replay stored with job %d sequence %d, want job %d sequence 2", second.ImportJobID, second.Sequence, stored.ImportJobID)
+	}
+
+	replays, err := replayService.GetReplays(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("GetReplays: %v", err)
+	}
+	if len(replays) != 2 || replays[0].ID != second.ID || replays[1].ID != replay.ID {
+		t.Errorf("GetReplays returned %d replays, want %d and %d", len(replays), second.ID, replay.ID)
+	}
+	traffic, err := services.NewEndpointService(database).GetProgramEndpointTraffic(ctx, program.ID)
+	if err != nil {
+		t.Fatalf("GetProgramEndpointTraffic: %v", err)
+	}
+	if traffic[endpoint.ID].RequestCount != 1 {
+		t.Errorf("endpoint traffic counts %d requests, want only the original", traffic[endpoint.ID].RequestCount)
+	}
+
+	diff, err := services.NewDiffService(database).Compare(ctx, original.ID, replay.ID)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	if !diff.ResBody.IsJSON || len(diff.ResBody.JSONChanges) != 1 {
+		t.Fatalf("response body diff = %+v, want one JSON change", diff.ResBody)
+	}
+	change := diff.ResBody.JSONChanges[0]
+	if change.Path != "$.role" || change.Op != requests.DiffChange || change.Old != `"admin"` || change.New != `"guest"` {
+		t.Errorf("JSON change = %+v, want $.role changed from admin to guest", change)
+	}
+	if !requests.HasChanges(diff.ReqHeaders) {
+		t.Error("request header diff shows no change of Authorization")
+	}
+}
+
+func TestReplayRejectsOversizedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chunk := make([]byte, 1<<20)
+		for written := 0; written <= requests.MaxBodySize; written += len(chunk) {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	db := testdb.Open(t)
+	job := requests.ImportJob{Title: "original", Status: requests.ImportJobStatusDone}
+	if err := db.Create(&job).Error; err != nil {
+		t.Fatal(err)
+	}
+	original := requests.MyRequest{ImportJobID: job.ID, URL: server.URL + "/big", Method: "GET", Domain: "127.0.0.1", ResStatus: http.StatusOK}
+	if err := db.Create(&original).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	database := services.NewGormDatabaseAdapter(db)
+	replayService := services.NewReplayService(database, services.NewEndpointService(database), server.Client())
+	if _, err := replayService.Replay(context.Background(), original.ID, services.ReplayEdit{Method: "GET", URL: server.URL + "/big"}); err == nil {
+		t.Fatal("Replay stored a response larger than MaxBodySize")
+	}
+	var count int64
+	db.Model(&requests.MyRequest{}).Where("replay_of_id = ?", original.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("%d replays stored, want none", count)
+	}
+}
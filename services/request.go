@@ -7,6 +7,9 @@ import (
 	"strings"
 )
 
+// maxSearchBodyRefs caps the distinct response bodies a search may match, they are passed to the query as a list
+const maxSearchBodyRefs = 5000
+
 // RequestService handles request-related operations
 type RequestService struct {
 	db Database
@@ -96,7 +99,7 @@ func (s *RequestService) GetRequestsByEndpointWithOrderAndSearch(ctx context.Con
 func (s *RequestService) GetRequestByID(ctx context.Context, id uint) (*requests.MyRequest, error) {
 	var request requests.MyRequest
 	if err := s.db.WithContext(ctx).First(&request, id).Error(); err != nil {
-		return nil, fmt.Errorf("failed to fetch request with ID %d: %v", id, err)
+		return nil, fmt.Errorf("failed to fetch request with ID %d: %w", id, err)
 	}
 	return &request, nil
 }
@@ -143,8 +146,9 @@ func (s *RequestService) buildOrderClause(orderBy, direction string) string {
 	}
 }
 
-// GetRequestsByImportJobWithMultiOrderAndSearch fetches requests for a specific import job with multiple ordering and search
-func (s *RequestService) GetRequestsByImportJobWithMultiOrderAndSearch(ctx context.Context, importJobID uint, orders []OrderClause, search string) ([]requests.MyRequest, error) {
+// GetRequestsByImportJobWithMultiOrderAndSearch fetches requests for a specific import job with multiple ordering and search,
+// leaving out the requests flagged as outside the program scope when inScopeOnly is set
+func (s *RequestService) GetRequestsByImportJobWithMultiOrderAndSearch(ctx context.Context, importJobID uint, orders []OrderClause, search string, inScopeOnly bool) ([]requests.MyRequest, error) {
 	var reqs []requests.MyRequest
 
 	// Build the order clause
@@ -156,6 +160,9 @@ func (s *RequestService) GetRequestsByImportJobWithMultiOrderAndSearch(ctx conte
 	if search != "" {
 		query = query.Where("url LIKE ? OR method LIKE ? OR domain LIKE ?", "%"+search+"%", "%"+search+"%", "%"+search+"%")
 	}
+	if inScopeOnly {
+		query = query.Where("out_of_scope = ?", false)
+	}
 
 	if err := query.Order(orderClause).Find(&reqs).Error(); err != nil {
 		return nil, fmt.Errorf("failed to fetch requests for job %d: %v", importJobID, err)
@@ -163,8 +170,9 @@ func (s *RequestService) GetRequestsByImportJobWithMultiOrderAndSearch(ctx conte
 	return reqs, nil
 }
 
-// GetRequestsByEndpointWithMultiOrderAndSearch fetches requests for a specific endpoint with multiple ordering and search
-func (s *RequestService) GetRequestsByEndpointWithMultiOrderAndSearch(ctx context.Context, endpointID uint, orders []OrderClause, search string) ([]requests.MyRequest, error) {
+// GetRequestsByEndpointWithMultiOrderAndSearch fetches requests for a specific endpoint with multiple ordering and search,
+// leaving out the requests flagged as outside the program scope when inScopeOnly is set
+func (s *RequestService) GetRequestsByEndpointWithMultiOrderAndSearch(ctx context.Context, endpointID uint, orders []OrderClause, search string, inScopeOnly bool) ([]requests.MyRequest, error) {
 	var reqs []requests.MyRequest
 
 	// Build the order clause
@@ -176,6 +184,9 @@ func (s *RequestService) GetRequestsByEndpointWithMultiOrderAndSearch(ctx contex
 	if search != "" {
 		query = query.Where("url LIKE ? OR method LIKE ? OR domain LIKE ?", "%"+search+"%", "%"+search+"%", "%"+search+"%")
 	}
+	if inScopeOnly {
+		query = query.Where("out_of_scope = ?", false)
+	}
 
 	if err := query.Order(orderClause).Find(&reqs).Error(); err != nil {
 		return nil, fmt.Errorf("failed to fetch requests for endpoint %d: %v", endpointID, err)
@@ -238,7 +249,158 @@ func (s *RequestService) mapColumnToField(column string) string {
 		return "domain"
 	case "resp_size":
 		return "resp_size"
+	case "sequence":
+		return "sequence"
+	case "id":
+		return "id"
 	default:
 		return ""
 	}
 }
+
+// RequestFilter selects requests by any combination of import job, endpoints, program, search term and hash
+type RequestFilter struct {
+	ImportJobID  uint
+	EndpointIDs  []uint
+	ProgramID    uint
+	Search       string
+	SearchBodies bool // also search the headers and bodies, not just the URL, method and domain
+	InScopeOnly  bool
+	HashKind     requests.HashKind
+	Hash         string // only requests whose hash of HashKind equals it
+}
+
+// IsScoped reports whether filter names an import job, endpoint or program, as reading without paging requires
+func (filter RequestFilter) IsScoped() bool {
+	return filter.ImportJobID != 0 || len(filter.EndpointIDs) > 0 || filter.ProgramID != 0
+}
+
+// FindRequestsInBatches calls fn with the requests matching filter, batchSize at a time in the order they were stored.
+// The filter has to be scoped, only one batch is held in memory.
+func (s *RequestService) FindRequestsInBatches(ctx context.Context, filter RequestFilter, batchSize int, fn func([]requests.MyRequest) error) error {
+	if !filter.IsScoped() {
+		return fmt.Errorf("%w: an import job, endpoint or program is required", ErrInvalid)
+	}
+
+	bodyRefs, err := s.searchBodyRefs(ctx, filter)
+	if err != nil {
+		return err
+	}
+	var batch []requests.MyRequest
+	return s.filterQuery(ctx, filter, bodyRefs).FindInBatches(&batch, batchSize, func(int) error {
+		return fn(batch)
+	}).Error()
+}
+
+// FindRequestsPage fetches one page of the requests matching filter, along with the number of matching requests.
+// Unlike FindRequestsInBatches the filter may be empty, the page keeps the result small.
+func (s *RequestService) FindRequestsPage(ctx context.Context, filter RequestFilter, orders []OrderClause, offset, limit int) ([]requests.MyRequest, int64, error) {
+	bodyRefs, err := s.searchBodyRefs(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.countRequests(ctx, filter, bodyRefs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var reqs []requests.MyRequest
+	if err := s.filterQuery(ctx, filter, bodyRefs).Order(s.buildMultiOrderClause(orders)).Offset(offset).Limit(limit).Find(&reqs).Error(); err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch requests: %v", err)
+	}
+	return reqs, total, nil
+}
+
+// CountRequests counts the requests matching filter
+func (s *RequestService) CountRequests(ctx context.Context, filter RequestFilter) (int64, error) {
+	bodyRefs, err := s.searchBodyRefs(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return s.countRequests(ctx, filter, bodyRefs)
+}
+
+// countRequests counts the requests matching filter, given the bodies matching its search
+func (s *RequestService) countRequests(ctx context.Context, filter RequestFilter, bodyRefs []string) (int64, error) {
+	var total int64
+	if err := s.filterQuery(ctx, filter, bodyRefs).Count(&total).Error(); err != nil {
+		return 0, fmt.Errorf("failed to count requests: %v", err)
+	}
+	return total, nil
+}
+
+// searchBodyRefs returns the response bodies containing the search term of filter when it searches bodies.
+// Only the bodies of the requests the rest of the filter selects are searched, a batch of distinct refs at a time.
+// Bodies are stored compressed, so each distinct body is decompressed and searched once, ignoring case like LIKE.
+func (s *RequestService) searchBodyRefs(ctx context.Context, filter RequestFilter) ([]string, error) {
+	if filter.Search == "" || !filter.SearchBodies {
+		return nil, nil
+	}
+	term := strings.ToLower(filter.Search)
+	scoped := filter
+	scoped.Search = ""
+
+	refs := []string{}
+	last := ""
+	for {
+		var batch []string
+		if err := s.filterQuery(ctx, scoped, nil).Where("res_body_ref > ?", last).Distinct("res_body_ref").
+			Order("res_body_ref ASC").Limit(bodyLookupSize).Pluck("res_body_ref", &batch).Error(); err != nil {
+			return nil, fmt.Errorf("failed to list response bodies: %v", err)
+		}
+		if len(batch) == 0 {
+			return refs, nil
+		}
+		last = batch[len(batch)-1]
+
+		var blobs []requests.BodyBlob
+		if err := s.db.WithContext(ctx).Where("hash IN ?", batch).Find(&blobs).Error(); err != nil {
+			return nil, fmt.Errorf("failed to search response bodies: %v", err)
+		}
+		for _, blob := range blobs {
+			body, err := blob.Body()
+			if err != nil {
+				return nil, err
+			}
+			if strings.Contains(strings.ToLower(body), term) {
+				refs = append(refs, blob.Hash)
+			}
+		}
+		if len(refs) > maxSearchBodyRefs {
+			return nil, fmt.Errorf("%w: %q is found in more than %d response bodies, narrow the search or the filter", ErrInvalid, filter.Search, maxSearchBodyRefs)
+		}
+		if len(batch) < bodyLookupSize {
+			return refs, nil
+		}
+	}
+}
+
+// filterQuery builds the conditions of filter, bodyRefs being the response bodies matching its search
+func (s *RequestService) filterQuery(ctx context.Context, filter RequestFilter, bodyRefs []string) Query {
+	query := s.db.WithContext(ctx).Model(&requests.MyRequest{})
+	if filter.ImportJobID != 0 {
+		query = query.Where("import_job_id = ?", filter.ImportJobID)
+	}
+	if len(filter.EndpointIDs) > 0 {
+		query = query.Where("endpoint_id IN ?", filter.EndpointIDs)
+	}
+	if filter.ProgramID != 0 {
+		query = query.Where("program_id = ?", filter.ProgramID)
+	}
+	if filter.InScopeOnly {
+		query = query.Where("out_of_scope = ?", false)
+	}
+	if filter.Hash != "" {
+		query = query.Where(filter.HashKind.Column()+" = ?", filter.Hash)
+	}
+	if filter.Search != "" {
+		term := "%" + filter.Search + "%"
+		if filter.SearchBodies {
+			query = query.Where("url LIKE ? OR method LIKE ? OR domain LIKE ? OR req_headers LIKE ? OR req_body LIKE ? OR res_headers LIKE ? OR res_body_ref IN ?",
+				term, term, term, term, term, term, bodyRefs)
+		} else {
+			query = query.Where("url LIKE ? OR method LIKE ? OR domain LIKE ?", term, term, term)
+		}
+	}
+	return query
+}
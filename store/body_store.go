@@ -0,0 +1,163 @@
+package store
+
+import (
+	"fmt"
+	"linn221/Requester/requests"
+	"log"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// bodyLookupSize is the number of body blobs fetched per query
+	bodyLookupSize = 500
+	// blobBatchSize is the number of new body blobs inserted per statement
+	blobBatchSize = 100
+)
+
+// BodyStore keeps the response bodies of requests in the body_blobs table, compressed and stored once per content.
+// It is a gorm plugin so every create of a MyRequest stores its body and every query fetching requests loads it,
+// the services read and write ResBody as if it were a column.
+type BodyStore struct{}
+
+// Name names the plugin for gorm
+func (BodyStore) Name() string {
+	return "requester:body_store"
+}
+
+// Initialize registers the create and query callbacks
+func (BodyStore) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("requester:store_bodies", storeBodies); err != nil {
+		return err
+	}
+	return db.Callback().Query().After("gorm:query").Register("requester:load_bodies", loadBodies)
+}
+
+// storeBodies points the requests being created at the blobs of their bodies, saving the blobs not stored yet
+func storeBodies(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	bodies := make(map[string]string)
+	for _, request := range statementRequests(db) {
+		request.ResBodyRef = requests.BodyRef(request.ResBody)
+		if request.ResBodyRef != "" {
+			bodies[request.ResBodyRef] = request.ResBody
+		}
+	}
+	if len(bodies) == 0 {
+		return
+	}
+	if err := saveBodies(db.Session(&gorm.Session{NewDB: true}), bodies); err != nil {
+		db.AddError(err)
+	}
+}
+
+// saveBodies compresses and stores the bodies by ref that are not stored yet.
+// Concurrent imports may store the same body, the first one wins.
+func saveBodies(tx *gorm.DB, bodies map[string]string) error {
+	refs := make([]string, 0, len(bodies))
+	for ref := range bodies {
+		refs = append(refs, ref)
+	}
+	var stored []string
+	if err := tx.Model(&requests.BodyBlob{}).Where("hash IN ?", refs).Pluck("hash", &stored).Error; err != nil {
+		return fmt.Errorf("failed to look up response bodies: %v", err)
+	}
+	for _, ref := range stored {
+		delete(bodies, ref)
+	}
+
+	blobs := make([]requests.BodyBlob, 0, len(bodies))
+	for _, body := range bodies {
+		blob, err := requests.NewBodyBlob(body)
+		if err != nil {
+			return err
+		}
+		blobs = append(blobs, blob)
+	}
+	if len(blobs) == 0 {
+		return nil
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(blobs, blobBatchSize).Error; err != nil {
+		return fmt.Errorf("failed to save response bodies: %v", err)
+	}
+	return nil
+}
+
+// loadBodies fills in the response bodies of the fetched requests.
+// Queries selecting only some columns leave ResBodyRef, and so ResBody, empty.
+func loadBodies(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	fetched := statementRequests(db)
+	seen := make(map[string]bool)
+	var refs []string
+	for _, request := range fetched {
+		if request.ResBodyRef != "" && !seen[request.ResBodyRef] {
+			seen[request.ResBodyRef] = true
+			refs = append(refs, request.ResBodyRef)
+		}
+	}
+	if len(refs) == 0 {
+		return
+	}
+
+	tx := db.Session(&gorm.Session{NewDB: true})
+	bodies := make(map[string]string, len(refs))
+	for start := 0; start < len(refs); start += bodyLookupSize {
+		end := min(start+bodyLookupSize, len(refs))
+		var blobs []requests.BodyBlob
+		if err := tx.Where("hash IN ?", refs[start:end]).Find(&blobs).Error; err != nil {
+			db.AddError(fmt.Errorf("failed to load response bodies: %v", err))
+			return
+		}
+		for _, blob := range blobs {
+			body, err := blob.Body()
+			if err != nil {
+				db.AddError(err)
+				return
+			}
+			bodies[blob.Hash] = body
+		}
+	}
+
+	// A missing blob leaves only its requests without a body instead of failing the whole query
+	for _, request := range fetched {
+		if request.ResBodyRef == "" {
+			continue
+		}
+		body, ok := bodies[request.ResBodyRef]
+		if !ok {
+			log.Printf("[BODY STORE] response body %s of request %d is missing", request.ResBodyRef, request.ID)
+			continue
+		}
+		request.ResBody = body
+	}
+}
+
+// statementRequests returns the requests a statement creates or fetched, if its value holds any
+func statementRequests(db *gorm.DB) []*requests.MyRequest {
+	value := db.Statement.ReflectValue
+	var found []*requests.MyRequest
+	add := func(v reflect.Value) {
+		v = reflect.Indirect(v)
+		if v.IsValid() && v.CanAddr() {
+			if request, ok := v.Addr().Interface().(*requests.MyRequest); ok {
+				found = append(found, request)
+			}
+		}
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			add(value.Index(i))
+		}
+	case reflect.Struct:
+		add(value)
+	}
+	return found
+}
@@ -36,56 +36,79 @@ func RequestFilters(filterState FilterState, endpoints []requests.Endpoint, prog
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"bg-white rounded-lg shadow p-6 mb-6\" x-data=\"{ showAdvanced: false }\"><form hx-get=\"/requests\" hx-target=\"main\" hx-push-url=\"true\" hx-indicator=\"#loading-indicator\"><!-- Search Bar (Full Width) --><div class=\"mb-4\"><label for=\"search\" class=\"block text-sm font-medium text-gray-700 mb-2\">Search Requests</label> <input type=\"text\" id=\"search\" name=\"search\" value=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"bg-white rounded-lg shadow p-6 mb-6\" x-data=\"{ showAdvanced: false }\"><form hx-get=\"/requests\" hx-target=\"main\" hx-push-url=\"true\" hx-indicator=\"#loading-indicator\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var2 string
-		templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(filterState.Search)
+		if filterState.ImportJobID != "" {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<input type=\"hidden\" name=\"import_job_id\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var2 string
+			templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(filterState.ImportJobID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 14, Col: 77}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<!-- Search Bar (Full Width) --><div class=\"mb-4\"><label for=\"search\" class=\"block text-sm font-medium text-gray-700 mb-2\">Search Requests</label> <input type=\"text\" id=\"search\" name=\"search\" value=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var3 string
+		templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(filterState.Search)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 20, Col: 31}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 23, Col: 31}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "\" placeholder=\"Search by URL, headers, body content (regex supported)...\" class=\"w-full px-4 py-2 border border-gray-300 rounded-md focus:ring-blue-500 focus:border-blue-500\" hx-trigger=\"input changed delay:500ms, search\" hx-get=\"/requests\" hx-target=\"main\" hx-push-url=\"true\"></div><div class=\"grid grid-cols-1 md:grid-cols-2 lg:grid-cols-4 gap-4 mb-4\"><!-- Program Filter --><div><label for=\"program_id\" class=\"block text-sm font-medium text-gray-700 mb-1\">Program</label> <select id=\"program_id\" name=\"program_id\" class=\"w-full px-3 py-2 border border-gray-300 rounded-md focus:ring-blue-500 focus:border-blue-500\"><option value=\"\">All Programs</option> ")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "\" placeholder=\"Search by URL, headers, body content (regex supported)...\" class=\"w-full px-4 py-2 border border-gray-300 rounded-md focus:ring-blue-500 focus:border-blue-500\" hx-trigger=\"input changed delay:500ms, search\" hx-get=\"/requests\" hx-target=\"main\" hx-push-url=\"true\"></div><div class=\"grid grid-cols-1 md:grid-cols-2 lg:grid-cols-4 gap-4 mb-4\"><!-- Program Filter --><div><label for=\"program_id\" class=\"block text-sm font-medium text-gray-700 mb-1\">Program</label> <select id=\"program_id\" name=\"program_id\" class=\"w-full px-3 py-2 border border-gray-300 rounded-md focus:ring-blue-500 focus:border-blue-500\"><option value=\"\">All Programs</option> ")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		for _, program := range programs {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<option value=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "<option value=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var3 string
-			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(strconv.Itoa(int(program.ID)))
+			var templ_7745c5c3_Var4 string
+			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(strconv.Itoa(int(program.ID)))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 37, Col: 52}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 40, Col: 52}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var4 string
-			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(program.Name)
+			var templ_7745c5c3_Var5 string
+			templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(program.Name)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 37, Col: 69}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 40, Col: 69}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</option>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "</option>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "</select></div><!-- HTTP Method Filter --><div><label class=\"block text-sm font-medium text-gray-700 mb-1\">HTTP Methods</label><div class=\"space-y-1\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "</select></div><!-- HTTP Method Filter --><div><label class=\"block text-sm font-medium text-gray-700 mb-1\">HTTP Methods</label><div class=\"space-y-1\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -113,7 +136,7 @@ func RequestFilters(filterState FilterState, endpoints []requests.Endpoint, prog
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "</div></div><!-- Status Code Filter --><div><label class=\"block text-sm font-medium text-gray-700 mb-1\">Status Codes</label><div class=\"space-y-1\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "</div></div><!-- Status Code Filter --><div><label class=\"block text-sm font-medium text-gray-700 mb-1\">Status Codes</label><div class=\"space-y-1\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -133,33 +156,33 @@ func RequestFilters(filterState FilterState, endpoints []requests.Endpoint, prog
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "</div></div><!-- Size Filter --><div><label class=\"block text-sm font-medium text-gray-700 mb-1\">Response Size</label><div class=\"space-y-2\"><input type=\"number\" name=\"size_min\" value=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</div></div><!-- Size Filter --><div><label class=\"block text-sm font-medium text-gray-700 mb-1\">Response Size</label><div class=\"space-y-2\"><input type=\"number\" name=\"size_min\" value=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var5 string
-		templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(filterState.SizeMin)
+		var templ_7745c5c3_Var6 string
+		templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(filterState.SizeMin)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 73, Col: 34}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 76, Col: 34}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "\" placeholder=\"Min bytes\" class=\"w-full px-2 py-1 text-sm border border-gray-300 rounded focus:ring-blue-500 focus:border-blue-500\"> <input type=\"number\" name=\"size_max\" value=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "\" placeholder=\"Min bytes\" class=\"w-full px-2 py-1 text-sm border border-gray-300 rounded focus:ring-blue-500 focus:border-blue-500\"> <input type=\"number\" name=\"size_max\" value=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var6 string
-		templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(filterState.SizeMax)
+		var templ_7745c5c3_Var7 string
+		templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(filterState.SizeMax)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 80, Col: 34}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 83, Col: 34}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "\" placeholder=\"Max bytes\" class=\"w-full px-2 py-1 text-sm border border-gray-300 rounded focus:ring-blue-500 focus:border-blue-500\"></div></div></div><!-- Endpoint Filter with Text Suggestions --><div class=\"mb-4\"><label class=\"block text-sm font-medium text-gray-700 mb-2\">Endpoints</label>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "\" placeholder=\"Max bytes\" class=\"w-full px-2 py-1 text-sm border border-gray-300 rounded focus:ring-blue-500 focus:border-blue-500\"></div></div></div><!-- Endpoint Filter with Text Suggestions --><div class=\"mb-4\"><label class=\"block text-sm font-medium text-gray-700 mb-2\">Endpoints</label>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -167,7 +190,17 @@ func RequestFilters(filterState FilterState, endpoints []requests.Endpoint, prog
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</div><!-- Advanced Filters Toggle --><div class=\"mb-4\"><button type=\"button\" @click=\"showAdvanced = !showAdvanced\" class=\"text-sm text-blue-600 hover:text-blue-800\"><span x-text=\"showAdvanced ? 'Hide Advanced Filters' : 'Show Advanced Filters'\"></span></button></div><!-- Advanced Filters --><div x-show=\"showAdvanced\" x-transition class=\"space-y-4 mb-4\"><!-- Content Type Filter --><div><label class=\"block text-sm font-medium text-gray-700 mb-1\">Content Types</label><div class=\"grid grid-cols-2 md:grid-cols-4 gap-2\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "</div><!-- Scope Filter --><div class=\"mb-4\"><label class=\"flex items-center\"><input type=\"checkbox\" name=\"in_scope\" value=\"true\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if filterState.InScopeOnly {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, " checked")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, " class=\"rounded border-gray-300 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700\">In scope only</span></label></div><!-- Advanced Filters Toggle --><div class=\"mb-4\"><button type=\"button\" @click=\"showAdvanced = !showAdvanced\" class=\"text-sm text-blue-600 hover:text-blue-800\"><span x-text=\"showAdvanced ? 'Hide Advanced Filters' : 'Show Advanced Filters'\"></span></button></div><!-- Advanced Filters --><div x-show=\"showAdvanced\" x-transition class=\"space-y-4 mb-4\"><!-- Content Type Filter --><div><label class=\"block text-sm font-medium text-gray-700 mb-1\">Content Types</label><div class=\"grid grid-cols-2 md:grid-cols-4 gap-2\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -195,25 +228,25 @@ func RequestFilters(filterState FilterState, endpoints []requests.Endpoint, prog
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "</div></div><!-- Sorting Options --><div><label class=\"block text-sm font-medium text-gray-700 mb-2\">Sort By</label><div class=\"grid grid-cols-1 md:grid-cols-2 gap-4\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "</div></div><!-- Sorting Options --><div><label class=\"block text-sm font-medium text-gray-700 mb-2\">Sort By</label><div class=\"grid grid-cols-1 md:grid-cols-2 gap-4\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		for i := 0; i < 2; i++ {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "<div class=\"flex space-x-2\"><select name=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "<div class=\"flex space-x-2\"><select name=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var7 string
-			templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("order_%d", i))
+			var templ_7745c5c3_Var8 string
+			templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("order_%d", i))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 122, Col: 49}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 139, Col: 49}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "\" class=\"flex-1 px-3 py-2 border border-gray-300 rounded-md focus:ring-blue-500 focus:border-blue-500\"><option value=\"\">Select field</option>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "\" class=\"flex-1 px-3 py-2 border border-gray-300 rounded-md focus:ring-blue-500 focus:border-blue-500\"><option value=\"\">Select field</option>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -241,40 +274,40 @@ func RequestFilters(filterState FilterState, endpoints []requests.Endpoint, prog
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "</select> <select name=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "</select> <select name=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var8 string
-			templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("direction_%d", i))
+			var templ_7745c5c3_Var9 string
+			templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("direction_%d", i))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 131, Col: 53}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 148, Col: 53}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "\" class=\"px-3 py-2 border border-gray-300 rounded-md focus:ring-blue-500 focus:border-blue-500\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "\" class=\"px-3 py-2 border border-gray-300 rounded-md focus:ring-blue-500 focus:border-blue-500\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			if len(filterState.Orders) > i && filterState.Orders[i].Direction == "ASC" {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "<option value=\"ASC\" selected>Ascending</option> <option value=\"DESC\">Descending</option>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "<option value=\"ASC\" selected>Ascending</option> <option value=\"DESC\">Descending</option>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			} else {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "<option value=\"ASC\">Ascending</option> <option value=\"DESC\" selected>Descending</option>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, "<option value=\"ASC\">Ascending</option> <option value=\"DESC\" selected>Descending</option>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "</select></div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "</select></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "</div></div></div><!-- Action Buttons (Right Side) --><div class=\"flex justify-end space-x-3\"><button type=\"button\" onclick=\"this.form.reset(); htmx.trigger(this.form, 'submit')\" class=\"px-4 py-2 text-sm font-medium text-gray-700 bg-white border border-gray-300 rounded-md hover:bg-gray-50 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500\">Clear Filters</button> <button type=\"submit\" class=\"px-4 py-2 text-sm font-medium text-white bg-blue-600 border border-transparent rounded-md hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500\">Apply Filters</button></div></form></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, "</div></div></div><!-- Action Buttons (Right Side) --><div class=\"flex justify-end space-x-3\"><button type=\"button\" onclick=\"this.form.reset(); htmx.trigger(this.form, 'submit')\" class=\"px-4 py-2 text-sm font-medium text-gray-700 bg-white border border-gray-300 rounded-md hover:bg-gray-50 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500\">Clear Filters</button> <button type=\"submit\" class=\"px-4 py-2 text-sm font-medium text-white bg-blue-600 border border-transparent rounded-md hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500\">Apply Filters</button></div></form></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -299,48 +332,48 @@ func MethodCheckbox(method string, selectedMethods []string) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var9 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var9 == nil {
-			templ_7745c5c3_Var9 = templ.NopComponent
+		templ_7745c5c3_Var10 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var10 == nil {
+			templ_7745c5c3_Var10 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "<label class=\"flex items-center\"><input type=\"checkbox\" name=\"methods[]\" value=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, "<label class=\"flex items-center\"><input type=\"checkbox\" name=\"methods[]\" value=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var10 string
-		templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(method)
+		var templ_7745c5c3_Var11 string
+		templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(method)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 172, Col: 17}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 189, Col: 17}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, "\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		if contains(selectedMethods, method) {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, " checked")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, " checked")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, " class=\"rounded border-gray-300 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, " class=\"rounded border-gray-300 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var11 string
-		templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(method)
+		var templ_7745c5c3_Var12 string
+		templ_7745c5c3_Var12, templ_7745c5c3_Err = templ.JoinStringErrs(method)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 176, Col: 51}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 193, Col: 51}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var12))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, "</span></label>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, "</span></label>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -365,48 +398,48 @@ func StatusCheckbox(status string, selectedStatuses []string) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var12 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var12 == nil {
-			templ_7745c5c3_Var12 = templ.NopComponent
+		templ_7745c5c3_Var13 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var13 == nil {
+			templ_7745c5c3_Var13 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, "<label class=\"flex items-center\"><input type=\"checkbox\" name=\"statuses[]\" value=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, "<label class=\"flex items-center\"><input type=\"checkbox\" name=\"statuses[]\" value=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var13 string
-		templ_7745c5c3_Var13, templ_7745c5c3_Err = templ.JoinStringErrs(status)
+		var templ_7745c5c3_Var14 string
+		templ_7745c5c3_Var14, templ_7745c5c3_Err = templ.JoinStringErrs(status)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 186, Col: 17}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 203, Col: 17}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var13))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var14))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, "\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 32, "\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		if contains(selectedStatuses, status) {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, " checked")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 33, " checked")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, " class=\"rounded border-gray-300 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 34, " class=\"rounded border-gray-300 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var14 string
-		templ_7745c5c3_Var14, templ_7745c5c3_Err = templ.JoinStringErrs(status)
+		var templ_7745c5c3_Var15 string
+		templ_7745c5c3_Var15, templ_7745c5c3_Err = templ.JoinStringErrs(status)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 190, Col: 51}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 207, Col: 51}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var14))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var15))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, "</span></label>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, "</span></label>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -431,48 +464,48 @@ func ContentTypeCheckbox(contentType string, selectedTypes []string) templ.Compo
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var15 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var15 == nil {
-			templ_7745c5c3_Var15 = templ.NopComponent
+		templ_7745c5c3_Var16 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var16 == nil {
+			templ_7745c5c3_Var16 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, "<label class=\"flex items-center\"><input type=\"checkbox\" name=\"types[]\" value=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, "<label class=\"flex items-center\"><input type=\"checkbox\" name=\"types[]\" value=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var16 string
-		templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs(contentType)
+		var templ_7745c5c3_Var17 string
+		templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(contentType)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 200, Col: 22}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 217, Col: 22}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 32, "\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, "\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		if contains(selectedTypes, contentType) {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 33, " checked")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, " checked")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 34, " class=\"rounded border-gray-300 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, " class=\"rounded border-gray-300 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var17 string
-		templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(contentType)
+		var templ_7745c5c3_Var18 string
+		templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs(contentType)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 204, Col: 56}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 221, Col: 56}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, "</span></label>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "</span></label>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -497,70 +530,70 @@ func SortOption(value, label string, orders []OrderClause, index int) templ.Comp
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var18 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var18 == nil {
-			templ_7745c5c3_Var18 = templ.NopComponent
+		templ_7745c5c3_Var19 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var19 == nil {
+			templ_7745c5c3_Var19 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
 		if len(orders) > index && orders[index].Column == value {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, "<option value=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, "<option value=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var19 string
-			templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinStringErrs(value)
+			var templ_7745c5c3_Var20 string
+			templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(value)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 211, Col: 23}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 228, Col: 23}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, "\" selected>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "\" selected>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var20 string
-			templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(label)
+			var templ_7745c5c3_Var21 string
+			templ_7745c5c3_Var21, templ_7745c5c3_Err = templ.JoinStringErrs(label)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 211, Col: 42}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 228, Col: 42}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var21))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, "</option>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 43, "</option>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		} else {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, "<option value=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 44, "<option value=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var21 string
-			templ_7745c5c3_Var21, templ_7745c5c3_Err = templ.JoinStringErrs(value)
+			var templ_7745c5c3_Var22 string
+			templ_7745c5c3_Var22, templ_7745c5c3_Err = templ.JoinStringErrs(value)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 213, Col: 23}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 230, Col: 23}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var21))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var22))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 45, "\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var22 string
-			templ_7745c5c3_Var22, templ_7745c5c3_Err = templ.JoinStringErrs(label)
+			var templ_7745c5c3_Var23 string
+			templ_7745c5c3_Var23, templ_7745c5c3_Err = templ.JoinStringErrs(label)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 213, Col: 33}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `views/templates/components.templ`, Line: 230, Col: 33}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var22))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var23))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, "</option>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 46, "</option>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -586,12 +619,12 @@ func EndpointSelector(endpoints []requests.Endpoint, selectedEndpointIDs []strin
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var23 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var23 == nil {
-			templ_7745c5c3_Var23 = templ.NopComponent
+		templ_7745c5c3_Var24 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var24 == nil {
+			templ_7745c5c3_Var24 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "<div x-data=\"endpointSelector()\" x-init=\"init()\"><!-- Search input --><div class=\"relative\"><input type=\"text\" x-model=\"searchTerm\" @input=\"filterEndpoints()\" @focus=\"showDropdown = true\" @keydown.escape=\"showDropdown = false\" @keydown.arrow-down.prevent=\"navigateDown()\" @keydown.arrow-up.prevent=\"navigateUp()\" @keydown.enter.prevent=\"selectHighlighted()\" placeholder=\"Type to search endpoints...\" class=\"w-full px-4 py-2 border border-gray-300 rounded-md focus:ring-blue-500 focus:border-blue-500\"><!-- Dropdown suggestions --><div x-show=\"showDropdown && filteredEndpoints.length > 0\" x-transition:enter=\"transition ease-out duration-100\" x-transition:enter-start=\"transform opacity-0 scale-95\" x-transition:enter-end=\"transform opacity-100 scale-100\" x-transition:leave=\"transition ease-in duration-75\" x-transition:leave-start=\"transform opacity-100 scale-100\" x-transition:leave-end=\"transform opacity-0 scale-95\" class=\"absolute z-10 w-full mt-1 bg-white border border-gray-300 rounded-md shadow-lg max-h-60 overflow-auto\"><template x-for=\"(endpoint, index) in filteredEndpoints\" :key=\"endpoint.id\"><div @click=\"selectEndpoint(endpoint)\" :class=\"{'bg-blue-50': index === highlightedIndex}\" class=\"px-4 py-2 cursor-pointer hover:bg-gray-50 border-b border-gray-100 last:border-b-0\"><div class=\"flex items-center justify-between\"><div><span class=\"font-medium text-sm\" :class=\"getMethodColor(endpoint.method)\" x-text=\"endpoint.method\"></span> <span class=\"text-sm text-gray-600 ml-2\" x-text=\"endpoint.domain + endpoint.uri\"></span></div><div class=\"text-xs text-gray-400\" x-text=\"endpoint.type\"></div></div></div></template></div></div><!-- Selected endpoints --><div class=\"mt-2 space-y-1\" x-show=\"selectedEndpoints.length > 0\"><template x-for=\"endpoint in selectedEndpoints\" :key=\"endpoint.id\"><div class=\"flex items-center justify-between bg-blue-50 px-3 py-1 rounded\"><span class=\"text-sm\"><span class=\"font-medium\" :class=\"getMethodColor(endpoint.method)\" x-text=\"endpoint.method\"></span> <span class=\"text-gray-600 ml-2\" x-text=\"endpoint.domain + endpoint.uri\"></span></span> <button type=\"button\" @click=\"removeEndpoint(endpoint)\" class=\"text-red-500 hover:text-red-700\"><svg class=\"w-4 h-4\" fill=\"none\" stroke=\"currentColor\" viewBox=\"0 0 24 24\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M6 18L18 6M6 6l12 12\"></path></svg></button></div></template></div><!-- Hidden inputs for form submission --><template x-for=\"endpoint in selectedEndpoints\" :key=\"endpoint.id\"><input type=\"hidden\" name=\"endpoint_ids[]\" :value=\"endpoint.id\"></template></div><script>\n\t\tfunction endpointSelector() {\n\t\t\treturn {\n\t\t\t\tsearchTerm: '',\n\t\t\t\tshowDropdown: false,\n\t\t\t\thighlightedIndex: -1,\n\t\t\t\tfilteredEndpoints: [],\n\t\t\t\tselectedEndpoints: [],\n\t\t\t\tallEndpoints: [],\n\n\t\t\t\tinit() {\n\t\t\t\t\t// Initialize endpoints from server data\n\t\t\t\t\tthis.allEndpoints = [\n\t\t\t\t\t\tfor _, endpoint := range endpoints {\n\t\t\t\t\t\t\t{\n\t\t\t\t\t\t\t\tid: { strconv.Itoa(int(endpoint.ID)) },\n\t\t\t\t\t\t\t\tmethod: \"{ endpoint.Method }\",\n\t\t\t\t\t\t\t\tdomain: \"{ endpoint.Domain }\",\n\t\t\t\t\t\t\t\turi: \"{ endpoint.URI }\",\n\t\t\t\t\t\t\t\ttype: \"{ string(endpoint.EndpointType) }\",\n\t\t\t\t\t\t\t\tfullPath: \"{ endpoint.Method } { endpoint.Domain }{ endpoint.URI }\"\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t}\n\t\t\t\t\t];\n\n\t\t\t\t\t// Initialize selected endpoints from filterState\n\t\t\t\t\tconst selectedIDs = [\n\t\t\t\t\t\tfor _, id := range selectedEndpointIDs {\n\t\t\t\t\t\t\t\"{ id }\",\n\t\t\t\t\t\t}\n\t\t\t\t\t];\n\n\t\t\t\t\tthis.selectedEndpoints = this.allEndpoints.filter(ep => selectedIDs.includes(ep.id));\n\t\t\t\t\tthis.filterEndpoints();\n\n\t\t\t\t\t// Close dropdown when clicking outside\n\t\t\t\t\tdocument.addEventListener('click', (e) => {\n\t\t\t\t\t\tif (!this.$el.contains(e.target)) {\n\t\t\t\t\t\t\tthis.showDropdown = false;\n\t\t\t\t\t\t}\n\t\t\t\t\t});\n\t\t\t\t},\n\n\t\t\t\tfilterEndpoints() {\n\t\t\t\t\tif (!this.searchTerm) {\n\t\t\t\t\t\tthis.filteredEndpoints = this.allEndpoints.filter(ep => \n\t\t\t\t\t\t\t!this.selectedEndpoints.some(selected => selected.id === ep.id)\n\t\t\t\t\t\t).slice(0, 10);\n\t\t\t\t\t} else {\n\t\t\t\t\t\tconst term = this.searchTerm.toLowerCase();\n\t\t\t\t\t\tthis.filteredEndpoints = this.allEndpoints\n\t\t\t\t\t\t\t.filter(ep => \n\t\t\t\t\t\t\t\t!this.selectedEndpoints.some(selected => selected.id === ep.id) &&\n\t\t\t\t\t\t\t\tep.fullPath.toLowerCase().includes(term)\n\t\t\t\t\t\t\t)\n\t\t\t\t\t\t\t.slice(0, 10);\n\t\t\t\t\t}\n\t\t\t\t\tthis.highlightedIndex = -1;\n\t\t\t\t},\n\n\t\t\t\tnavigateDown() {\n\t\t\t\t\tthis.highlightedIndex = Math.min(this.highlightedIndex + 1, this.filteredEndpoints.length - 1);\n\t\t\t\t},\n\n\t\t\t\tnavigateUp() {\n\t\t\t\t\tthis.highlightedIndex = Math.max(this.highlightedIndex - 1, 0);\n\t\t\t\t},\n\n\t\t\t\tselectHighlighted() {\n\t\t\t\t\tif (this.highlightedIndex >= 0 && this.filteredEndpoints[this.highlightedIndex]) {\n\t\t\t\t\t\tthis.selectEndpoint(this.filteredEndpoints[this.highlightedIndex]);\n\t\t\t\t\t}\n\t\t\t\t},\n\n\t\t\t\tselectEndpoint(endpoint) {\n\t\t\t\t\tthis.selectedEndpoints.push(endpoint);\n\t\t\t\t\tthis.searchTerm = '';\n\t\t\t\t\tthis.showDropdown = false;\n\t\t\t\t\tthis.filterEndpoints();\n\t\t\t\t},\n\n\t\t\t\tremoveEndpoint(endpoint) {\n\t\t\t\t\tthis.selectedEndpoints = this.selectedEndpoints.filter(ep => ep.id !== endpoint.id);\n\t\t\t\t\tthis.filterEndpoints();\n\t\t\t\t},\n\n\t\t\t\tgetMethodColor(method) {\n\t\t\t\t\tconst colors = {\n\t\t\t\t\t\t'GET': 'text-green-600',\n\t\t\t\t\t\t'POST': 'text-blue-600',\n\t\t\t\t\t\t'PUT': 'text-yellow-600',\n\t\t\t\t\t\t'DELETE': 'text-red-600',\n\t\t\t\t\t\t'PATCH': 'text-purple-600',\n\t\t\t\t\t\t'OPTIONS': 'text-gray-600'\n\t\t\t\t\t};\n\t\t\t\t\treturn colors[method] || 'text-gray-600';\n\t\t\t\t}\n\t\t\t}\n\t\t}\n\t</script>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 47, "<div x-data=\"endpointSelector()\" x-init=\"init()\"><!-- Search input --><div class=\"relative\"><input type=\"text\" x-model=\"searchTerm\" @input=\"filterEndpoints()\" @focus=\"showDropdown = true\" @keydown.escape=\"showDropdown = false\" @keydown.arrow-down.prevent=\"navigateDown()\" @keydown.arrow-up.prevent=\"navigateUp()\" @keydown.enter.prevent=\"selectHighlighted()\" placeholder=\"Type to search endpoints...\" class=\"w-full px-4 py-2 border border-gray-300 rounded-md focus:ring-blue-500 focus:border-blue-500\"><!-- Dropdown suggestions --><div x-show=\"showDropdown && filteredEndpoints.length > 0\" x-transition:enter=\"transition ease-out duration-100\" x-transition:enter-start=\"transform opacity-0 scale-95\" x-transition:enter-end=\"transform opacity-100 scale-100\" x-transition:leave=\"transition ease-in duration-75\" x-transition:leave-start=\"transform opacity-100 scale-100\" x-transition:leave-end=\"transform opacity-0 scale-95\" class=\"absolute z-10 w-full mt-1 bg-white border border-gray-300 rounded-md shadow-lg max-h-60 overflow-auto\"><template x-for=\"(endpoint, index) in filteredEndpoints\" :key=\"endpoint.id\"><div @click=\"selectEndpoint(endpoint)\" :class=\"{'bg-blue-50': index === highlightedIndex}\" class=\"px-4 py-2 cursor-pointer hover:bg-gray-50 border-b border-gray-100 last:border-b-0\"><div class=\"flex items-center justify-between\"><div><span class=\"font-medium text-sm\" :class=\"getMethodColor(endpoint.method)\" x-text=\"endpoint.method\"></span> <span class=\"text-sm text-gray-600 ml-2\" x-text=\"endpoint.domain + endpoint.uri\"></span></div><div class=\"text-xs text-gray-400\" x-text=\"endpoint.type\"></div></div></div></template></div></div><!-- Selected endpoints --><div class=\"mt-2 space-y-1\" x-show=\"selectedEndpoints.length > 0\"><template x-for=\"endpoint in selectedEndpoints\" :key=\"endpoint.id\"><div class=\"flex items-center justify-between bg-blue-50 px-3 py-1 rounded\"><span class=\"text-sm\"><span class=\"font-medium\" :class=\"getMethodColor(endpoint.method)\" x-text=\"endpoint.method\"></span> <span class=\"text-gray-600 ml-2\" x-text=\"endpoint.domain + endpoint.uri\"></span></span> <button type=\"button\" @click=\"removeEndpoint(endpoint)\" class=\"text-red-500 hover:text-red-700\"><svg class=\"w-4 h-4\" fill=\"none\" stroke=\"currentColor\" viewBox=\"0 0 24 24\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M6 18L18 6M6 6l12 12\"></path></svg></button></div></template></div><!-- Hidden inputs for form submission --><template x-for=\"endpoint in selectedEndpoints\" :key=\"endpoint.id\"><input type=\"hidden\" name=\"endpoint_ids[]\" :value=\"endpoint.id\"></template></div><script>\n\t\tfunction endpointSelector() {\n\t\t\treturn {\n\t\t\t\tsearchTerm: '',\n\t\t\t\tshowDropdown: false,\n\t\t\t\thighlightedIndex: -1,\n\t\t\t\tfilteredEndpoints: [],\n\t\t\t\tselectedEndpoints: [],\n\t\t\t\tallEndpoints: [],\n\n\t\t\t\tinit() {\n\t\t\t\t\t// Initialize endpoints from server data\n\t\t\t\t\tthis.allEndpoints = [\n\t\t\t\t\t\tfor _, endpoint := range endpoints {\n\t\t\t\t\t\t\t{\n\t\t\t\t\t\t\t\tid: { strconv.Itoa(int(endpoint.ID)) },\n\t\t\t\t\t\t\t\tmethod: \"{ endpoint.Method }\",\n\t\t\t\t\t\t\t\tdomain: \"{ endpoint.Domain }\",\n\t\t\t\t\t\t\t\turi: \"{ endpoint.URI }\",\n\t\t\t\t\t\t\t\ttype: \"{ string(endpoint.EndpointType) }\",\n\t\t\t\t\t\t\t\tfullPath: \"{ endpoint.Method } { endpoint.Domain }{ endpoint.URI }\"\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t}\n\t\t\t\t\t];\n\n\t\t\t\t\t// Initialize selected endpoints from filterState\n\t\t\t\t\tconst selectedIDs = [\n\t\t\t\t\t\tfor _, id := range selectedEndpointIDs {\n\t\t\t\t\t\t\t\"{ id }\",\n\t\t\t\t\t\t}\n\t\t\t\t\t];\n\n\t\t\t\t\tthis.selectedEndpoints = this.allEndpoints.filter(ep => selectedIDs.includes(ep.id));\n\t\t\t\t\tthis.filterEndpoints();\n\n\t\t\t\t\t// Close dropdown when clicking outside\n\t\t\t\t\tdocument.addEventListener('click', (e) => {\n\t\t\t\t\t\tif (!this.$el.contains(e.target)) {\n\t\t\t\t\t\t\tthis.showDropdown = false;\n\t\t\t\t\t\t}\n\t\t\t\t\t});\n\t\t\t\t},\n\n\t\t\t\tfilterEndpoints() {\n\t\t\t\t\tif (!this.searchTerm) {\n\t\t\t\t\t\tthis.filteredEndpoints = this.allEndpoints.filter(ep => \n\t\t\t\t\t\t\t!this.selectedEndpoints.some(selected => selected.id === ep.id)\n\t\t\t\t\t\t).slice(0, 10);\n\t\t\t\t\t} else {\n\t\t\t\t\t\tconst term = this.searchTerm.toLowerCase();\n\t\t\t\t\t\tthis.filteredEndpoints = this.allEndpoints\n\t\t\t\t\t\t\t.filter(ep => \n\t\t\t\t\t\t\t\t!this.selectedEndpoints.some(selected => selected.id === ep.id) &&\n\t\t\t\t\t\t\t\tep.fullPath.toLowerCase().includes(term)\n\t\t\t\t\t\t\t)\n\t\t\t\t\t\t\t.slice(0, 10);\n\t\t\t\t\t}\n\t\t\t\t\tthis.highlightedIndex = -1;\n\t\t\t\t},\n\n\t\t\t\tnavigateDown() {\n\t\t\t\t\tthis.highlightedIndex = Math.min(this.highlightedIndex + 1, this.filteredEndpoints.length - 1);\n\t\t\t\t},\n\n\t\t\t\tnavigateUp() {\n\t\t\t\t\tthis.highlightedIndex = Math.max(this.highlightedIndex - 1, 0);\n\t\t\t\t},\n\n\t\t\t\tselectHighlighted() {\n\t\t\t\t\tif (this.highlightedIndex >= 0 && this.filteredEndpoints[this.highlightedIndex]) {\n\t\t\t\t\t\tthis.selectEndpoint(this.filteredEndpoints[this.highlightedIndex]);\n\t\t\t\t\t}\n\t\t\t\t},\n\n\t\t\t\tselectEndpoint(endpoint) {\n\t\t\t\t\tthis.selectedEndpoints.push(endpoint);\n\t\t\t\t\tthis.searchTerm = '';\n\t\t\t\t\tthis.showDropdown = false;\n\t\t\t\t\tthis.filterEndpoints();\n\t\t\t\t},\n\n\t\t\t\tremoveEndpoint(endpoint) {\n\t\t\t\t\tthis.selectedEndpoints = this.selectedEndpoints.filter(ep => ep.id !== endpoint.id);\n\t\t\t\t\tthis.filterEndpoints();\n\t\t\t\t},\n\n\t\t\t\tgetMethodColor(method) {\n\t\t\t\t\tconst colors = {\n\t\t\t\t\t\t'GET': 'text-green-600',\n\t\t\t\t\t\t'POST': 'text-blue-600',\n\t\t\t\t\t\t'PUT': 'text-yellow-600',\n\t\t\t\t\t\t'DELETE': 'text-red-600',\n\t\t\t\t\t\t'PATCH': 'text-purple-600',\n\t\t\t\t\t\t'OPTIONS': 'text-gray-600'\n\t\t\t\t\t};\n\t\t\t\t\treturn colors[method] || 'text-gray-600';\n\t\t\t\t}\n\t\t\t}\n\t\t}\n\t</script>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -1,5 +1,7 @@
 package templates
 
+import "linn221/Requester/requests"
+
 // FilterState holds the current state of filters applied to the requests list
 type FilterState struct {
 	Search      string
@@ -11,6 +13,7 @@ type FilterState struct {
 	SizeMin     string
 	SizeMax     string
 	Orders      []OrderClause
+	InScopeOnly bool
 }
 
 // OrderClause represents a single order clause
@@ -34,11 +37,195 @@ type EndpointOption struct {
 	FullPath string
 }
 
-// ImportSummary represents the summary of an import operation
-type ImportSummary struct {
-	TotalRequests   int
-	UniqueEndpoints int
-	UniqueDomains   int
-	Methods         map[string]int
-	StatusCodes     map[string]int
+// EndpointListEntry is an endpoint with the traffic stored for it
+type EndpointListEntry struct {
+	Endpoint      requests.Endpoint
+	RequestCount  int64
+	ResponseCount int64
+	OutOfScope    bool // host or path is outside the scope rules of its program
+}
+
+// CoverageRow is a documented endpoint with the captured traffic matching it
+type CoverageRow struct {
+	Endpoint         requests.Endpoint
+	ResponseCount    int64
+	MatchedEndpoints int
+}
+
+// CoverageView summarizes which documented endpoints of a program were exercised
+type CoverageView struct {
+	Rows         []CoverageRow
+	Covered      int
+	Undocumented []requests.Endpoint
+	Message      string // shown after a document import
+}
+
+// ReplayView is the replay editor of a stored request and the replays sent from it
+type ReplayView struct {
+	Original requests.MyRequest
+	Headers  string // one "Name: value" line per request header
+	Replays  []requests.MyRequest
+}
+
+// DiffView compares two requests and their responses side by side
+type DiffView struct {
+	A, B           requests.MyRequest
+	IgnoredHeaders []string
+	ReqHeaders     DiffSection
+	ResHeaders     DiffSection
+	ReqBody        DiffSection
+	ResBody        DiffSection
+}
+
+// DiffSection is one compared part of the two requests
+type DiffSection struct {
+	Rows        []requests.DiffRow
+	Changed     bool
+	IsJSON      bool
+	JSONChanges []requests.JSONChange
+}
+
+// FuzzFormView is the fuzz form of a stored request with the insertion points found in it
+type FuzzFormView struct {
+	Request        requests.MyRequest
+	Points         []requests.InsertionPoint
+	IgnoredHeaders string // prefilled from the request's import job
+}
+
+// FuzzResultRow is one attempt of a fuzz run
+type FuzzResultRow struct {
+	Attempt     requests.FuzzAttempt
+	Request     requests.MyRequest
+	ClusterSize int
+	Outlier     bool
+}
+
+// FuzzResultsView lists the attempts of a fuzz run
+type FuzzResultsView struct {
+	Job      requests.ImportJob
+	Rows     []FuzzResultRow
+	Clusters int
+	Outliers int
+}
+
+// IdentityRow is an identity of a program with the names of the headers it changes
+type IdentityRow struct {
+	Identity    requests.Identity
+	HeaderNames []string
+}
+
+// IdentitiesView lists the identities of a program next to the form adding one
+type IdentitiesView struct {
+	Program    requests.Program
+	Identities []IdentityRow
+}
+
+// RehashFormView recomputes the hashes of an import job or of every job of a program
+type RehashFormView struct {
+	Source         string // the import job or program being rehashed
+	ImportJobID    uint
+	ProgramID      uint
+	IgnoredHeaders []string // current lists, prefilled in the form
+	IgnoredFields  []string
+}
+
+// AuthzFormView starts an authorization run over an import job or an endpoint
+type AuthzFormView struct {
+	Program     requests.Program
+	Source      string // the import job or endpoint being tested
+	ImportJobID uint
+	EndpointID  uint
+	Identities  []requests.Identity
+}
+
+// AuthzCell summarizes the variants of one endpoint sent as one identity
+type AuthzCell struct {
+	Variants  int
+	Flagged   int
+	Verdict   requests.AuthzVerdict
+	Statuses  []int
+	RequestID uint
+}
+
+// AuthzMatrixRow is an endpoint with one cell per identity
+type AuthzMatrixRow struct {
+	Endpoint         requests.Endpoint
+	Originals        int
+	OriginalStatuses []int
+	Cells            []AuthzCell
+}
+
+// AuthzMatrixView is the endpoint by identity matrix of an authorization run
+type AuthzMatrixView struct {
+	Job        requests.ImportJob
+	Identities []requests.Identity
+	Rows       []AuthzMatrixRow
+	Attempts   int
+	Flagged    int
+}
+
+// ClusterScopeView names the requests a cluster view groups
+type ClusterScopeView struct {
+	Title string
+	Query string // the scope's query parameters, such as import_job_id=3, carried by tabs and links
+}
+
+// HashClusterRow is a group of requests sharing a hash, shown by its first request
+type HashClusterRow struct {
+	Hash   string
+	Count  int64
+	Sample requests.MyRequest
+}
+
+// HashClustersView lists the hash clusters of an import job, endpoint or program
+type HashClustersView struct {
+	Scope         ClusterScopeView
+	Kind          requests.HashKind
+	Rows          []HashClusterRow
+	ClusterCount  int
+	UniqueCount   int
+	RequestCount  int64
+	LargestCount  int64
+	DuplicateRate int
+}
+
+// HashClusterRequestsView lists the requests sharing one hash
+type HashClusterRequestsView struct {
+	Scope    ClusterScopeView
+	Kind     requests.HashKind
+	Hash     string
+	Requests []requests.MyRequest // the current page
+	Total    int64                // requests sharing the hash in the scope
+	Page     int                  // 1-based
+	PerPage  int
+}
+
+// NotesPanelView holds the notes and attachments of a program, endpoint or request
+type NotesPanelView struct {
+	ReferenceType requests.ReferenceType
+	ReferenceID   uint
+	Notes         []requests.Note
+	Attachments   []requests.Attachment
+}
+
+// NoteSearchRow is a note found by the note search, with the record it belongs to
+type NoteSearchRow struct {
+	Note  requests.Note
+	Label string // the program name, endpoint path or request URL
+	Href  string
+}
+
+// NoteSearchView lists the notes matching a search across all programs, endpoints and requests
+type NoteSearchView struct {
+	Search        string
+	ReferenceType requests.ReferenceType
+	Rows          []NoteSearchRow
+	Total         int64
+}
+
+// ProgramDetailView shows a program with the counts of its records
+type ProgramDetailView struct {
+	Program       requests.Program
+	EndpointCount int
+	RequestCount  int64
 }